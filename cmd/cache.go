@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/cache"
+
+	"github.com/spf13/cobra"
+)
+
+var pruneAll bool
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "List and prune the on-disk caches",
+	Long: `Manages the on-disk caches of pullpoet:
+
+  responses  AI responses, identical requests are answered from it (cache in .pullpoet.yml)
+  issues     Issue tracker responses (issue_fetch in .pullpoet.yml)`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cached AI and issue tracker responses",
+	Args:  cobra.NoArgs,
+	RunE:  runCacheList,
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove expired entries and responses above the size limit",
+	Args:  cobra.NoArgs,
+	RunE:  runCachePrune,
+}
+
+func init() {
+	cachePruneCmd.Flags().BoolVar(&pruneAll, "all", false, "Remove all entries, not only the expired ones")
+
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cacheStores returns the caches by name with the TTL and size limit of the configuration
+func cacheStores() ([]string, map[string]*cache.Store) {
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to load config file: %v\n", err)
+		fileConfig = &config.FileConfig{UI: config.DefaultUIConfig()}
+	}
+	cfg := &config.Config{}
+	applyIssueConfig(cfg, fileConfig)
+	applyCacheConfig(cfg, fileConfig)
+	// Disabling a cache only stops its use, its entries still expire after the configured TTL
+	cfg.IssueCacheTTL = issueCacheTTL(fileConfig.IssueFetch)
+	cfg.ResponseCacheTTL = responseCacheTTL(fileConfig.Cache)
+
+	return []string{"responses", "issues"}, map[string]*cache.Store{
+		"responses": responseStore(cfg),
+		"issues":    cache.New(cache.DefaultDir("issues"), cfg.IssueCacheTTL),
+	}
+}
+
+func runCacheList(cmd *cobra.Command, args []string) error {
+	names, stores := cacheStores()
+	for _, name := range names {
+		store := stores[name]
+		entries, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		var size int64
+		for _, entry := range entries {
+			size += entry.Size
+		}
+		fmt.Printf("📦 %s: %d entries, %s (%s)\n", name, len(entries), formatBytes(size), store.Dir())
+		for _, entry := range entries {
+			status := ""
+			if entry.Expired {
+				status = " [expired]"
+			}
+			fmt.Printf("   %s  %8s  %s%s%s\n", entry.Name[:12], formatBytes(entry.Size), entry.Modified.Format("2006-01-02 15:04"), describeEntry(name, entry), status)
+		}
+	}
+	return nil
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	names, stores := cacheStores()
+	for _, name := range names {
+		var removed int
+		var err error
+		if pruneAll {
+			removed, err = stores[name].Clear()
+		} else {
+			removed, err = stores[name].Prune()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to prune %s cache: %w", name, err)
+		}
+		fmt.Printf("🧹 %s: removed %d entries\n", name, removed)
+	}
+	return nil
+}
+
+// describeEntry returns the provider, model and token usage of a cached AI response
+func describeEntry(name string, entry cache.Entry) string {
+	if name != "responses" {
+		return ""
+	}
+	data, err := os.ReadFile(entry.Path)
+	if err != nil {
+		return ""
+	}
+	var cached ai.CacheEntry
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return ""
+	}
+	return fmt.Sprintf("  %s/%s, %d tokens", cached.Provider, cached.Model, cached.Usage.TotalTokens)
+}
+
+// formatBytes formats a size in B, KB or MB
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-
-	"pullpoet/config"
-	"pullpoet/internal/ai"
-	"pullpoet/internal/clickup"
-	"pullpoet/internal/git"
-	"pullpoet/internal/jira"
-	"pullpoet/internal/pr"
-	"pullpoet/internal/ui"
+	"time"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/cache"
+	"github.com/erkineren/pullpoet/internal/ci"
+	"github.com/erkineren/pullpoet/internal/forge"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/issues"
+	"github.com/erkineren/pullpoet/internal/output"
+	"github.com/erkineren/pullpoet/internal/pr"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+	"github.com/erkineren/pullpoet/internal/ui"
 
 	"github.com/spf13/cobra"
 )
@@ -33,14 +41,51 @@ var (
 	outputFile      string
 	systemPrompt    string
 	language        string
+	tuiMode         bool
+	outputFormat    string
+	ciMode          bool
+	failOnSecrets   bool
 	// ClickUp integration variables
-	clickupPAT    string
-	clickupTaskID string
+	clickupPAT     string
+	clickupTaskID  string
+	clickupBaseURL string
+	// clickupCustomFields are comma-separated custom field names or IDs to include
+	clickupCustomFields string
+	// ClickUp write-back after generation
+	clickupComment bool
+	clickupStatus  string
+	clickupPRField string
+	// ciEnv is the detected CI job when running with --ci
+	ciEnv *ci.Environment
 	// Jira integration variables
 	jiraBaseURL  string
 	jiraUsername string
 	jiraAPIToken string
 	jiraTaskID   string
+	// Linear integration variables
+	linearAPIKey  string
+	linearIssueID string
+	// GitHub/GitLab issue references
+	issueRef string
+	// noIssueDetect disables issue key detection from the branch and commits
+	noIssueDetect bool
+	// noIssueCache disables the on-disk cache of issue tracker responses
+	noIssueCache bool
+	// maxIssueComments is the number of newest comments kept per issue
+	maxIssueComments int
+	// noReviewers disables reviewer suggestions
+	noReviewers bool
+	// AI response cache
+	noCache      bool
+	refreshCache bool
+	// jiraCustomFields are comma-separated custom field IDs to include
+	jiraCustomFields string
+	// jiraDeployment is "cloud" or "server", detected if empty
+	jiraDeployment string
+	// Jira write-back after generation
+	jiraComment    bool
+	jiraTransition string
+	prURL          string
 )
 
 // Environment variable names
@@ -55,6 +100,9 @@ const (
 	EnvJiraBaseURL  = "PULLPOET_JIRA_BASE_URL"
 	EnvJiraUsername = "PULLPOET_JIRA_USERNAME"
 	EnvJiraAPIToken = "PULLPOET_JIRA_API_TOKEN"
+	// EnvJiraDeployment selects Jira Cloud or Server/Data Center
+	EnvJiraDeployment = "PULLPOET_JIRA_DEPLOYMENT"
+	EnvLinearAPIKey   = "PULLPOET_LINEAR_API_KEY"
 	// EnvJiraTaskID      = "PULLPOET_JIRA_TASK_ID" // Removed - task ID should be provided per PR
 )
 
@@ -66,84 +114,121 @@ func getEnvOrDefault(envName, defaultValue string) string {
 	return defaultValue
 }
 
-// getProviderFromEnvOrFlag returns provider from environment or flag
-func getProviderFromEnvOrFlag() string {
-	if provider != "" {
-		return provider
-	}
-	return getEnvOrDefault(EnvProvider, "")
-}
-
-// getProviderBaseURLFromEnvOrFlag returns provider base URL from environment or flag
-func getProviderBaseURLFromEnvOrFlag() string {
-	if providerBaseURL != "" {
-		return providerBaseURL
-	}
-	return getEnvOrDefault(EnvProviderBaseURL, "")
-}
-
-// getModelFromEnvOrFlag returns model from environment or flag
-func getModelFromEnvOrFlag() string {
-	if model != "" {
-		return model
+// flagOrEnv returns the flag value, or the environment variable when the flag is empty
+func flagOrEnv(value, envName string) string {
+	if value != "" {
+		return value
 	}
-	return getEnvOrDefault(EnvModel, "")
+	return os.Getenv(envName)
 }
 
-// getAPIKeyFromEnvOrFlag returns API key from environment or flag
-func getAPIKeyFromEnvOrFlag() string {
-	if apiKey != "" {
-		return apiKey
-	}
-	return getEnvOrDefault(EnvAPIKey, "")
+// resolveConfig builds the runtime configuration from CLI flags, the config file and environment variables
+// Priority: CLI flags > .pullpoet.yml > environment variables > defaults
+func resolveConfig(fileConfig *config.FileConfig) *config.Config {
+	cfg := &config.Config{
+		Repo:                repo,
+		Source:              source,
+		Target:              target,
+		Description:         description,
+		Provider:            provider,
+		APIKey:              apiKey,
+		ProviderBaseURL:     providerBaseURL,
+		Model:               model,
+		SystemPrompt:        systemPrompt,
+		Language:            language,
+		ClickUpPAT:          clickupPAT,
+		ClickUpTaskID:       clickupTaskID,
+		ClickUpBaseURL:      clickupBaseURL,
+		ClickUpCustomFields: splitList(clickupCustomFields),
+		JiraBaseURL:         jiraBaseURL,
+		JiraUsername:        jiraUsername,
+		JiraAPIToken:        jiraAPIToken,
+		JiraTaskID:          jiraTaskID,
+		JiraCustomFields:    splitList(jiraCustomFields),
+		JiraDeployment:      jiraDeployment,
+		LinearAPIKey:        linearAPIKey,
+		LinearIssueID:       linearIssueID,
+		IssueRef:            issueRef,
+		GitHubToken:         githubToken,
+		GitHubAPIURL:        githubAPIURL,
+		GitLabToken:         gitlabToken,
+		GitLabAPIURL:        gitlabAPIURL,
+	}
+	fileConfig.MergeWithConfig(cfg)
+	applyIssueConfig(cfg, fileConfig)
+	applyReviewerConfig(cfg, fileConfig)
+	applyCacheConfig(cfg, fileConfig)
+
+	cfg.Provider = flagOrEnv(cfg.Provider, EnvProvider)
+	cfg.APIKey = flagOrEnv(cfg.APIKey, EnvAPIKey)
+	cfg.ProviderBaseURL = flagOrEnv(cfg.ProviderBaseURL, EnvProviderBaseURL)
+	cfg.Model = flagOrEnv(cfg.Model, EnvModel)
+	if cfg.Language == "" {
+		cfg.Language = getEnvOrDefault(EnvLanguage, "en")
+	}
+	cfg.ClickUpPAT = flagOrEnv(cfg.ClickUpPAT, EnvClickUpPAT)
+	cfg.JiraBaseURL = flagOrEnv(cfg.JiraBaseURL, EnvJiraBaseURL)
+	cfg.JiraUsername = flagOrEnv(cfg.JiraUsername, EnvJiraUsername)
+	cfg.JiraAPIToken = flagOrEnv(cfg.JiraAPIToken, EnvJiraAPIToken)
+	cfg.JiraDeployment = flagOrEnv(cfg.JiraDeployment, EnvJiraDeployment)
+	cfg.LinearAPIKey = flagOrEnv(cfg.LinearAPIKey, EnvLinearAPIKey)
+	cfg.GitHubToken = flagOrEnv(cfg.GitHubToken, EnvGitHubToken)
+	cfg.GitLabToken = flagOrEnv(cfg.GitLabToken, EnvGitLabToken)
+	return cfg
 }
 
-// getClickUpPATFromEnvOrFlag returns ClickUp PAT from environment or flag
-func getClickUpPATFromEnvOrFlag() string {
-	if clickupPAT != "" {
-		return clickupPAT
+// resolveRunConfig resolves the configuration of run and preview: fast mode and the output
+// file from .pullpoet.yml, then the shared settings, with unset git settings detected from
+// the current directory
+func resolveRunConfig(cmd *cobra.Command, termUI *ui.UI, fileConfig *config.FileConfig) (*config.Config, error) {
+	// Fast mode from config file (only if not set via CLI flag)
+	// Note: For bool flags, cobra sets them to false by default, so we need to check if flag was actually provided
+	if !cmd.Flags().Changed("fast") && fileConfig.FastMode {
+		fastMode = fileConfig.FastMode
+		termUI.Verbose(fmt.Sprintf("Using fast mode from config file: %v", fastMode))
 	}
-	return getEnvOrDefault(EnvClickUpPAT, "")
-}
 
-// getLanguageFromEnvOrFlag returns language from environment or flag
-func getLanguageFromEnvOrFlag() string {
-	if language != "" {
-		return language
+	// Output file from config
+	if outputFile == "" && fileConfig.Output != "" {
+		outputFile = fileConfig.Output
+		termUI.Verbose(fmt.Sprintf("Using output file from config file: %s", outputFile))
 	}
-	return getEnvOrDefault(EnvLanguage, "en")
-}
 
-// getClickUpTaskIDFromEnvOrFlag returns ClickUp Task ID from environment or flag
-// func getClickUpTaskIDFromEnvOrFlag() string {
-// 	if clickupTaskID != "" {
-// 		return clickupTaskID
-// 	}
-// 	return getEnvOrDefault(EnvClickUpTaskID, "")
-// }
-
-// getJiraBaseURLFromEnvOrFlag returns Jira base URL from environment or flag
-func getJiraBaseURLFromEnvOrFlag() string {
-	if jiraBaseURL != "" {
-		return jiraBaseURL
+	cfg := resolveConfig(fileConfig)
+	if cfg.Provider == "" {
+		return nil, fmt.Errorf("provider is required (can be set via --provider flag, .pullpoet.yml, or PULLPOET_PROVIDER environment variable)")
 	}
-	return getEnvOrDefault(EnvJiraBaseURL, "")
-}
-
-// getJiraUsernameFromEnvOrFlag returns Jira username from environment or flag
-func getJiraUsernameFromEnvOrFlag() string {
-	if jiraUsername != "" {
-		return jiraUsername
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("model is required (can be set via --model flag, .pullpoet.yml, or PULLPOET_MODEL environment variable)")
 	}
-	return getEnvOrDefault(EnvJiraUsername, "")
-}
 
-// getJiraAPITokenFromEnvOrFlag returns Jira API token from environment or flag
-func getJiraAPITokenFromEnvOrFlag() string {
-	if jiraAPIToken != "" {
-		return jiraAPIToken
+	// Auto-detect git information if not provided (after config file merge)
+	if cfg.Repo == "" || cfg.Source == "" || cfg.Target == "" {
+		termUI.Info("Auto-detecting git repository information...")
+		gitClient := git.NewClient()
+		gitClient.SetLogger(termUI)
+		gitInfo, err := gitClient.GetGitInfoFromCurrentDir()
+		if err != nil {
+			return nil, fmt.Errorf("auto-detection failed: %w", err)
+		}
+		if !gitInfo.IsGitRepo {
+			return nil, fmt.Errorf("not in a git repository - please provide --repo, --source and --target flags or set them in .pullpoet.yml")
+		}
+		if cfg.Repo == "" {
+			cfg.Repo = gitInfo.RepoURL
+			termUI.Step(fmt.Sprintf("Repository: %s", pr.SanitizeRepoURL(cfg.Repo)))
+		}
+		if cfg.Source == "" {
+			cfg.Source = gitInfo.CurrentBranch
+			termUI.Step(fmt.Sprintf("Source branch: %s", cfg.Source))
+		}
+		if cfg.Target == "" {
+			cfg.Target = gitInfo.DefaultBranch
+			termUI.Step(fmt.Sprintf("Target branch: %s", cfg.Target))
+		}
+		termUI.Success("Git repository information detected")
 	}
-	return getEnvOrDefault(EnvJiraAPIToken, "")
+	return cfg, nil
 }
 
 var rootCmd = &cobra.Command{
@@ -151,7 +236,7 @@ var rootCmd = &cobra.Command{
 	Short:   "Generate AI-powered pull request descriptions",
 	Long:    `PullPoet is a CLI tool that generates pull request titles and descriptions using AI providers like OpenAI and Ollama.`,
 	Version: version,
-	RunE:    run,
+	RunE:    runRoot,
 }
 
 var previewCmd = &cobra.Command{
@@ -182,16 +267,47 @@ func init() {
 	rootCmd.Flags().StringVar(&outputFile, "output", "", "Save PR content to file (optional)")
 	rootCmd.Flags().StringVar(&systemPrompt, "system-prompt", "", "Custom system prompt file path to override default (optional)")
 	rootCmd.Flags().StringVar(&language, "language", "", "Language for the generated PR description (default: en, can also be set via PULLPOET_LANGUAGE env var)")
+	rootCmd.Flags().BoolVar(&tuiMode, "tui", false, "Review the diff and generated description in a full-screen terminal UI before output")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: 'text', 'markdown' or 'json' (markdown and json print only the result to stdout, progress goes to stderr)")
+	rootCmd.Flags().BoolVar(&ciMode, "ci", false, "CI mode: detect GitHub Actions, GitLab CI, Bitbucket Pipelines or Azure Pipelines and publish the result as step outputs")
+	rootCmd.Flags().BoolVar(&failOnSecrets, "fail-on-secrets", false, "Fail instead of redacting when secrets are detected in the changes (useful in CI)")
 
 	// ClickUp integration flags
 	rootCmd.Flags().StringVar(&clickupPAT, "clickup-pat", "", "ClickUp Personal Access Token (can also be set via PULLPOET_CLICKUP_PAT env var)")
 	rootCmd.Flags().StringVar(&clickupTaskID, "clickup-task-id", "", "ClickUp Task ID(s) to fetch description from, comma-separated for multiple tasks (e.g., 'task1,task2,task3')")
+	rootCmd.Flags().StringVar(&clickupCustomFields, "clickup-custom-fields", "", "ClickUp custom field names or IDs to include in the task context, comma-separated (default: all)")
+	rootCmd.Flags().StringVar(&clickupBaseURL, "clickup-base-url", "", "ClickUp API base URL (default: https://api.clickup.com/api/v2)")
+	rootCmd.Flags().BoolVar(&clickupComment, "clickup-comment", false, "Comment the PR title, link and a short summary on every referenced ClickUp task")
+	rootCmd.Flags().StringVar(&clickupStatus, "clickup-status", "", "Set every referenced ClickUp task to this status (e.g., 'in review')")
+	rootCmd.Flags().StringVar(&clickupPRField, "clickup-pr-field", "", "ID of a ClickUp URL custom field to set to the PR link")
 
 	// Jira integration flags
 	rootCmd.Flags().StringVar(&jiraBaseURL, "jira-base-url", "", "Jira base URL (e.g., https://yourcompany.atlassian.net, can also be set via PULLPOET_JIRA_BASE_URL env var)")
 	rootCmd.Flags().StringVar(&jiraUsername, "jira-username", "", "Jira username/email (can also be set via PULLPOET_JIRA_USERNAME env var)")
 	rootCmd.Flags().StringVar(&jiraAPIToken, "jira-api-token", "", "Jira API token (can also be set via PULLPOET_JIRA_API_TOKEN env var)")
+	rootCmd.Flags().StringVar(&jiraDeployment, "jira-deployment", "", "Jira deployment: cloud or server (Server/Data Center), detected if not set (can also be set via PULLPOET_JIRA_DEPLOYMENT env var)")
+	rootCmd.Flags().StringVar(&jiraCustomFields, "jira-custom-fields", "", "Jira custom field IDs to include in the issue context, comma-separated (e.g., 'customfield_10042')")
 	rootCmd.Flags().StringVar(&jiraTaskID, "jira-task-id", "", "Jira issue key(s) to fetch description from, comma-separated for multiple issues (e.g., 'HIP-1234,HIP-1250')")
+	rootCmd.Flags().BoolVar(&jiraComment, "jira-comment", false, "Comment the PR title, link and a short summary on every referenced Jira issue")
+	rootCmd.Flags().StringVar(&jiraTransition, "jira-transition", "", "Move every referenced Jira issue through this transition or to this status (e.g., 'In Review')")
+	rootCmd.Flags().StringVar(&prURL, "pr-url", "", "Pull request URL used in Jira and ClickUp write-back (detected automatically with --ci)")
+
+	// Linear integration flags
+	rootCmd.Flags().StringVar(&linearAPIKey, "linear-api-key", "", "Linear API key (can also be set via PULLPOET_LINEAR_API_KEY env var)")
+	rootCmd.Flags().StringVar(&linearIssueID, "linear-issue", "", "Linear issue ID(s) to fetch description from, comma-separated for multiple issues (e.g., 'ENG-123,ENG-130')")
+
+	// GitHub/GitLab issue flags
+	rootCmd.Flags().StringVar(&issueRef, "issue", "", "Issue(s) to fetch description from, comma-separated and prefixed with the tracker (e.g., 'jira:HIP-12,clickup:abc123,linear:ENG-7'); '#123', 'owner/repo#123' and issue URLs default to GitHub/GitLab")
+	rootCmd.Flags().StringVar(&githubToken, "github-token", "", "GitHub token used to fetch issues (can also be set via PULLPOET_GITHUB_TOKEN env var)")
+	rootCmd.Flags().StringVar(&githubAPIURL, "github-api-url", "", "GitHub API URL (default: derived from the issue host, set for GitHub Enterprise)")
+	rootCmd.Flags().StringVar(&gitlabToken, "gitlab-token", "", "GitLab token used to fetch issues (can also be set via PULLPOET_GITLAB_TOKEN env var)")
+	rootCmd.Flags().StringVar(&gitlabAPIURL, "gitlab-api-url", "", "GitLab API URL (default: derived from the issue host)")
+	rootCmd.Flags().BoolVar(&noIssueDetect, "no-issue-detect", false, "Do not fetch issue keys detected in the branch name and commit messages")
+	rootCmd.Flags().BoolVar(&noIssueCache, "no-issue-cache", false, "Do not use the on-disk cache of issue tracker responses (default TTL: 5m)")
+	rootCmd.Flags().IntVar(&maxIssueComments, "max-issue-comments", 0, "Newest comments kept per issue, older ones are summarised (default: 20, -1 keeps all)")
+	rootCmd.Flags().BoolVar(&noReviewers, "no-reviewers", false, "Do not suggest reviewers from CODEOWNERS and the git history")
+	rootCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not use the on-disk cache of AI responses (default TTL: 7 days)")
+	rootCmd.Flags().BoolVar(&refreshCache, "refresh", false, "Ask the AI again and replace the cached response")
 
 	// Preview command flags (inherit from root)
 	previewCmd.Flags().StringVar(&repo, "repo", "", "Git repository URL (auto-detected if not provided and running in git repo)")
@@ -206,17 +322,40 @@ func init() {
 	previewCmd.Flags().StringVar(&outputFile, "output", "", "Save preview content to file (optional)")
 	previewCmd.Flags().StringVar(&systemPrompt, "system-prompt", "", "Custom system prompt file path to override default (optional)")
 	previewCmd.Flags().StringVar(&language, "language", "", "Language for the generated preview (default: en, can also be set via PULLPOET_LANGUAGE env var)")
+	previewCmd.Flags().BoolVar(&tuiMode, "tui", false, "Review the staged diff and generated preview in a full-screen terminal UI before output")
+	previewCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format: 'text', 'markdown' or 'json' (markdown and json print only the result to stdout, progress goes to stderr)")
+	previewCmd.Flags().BoolVar(&failOnSecrets, "fail-on-secrets", false, "Fail instead of redacting when secrets are detected in the staged changes")
 
 	// ClickUp integration flags for preview
 	previewCmd.Flags().StringVar(&clickupPAT, "clickup-pat", "", "ClickUp Personal Access Token (can also be set via PULLPOET_CLICKUP_PAT env var)")
 	previewCmd.Flags().StringVar(&clickupTaskID, "clickup-task-id", "", "ClickUp Task ID(s) to fetch description from, comma-separated for multiple tasks (e.g., 'task1,task2,task3')")
+	previewCmd.Flags().StringVar(&clickupCustomFields, "clickup-custom-fields", "", "ClickUp custom field names or IDs to include in the task context, comma-separated (default: all)")
+	previewCmd.Flags().StringVar(&clickupBaseURL, "clickup-base-url", "", "ClickUp API base URL (default: https://api.clickup.com/api/v2)")
 
 	// Jira integration flags for preview
 	previewCmd.Flags().StringVar(&jiraBaseURL, "jira-base-url", "", "Jira base URL (e.g., https://yourcompany.atlassian.net, can also be set via PULLPOET_JIRA_BASE_URL env var)")
 	previewCmd.Flags().StringVar(&jiraUsername, "jira-username", "", "Jira username/email (can also be set via PULLPOET_JIRA_USERNAME env var)")
 	previewCmd.Flags().StringVar(&jiraAPIToken, "jira-api-token", "", "Jira API token (can also be set via PULLPOET_JIRA_API_TOKEN env var)")
+	previewCmd.Flags().StringVar(&jiraDeployment, "jira-deployment", "", "Jira deployment: cloud or server (Server/Data Center), detected if not set (can also be set via PULLPOET_JIRA_DEPLOYMENT env var)")
+	previewCmd.Flags().StringVar(&jiraCustomFields, "jira-custom-fields", "", "Jira custom field IDs to include in the issue context, comma-separated (e.g., 'customfield_10042')")
 	previewCmd.Flags().StringVar(&jiraTaskID, "jira-task-id", "", "Jira issue key(s) to fetch description from, comma-separated for multiple issues (e.g., 'HIP-1234,HIP-1250')")
 
+	// Linear integration flags for preview
+	previewCmd.Flags().StringVar(&linearAPIKey, "linear-api-key", "", "Linear API key (can also be set via PULLPOET_LINEAR_API_KEY env var)")
+	previewCmd.Flags().StringVar(&linearIssueID, "linear-issue", "", "Linear issue ID(s) to fetch description from, comma-separated for multiple issues (e.g., 'ENG-123,ENG-130')")
+
+	// GitHub/GitLab issue flags for preview
+	previewCmd.Flags().StringVar(&issueRef, "issue", "", "Issue(s) to fetch description from, comma-separated and prefixed with the tracker (e.g., 'jira:HIP-12,clickup:abc123,linear:ENG-7'); '#123', 'owner/repo#123' and issue URLs default to GitHub/GitLab")
+	previewCmd.Flags().StringVar(&githubToken, "github-token", "", "GitHub token used to fetch issues (can also be set via PULLPOET_GITHUB_TOKEN env var)")
+	previewCmd.Flags().StringVar(&githubAPIURL, "github-api-url", "", "GitHub API URL (default: derived from the issue host, set for GitHub Enterprise)")
+	previewCmd.Flags().StringVar(&gitlabToken, "gitlab-token", "", "GitLab token used to fetch issues (can also be set via PULLPOET_GITLAB_TOKEN env var)")
+	previewCmd.Flags().StringVar(&gitlabAPIURL, "gitlab-api-url", "", "GitLab API URL (default: derived from the issue host)")
+	previewCmd.Flags().BoolVar(&noIssueDetect, "no-issue-detect", false, "Do not fetch issue keys detected in the branch name and commit messages")
+	previewCmd.Flags().BoolVar(&noIssueCache, "no-issue-cache", false, "Do not use the on-disk cache of issue tracker responses (default TTL: 5m)")
+	previewCmd.Flags().IntVar(&maxIssueComments, "max-issue-comments", 0, "Newest comments kept per issue, older ones are summarised (default: 20, -1 keeps all)")
+	previewCmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not use the on-disk cache of AI responses (default TTL: 7 days)")
+	previewCmd.Flags().BoolVar(&refreshCache, "refresh", false, "Ask the AI again and replace the cached response")
+
 	// Set version template and enable -v shorthand
 	rootCmd.SetVersionTemplate("{{.Version}}\n")
 	rootCmd.Flags().BoolP("version", "v", false, "version for pullpoet")
@@ -228,142 +367,432 @@ func init() {
 	// Flag validasyonunu kaldırdık, run fonksiyonunda manuel validasyon yapacağız
 }
 
-// fetchClickUpTasks fetches multiple ClickUp tasks and combines their descriptions
-func fetchClickUpTasks(pat, taskIDs string) (string, error) {
-	// Parse task IDs (comma-separated)
-	ids := strings.Split(taskIDs, ",")
-	var cleanIDs []string
-	for _, id := range ids {
-		trimmed := strings.TrimSpace(id)
-		if trimmed != "" {
-			cleanIDs = append(cleanIDs, trimmed)
+// fetchIssues fetches the referenced and detected issues from all configured trackers
+func fetchIssues(termUI *ui.UI, cfg *config.Config, detected []issues.Ref) ([]issues.Issue, error) {
+	refs, err := issueRefs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 && len(detected) == 0 {
+		return nil, nil
+	}
+
+	registry := issues.NewRegistryFromConfig(cfg, termUI)
+
+	// Detected keys may be false positives, so their failures only warn
+	explicit := make(map[issues.Ref]bool)
+	for _, ref := range refs {
+		explicit[ref] = true
+	}
+	all := refs
+	for _, ref := range detected {
+		if explicit[ref] {
+			continue
+		}
+		if _, ok := registry.Get(ref.Tracker); !ok {
+			termUI.Warning(fmt.Sprintf("Skipping detected issue %s: issue tracker %q is not configured", ref.ID, ref.Tracker))
+			continue
 		}
+		all = append(all, ref)
 	}
 
-	if len(cleanIDs) == 0 {
-		return "", fmt.Errorf("no valid task IDs provided")
+	fetched, failures, err := registry.Fetch(all)
+	if err != nil {
+		return nil, err
+	}
+	failedExplicit := 0
+	for _, failure := range failures {
+		if explicit[failure.Ref] {
+			failedExplicit++
+			termUI.Warning(fmt.Sprintf("Failed to fetch issue %s", failure))
+		} else {
+			termUI.Warning(fmt.Sprintf("Skipping detected issue %s: %v", failure.Ref.ID, failure.Err))
+		}
+	}
+	if len(refs) > 0 && failedExplicit == len(refs) {
+		return nil, fmt.Errorf("failed to fetch any of the requested issues")
 	}
 
-	fmt.Printf("📋 Fetching %d task(s) from ClickUp...\n", len(cleanIDs))
-	clickupClient := clickup.NewClient(pat)
+	fetched = issues.Trim(fetched, issues.TokenBudget(cfg))
+	for _, issue := range fetched {
+		for _, note := range issue.Trimmed {
+			termUI.Verbose(fmt.Sprintf("Issue %s: %s", issue.ID, note))
+		}
+	}
+	return fetched, nil
+}
 
-	var descriptions []string
-	for i, taskID := range cleanIDs {
-		fmt.Printf("   [%d/%d] Fetching task: %s\n", i+1, len(cleanIDs), taskID)
-		task, err := clickupClient.GetTask(taskID)
-		if err != nil {
-			return "", fmt.Errorf("failed to fetch ClickUp task %s: %w", taskID, err)
+// writeBackToJira comments on and transitions the referenced Jira issues
+func writeBackToJira(termUI *ui.UI, fileConfig *config.FileConfig, cfg *config.Config, fetched []issues.Issue, result *pr.Result) error {
+	comment := jiraComment
+	transition := jiraTransition
+	if fileConfig.Jira != nil {
+		comment = comment || fileConfig.Jira.Comment
+		if transition == "" {
+			transition = fileConfig.Jira.Transition
 		}
+	}
+	if !comment && transition == "" {
+		return nil
+	}
 
-		descriptions = append(descriptions, task.FormatTaskDescription())
-		fmt.Printf("   ✅ Task fetched: %s\n", task.Name)
+	var keys []string
+	statuses := make(map[string]string)
+	for _, issue := range fetched {
+		if issue.Tracker == "jira" {
+			keys = append(keys, issue.ID)
+			statuses[issue.ID] = issue.Status
+		}
+	}
+	if len(keys) == 0 {
+		termUI.Warning("Jira write-back is enabled but no Jira issues were referenced")
+		return nil
+	}
 
-		if len(task.Comments) > 0 {
-			totalReplies := 0
-			for _, comment := range task.Comments {
-				totalReplies += len(comment.Replies)
+	link := pullRequestLink(cfg)
+	if comment && link == "" {
+		// The comment is found again on reruns by its pull request link
+		termUI.Warning("No pull request URL is known (use --pr-url), Jira issues are not commented on")
+		comment = false
+	}
+	client, err := issues.NewJiraClient(cfg, termUI)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if comment {
+			updated, err := client.SetPullRequestComment(key, result.Title, link, summarize(result.Body))
+			if err != nil {
+				return fmt.Errorf("failed to comment on Jira issue %s: %w", key, err)
+			}
+			if updated {
+				termUI.Printf("💬 Updated the pull request comment on Jira issue %s\n", key)
+			} else {
+				termUI.Printf("💬 Commented on Jira issue %s\n", key)
 			}
-			fmt.Printf("   💬 %d comments", len(task.Comments))
-			if totalReplies > 0 {
-				fmt.Printf(" (%d replies)", totalReplies)
+		}
+		if transition != "" {
+			moved, err := client.TransitionIssue(key, transition, statuses[key])
+			if err != nil {
+				return fmt.Errorf("failed to transition Jira issue %s: %w", key, err)
+			}
+			if moved {
+				termUI.Printf("🔀 Moved Jira issue %s to '%s'\n", key, transition)
+			} else {
+				termUI.Printf("🔀 Jira issue %s is already in '%s'\n", key, statuses[key])
 			}
-			fmt.Println()
 		}
 	}
+	return nil
+}
+
+// writeBackToClickUp comments on, updates the status of and links the PR on the referenced ClickUp tasks
+func writeBackToClickUp(termUI *ui.UI, fileConfig *config.FileConfig, cfg *config.Config, fetched []issues.Issue, result *pr.Result) error {
+	comment, status, prField := clickupComment, clickupStatus, clickupPRField
+	if fileConfig.ClickUp != nil {
+		comment = comment || fileConfig.ClickUp.Comment
+		if status == "" {
+			status = fileConfig.ClickUp.Status
+		}
+		if prField == "" {
+			prField = fileConfig.ClickUp.PRField
+		}
+	}
+	if !comment && status == "" && prField == "" {
+		return nil
+	}
+
+	var taskIDs []string
+	for _, issue := range fetched {
+		if issue.Tracker == "clickup" {
+			taskIDs = append(taskIDs, issue.ID)
+		}
+	}
+	if len(taskIDs) == 0 {
+		termUI.Warning("ClickUp write-back is enabled but no ClickUp tasks were referenced")
+		return nil
+	}
+
+	link := pullRequestLink(cfg)
+	if comment && link == "" {
+		// The comment is found again on reruns by its pull request link
+		termUI.Warning("No pull request URL is known (use --pr-url), ClickUp tasks are not commented on")
+		comment = false
+	}
+	if prField != "" && link == "" {
+		termUI.Warning("No pull request URL is known (use --pr-url), the ClickUp PR field is not set")
+		prField = ""
+	}
 
-	// Combine all task descriptions
-	if len(descriptions) == 1 {
-		return descriptions[0], nil
+	client := issues.NewClickUpClient(cfg, termUI)
+	for _, taskID := range taskIDs {
+		if comment {
+			updated, err := client.SetPullRequestComment(taskID, result.Title, link, summarize(result.Body))
+			if err != nil {
+				return fmt.Errorf("failed to comment on ClickUp task %s: %w", taskID, err)
+			}
+			if updated {
+				termUI.Printf("💬 Updated the pull request comment on ClickUp task %s\n", taskID)
+			} else {
+				termUI.Printf("💬 Commented on ClickUp task %s\n", taskID)
+			}
+		}
+		if status != "" {
+			if err := client.UpdateTaskStatus(taskID, status); err != nil {
+				return fmt.Errorf("failed to update status of ClickUp task %s: %w", taskID, err)
+			}
+			termUI.Printf("🔀 Moved ClickUp task %s to '%s'\n", taskID, status)
+		}
+		if prField != "" {
+			if err := client.SetCustomField(taskID, prField, link); err != nil {
+				return fmt.Errorf("failed to set PR link on ClickUp task %s: %w", taskID, err)
+			}
+			termUI.Printf("🔗 Linked the pull request on ClickUp task %s\n", taskID)
+		}
 	}
+	return nil
+}
 
-	var combined strings.Builder
-	combined.WriteString(fmt.Sprintf("**Multiple ClickUp Tasks (%d tasks)**\n\n", len(descriptions)))
-	combined.WriteString(strings.Repeat("=", 80) + "\n\n")
+// pullRequestLink returns the --pr-url value, or the CI pull request URL
+func pullRequestLink(cfg *config.Config) string {
+	if prURL == "" && ciEnv != nil {
+		return forge.PullRequestURL(cfg.Repo, ciEnv.PRNumber)
+	}
+	return prURL
+}
 
-	for i, desc := range descriptions {
-		combined.WriteString(fmt.Sprintf("### Task %d of %d\n\n", i+1, len(descriptions)))
-		combined.WriteString(desc)
-		if i < len(descriptions)-1 {
-			combined.WriteString("\n\n" + strings.Repeat("-", 80) + "\n\n")
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
+}
 
-	return combined.String(), nil
+// summarize returns the first paragraph of a description, shortened for issue comments
+func summarize(body string) string {
+	for _, paragraph := range strings.Split(body, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" || strings.HasPrefix(paragraph, "#") {
+			continue
+		}
+		if len(paragraph) > 300 {
+			paragraph = strings.TrimSpace(paragraph[:297]) + "..."
+		}
+		return paragraph
+	}
+	return ""
 }
 
-// fetchJiraIssues fetches multiple Jira issues and combines their descriptions
-func fetchJiraIssues(baseURL, username, apiToken, issueKeys string) (string, error) {
-	// Parse issue keys (comma-separated)
-	keys := strings.Split(issueKeys, ",")
-	var cleanKeys []string
-	for _, key := range keys {
-		trimmed := strings.TrimSpace(key)
-		if trimmed != "" {
-			cleanKeys = append(cleanKeys, trimmed)
+// detectIssueRefs finds issue keys in the source branch and commit messages
+func detectIssueRefs(termUI *ui.UI, fileConfig *config.FileConfig, cfg *config.Config, commits []git.CommitInfo) ([]issues.Ref, error) {
+	detection := fileConfig.IssueDetection
+	if detection == nil {
+		detection = &config.IssueDetectionConfig{}
+	}
+	if noIssueDetect || detection.Disabled {
+		return nil, nil
+	}
+
+	tracker := detection.Tracker
+	if tracker == "" {
+		switch {
+		case cfg.JiraBaseURL != "" && cfg.JiraAPIToken != "":
+			tracker = "jira"
+		case cfg.LinearAPIKey != "":
+			tracker = "linear"
+		default:
+			termUI.Verbose("Issue detection skipped: no Jira or Linear credentials configured")
+			return nil, nil
 		}
 	}
 
-	if len(cleanKeys) == 0 {
-		return "", fmt.Errorf("no valid issue keys provided")
+	detector, err := issues.NewDetector(detection.Patterns, detection.Projects)
+	if err != nil {
+		return nil, fmt.Errorf("configuration error: %w", err)
 	}
 
-	fmt.Printf("📋 Fetching %d issue(s) from Jira...\n", len(cleanKeys))
-	jiraClient := jira.NewClient(baseURL, username, apiToken)
+	var refs []issues.Ref
+	var keys []string
+	for _, detected := range detector.Detect(cfg.Source, commits) {
+		termUI.Verbose(fmt.Sprintf("Detected issue %s in %s", detected.Key, detected.Source))
+		refs = append(refs, issues.Ref{Tracker: tracker, ID: detected.Key})
+		keys = append(keys, detected.Key)
+	}
+	if len(refs) > 0 {
+		termUI.Printf("🔎 Detected %d issue key(s) for %s: %s\n", len(refs), tracker, strings.Join(keys, ", "))
+	}
+	return refs, nil
+}
 
-	var descriptions []string
-	for i, issueKey := range cleanKeys {
-		fmt.Printf("   [%d/%d] Fetching issue: %s\n", i+1, len(cleanKeys), issueKey)
-		issue, err := jiraClient.GetIssue(issueKey)
+// issueRefs collects the --issue references and the tracker specific task ID flags
+func issueRefs(cfg *config.Config) ([]issues.Ref, error) {
+	var refs []issues.Ref
+	for _, source := range []struct {
+		tracker string
+		ids     string
+	}{
+		{"clickup", cfg.ClickUpTaskID},
+		{"jira", cfg.JiraTaskID},
+		{"linear", cfg.LinearIssueID},
+		{issues.DefaultTracker(cfg.Repo), cfg.IssueRef},
+	} {
+		parsed, err := issues.ParseRefs(source.ids, source.tracker)
 		if err != nil {
-			return "", fmt.Errorf("failed to fetch Jira issue %s: %w", issueKey, err)
+			return nil, err
 		}
+		refs = append(refs, parsed...)
+	}
+	return refs, nil
+}
 
-		descriptions = append(descriptions, issue.FormatIssueDescription())
-		fmt.Printf("   ✅ Issue fetched: %s\n", issue.Summary)
+// applyIssueConfig sets the issue fetching and context settings from .pullpoet.yml,
+// --no-issue-cache and --max-issue-comments
+func applyIssueConfig(cfg *config.Config, fileConfig *config.FileConfig) {
+	fetch := fileConfig.IssueFetch
+	if fetch == nil {
+		fetch = &config.IssueFetchConfig{}
+	}
+	cfg.IssueFetchConcurrency = fetch.Concurrency
+	cfg.IssueRequestsPerSecond = fetch.RequestsPerSecond
+	cfg.IssueCacheTTL = issueCacheTTL(fetch)
+	if noIssueCache || fetch.NoCache {
+		cfg.IssueCacheTTL = 0
+	}
+
+	issueContext := fileConfig.IssueContext
+	if issueContext == nil {
+		issueContext = &config.IssueContextConfig{}
+	}
+	cfg.IssueMaxComments = maxIssueComments
+	if cfg.IssueMaxComments == 0 {
+		cfg.IssueMaxComments = issueContext.MaxComments
+	}
+	switch {
+	case cfg.IssueMaxComments == 0:
+		cfg.IssueMaxComments = issues.DefaultMaxComments
+	case cfg.IssueMaxComments < 0:
+		cfg.IssueMaxComments = 0
+	}
+	cfg.IssueKeepBots = issueContext.KeepBots
+	cfg.IssueBotAuthors = issueContext.BotAuthors
+	cfg.IssueContextShare = issueContext.BudgetShare
+	cfg.ModelContextTokens = issueContext.ModelContextTokens
+}
 
-		if len(issue.Comments) > 0 {
-			fmt.Printf("   💬 %d comments\n", len(issue.Comments))
-		}
-	}
+// applyReviewerConfig sets the reviewer suggestion settings from .pullpoet.yml and --no-reviewers
+func applyReviewerConfig(cfg *config.Config, fileConfig *config.FileConfig) {
+	settings := fileConfig.Reviewers
+	if settings == nil {
+		settings = &config.ReviewersConfig{}
+	}
+	cfg.ReviewersDisabled = noReviewers || settings.Disabled
+	cfg.ReviewersMax = settings.Max
+	cfg.ReviewersHalfLife = settings.HalfLife
+	cfg.ReviewersExclude = settings.Exclude
+	cfg.RequestReviewers = settings.Request
+}
 
-	// Combine all issue descriptions
-	if len(descriptions) == 1 {
-		return descriptions[0], nil
+// applyCacheConfig sets the AI response cache settings from .pullpoet.yml, --no-cache and --refresh
+func applyCacheConfig(cfg *config.Config, fileConfig *config.FileConfig) {
+	settings := fileConfig.Cache
+	if settings == nil {
+		settings = &config.CacheConfig{}
+	}
+	cfg.ResponseCacheTTL = responseCacheTTL(settings)
+	if noCache || settings.Disabled {
+		cfg.ResponseCacheTTL = 0
 	}
+	cfg.ResponseCacheMaxSize = config.DefaultResponseCacheMaxSize
+	if settings.MaxSizeMB > 0 {
+		cfg.ResponseCacheMaxSize = int64(settings.MaxSizeMB) << 20
+	}
+	cfg.ResponseCacheRefresh = refreshCache
+}
 
-	var combined strings.Builder
-	combined.WriteString(fmt.Sprintf("**Multiple Jira Issues (%d issues)**\n\n", len(descriptions)))
-	combined.WriteString(strings.Repeat("=", 80) + "\n\n")
+// issueCacheTTL returns the configured TTL of the issue cache, whether or not the cache is used
+func issueCacheTTL(fetch *config.IssueFetchConfig) time.Duration {
+	if fetch != nil && fetch.CacheTTL > 0 {
+		return fetch.CacheTTL
+	}
+	return config.DefaultIssueCacheTTL
+}
 
-	for i, desc := range descriptions {
-		combined.WriteString(fmt.Sprintf("### Issue %d of %d\n\n", i+1, len(descriptions)))
-		combined.WriteString(desc)
-		if i < len(descriptions)-1 {
-			combined.WriteString("\n\n" + strings.Repeat("-", 80) + "\n\n")
-		}
+// responseCacheTTL returns the configured TTL of the AI response cache, whether or not the cache is used
+func responseCacheTTL(settings *config.CacheConfig) time.Duration {
+	if settings != nil && settings.TTL > 0 {
+		return settings.TTL
 	}
+	return config.DefaultResponseCacheTTL
+}
 
-	return combined.String(), nil
+// responseStore returns the store of the AI response cache
+func responseStore(cfg *config.Config) *cache.Store {
+	store := cache.New(cache.DefaultDir("responses"), cfg.ResponseCacheTTL)
+	store.SetMaxSize(cfg.ResponseCacheMaxSize)
+	return store
 }
 
-// autoDetectGitInfo attempts to auto-detect git repository information
-func autoDetectGitInfo() (string, string, error) {
-	gitClient := git.NewClient()
-	gitInfo, err := gitClient.GetGitInfoFromCurrentDir()
-	if err != nil {
-		return "", "", fmt.Errorf("failed to detect git information: %w", err)
+// withResponseCache wraps the AI client with the response cache unless it is disabled
+func withResponseCache(termUI *ui.UI, cfg *config.Config, aiClient ai.Client) ai.Client {
+	if cfg.ResponseCacheTTL <= 0 {
+		return aiClient
 	}
+	cached := ai.NewCachedClient(aiClient, responseStore(cfg), cfg.ResponseCacheRefresh)
+	cached.SetLogger(termUI)
+	return cached
+}
 
-	if !gitInfo.IsGitRepo {
-		return "", "", fmt.Errorf("not in a git repository - please provide --repo and --source flags")
+// newPolicy compiles the title and body policy; the keys of the fetched issues satisfy require_issue_key
+func newPolicy(cfg *config.Config, fetched []issues.Issue) (*pr.Policy, error) {
+	keys := make([]string, 0, len(fetched))
+	for _, issue := range fetched {
+		keys = append(keys, issue.ID)
 	}
+	return pr.NewPolicy(cfg.Policy, keys)
+}
 
-	fmt.Printf("🔍 Auto-detected git information:\n")
-	fmt.Printf("   📦 Repository: %s\n", gitInfo.RepoURL)
-	fmt.Printf("   🌿 Current branch: %s\n", gitInfo.CurrentBranch)
-	fmt.Printf("   🎯 Default branch: %s\n", gitInfo.DefaultBranch)
+// reviewResult opens the interactive review screen for the generated result.
+// Excluded files are removed from the diff and the description is regenerated in place.
+func reviewResult(termUI *ui.UI, generator *pr.Generator, gitResult *git.GitResult, result *pr.Result, issueContext, repoURL, language string, addSignature bool) (*pr.Result, error) {
+	var files []ui.ReviewFile
+	for _, file := range git.SplitDiff(gitResult.Diff) {
+		files = append(files, ui.ReviewFile{
+			Path:      file.Path,
+			Additions: file.Additions,
+			Deletions: file.Deletions,
+			Patch:     file.Patch,
+		})
+	}
+
+	// The screen shows the rendered body, the result of the latest generation is kept
+	latest := result
+	regenerate := func(excluded []string) (string, string, error) {
+		skip := make(map[string]bool, len(excluded))
+		for _, path := range excluded {
+			skip[path] = true
+		}
+		filtered := *gitResult
+		filtered.Diff = git.FilterDiff(gitResult.Diff, skip)
+
+		regenerated, err := generator.Generate(&filtered, issueContext, repoURL, language, addSignature)
+		if err != nil {
+			return "", "", err
+		}
+		latest = regenerated
+		return regenerated.Title, regenerated.Markdown(), nil
+	}
 
-	return gitInfo.RepoURL, gitInfo.CurrentBranch, nil
+	title, _, err := termUI.Review(files, result.Title, result.Markdown(), regenerate)
+	if err != nil {
+		return nil, err
+	}
+	reviewed := *latest
+	reviewed.Title = title
+	return &reviewed, nil
 }
 
 // savePRToFile saves the PR content to the specified file
@@ -375,7 +804,7 @@ func savePRToFile(result *pr.Result, filePath string) error {
 	}
 
 	// Format the content with title at the top and description below
-	content := fmt.Sprintf("# %s\n\n%s\n", result.Title, result.Body)
+	content := fmt.Sprintf("# %s\n\n%s\n", result.Title, result.Markdown())
 
 	// Write to file (overwrite if exists)
 	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
@@ -385,6 +814,121 @@ func savePRToFile(result *pr.Result, filePath string) error {
 	return nil
 }
 
+// writeDocument prints the result as a single markdown or JSON document on stdout
+func writeDocument(format output.Format, aiClient ai.Client, cfg *config.Config, gitResult *git.GitResult, result *pr.Result, timings output.Timings) error {
+	providerName, modelName := aiClient.GetProviderInfo()
+	doc := &output.Document{
+		Provider:   providerName,
+		Model:      modelName,
+		Repository: pr.SanitizeRepoURL(cfg.Repo),
+		Source:     cfg.Source,
+		Target:     cfg.Target,
+		Timings:    timings,
+	}
+	doc.SetResult(result)
+	doc.SetGitResult(gitResult)
+
+	return output.Write(os.Stdout, format, doc)
+}
+
+// newRedactor builds the secret redactor from the config file and the configured credentials
+func newRedactor(fileConfig *config.FileConfig, cfg *config.Config) (*redact.Redactor, error) {
+	if fileConfig.Redact != nil && fileConfig.Redact.Disabled {
+		return nil, nil
+	}
+
+	redactor := redact.NewRedactor()
+	redactor.AddLiteral("ai-api-key", cfg.APIKey)
+	redactor.AddLiteral("clickup-pat", cfg.ClickUpPAT)
+	redactor.AddLiteral("jira-api-token", cfg.JiraAPIToken)
+	redactor.AddLiteral("linear-api-key", cfg.LinearAPIKey)
+	redactor.AddLiteral("github-token", cfg.GitHubToken)
+	redactor.AddLiteral("gitlab-token", cfg.GitLabToken)
+	if fileConfig.Redact != nil {
+		for _, pattern := range fileConfig.Redact.Patterns {
+			if err := redactor.AddPattern(pattern.Name, pattern.Pattern); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return redactor, nil
+}
+
+// shouldFailOnSecrets reports whether --fail-on-secrets or redact.fail_on_secrets is set
+func shouldFailOnSecrets(fileConfig *config.FileConfig) bool {
+	return failOnSecrets || (fileConfig.Redact != nil && fileConfig.Redact.FailOnSecrets)
+}
+
+// runRoot runs the root command and reports failures as CI annotations in --ci mode
+func runRoot(cmd *cobra.Command, args []string) error {
+	err := run(cmd, args)
+	if err != nil && ciEnv != nil {
+		ciEnv.Annotate(os.Stderr, err)
+	}
+	return err
+}
+
+// applyCIEnvironment detects the CI job and uses its repository, branches and PR for unset flags
+func applyCIEnvironment(termUI *ui.UI) error {
+	env, err := ci.Detect(os.Getenv)
+	if err != nil {
+		return fmt.Errorf("failed to detect CI environment: %w", err)
+	}
+	if env == nil {
+		return fmt.Errorf("--ci was given but no supported CI environment was detected (GitHub Actions, GitLab CI, Bitbucket Pipelines, Azure Pipelines)")
+	}
+	ciEnv = env
+
+	termUI.Success(fmt.Sprintf("Detected %s", env.Provider))
+	if repo == "" && env.Repo != "" {
+		repo = env.Repo
+		termUI.Step(fmt.Sprintf("Repository: %s", pr.SanitizeRepoURL(repo)))
+	}
+	if source == "" && env.Source != "" {
+		source = env.Source
+		termUI.Step(fmt.Sprintf("Source branch: %s", source))
+	}
+	if target == "" && env.Target != "" {
+		target = env.Target
+		termUI.Step(fmt.Sprintf("Target branch: %s", target))
+	}
+	if env.PRNumber > 0 {
+		termUI.Step(fmt.Sprintf("Pull request: #%d", env.PRNumber))
+	}
+	return nil
+}
+
+// publishCIResult writes the result to the CI job outputs and summary
+func publishCIResult(termUI *ui.UI, result *pr.Result) error {
+	outputs := []ci.Output{
+		{Name: "title", Value: result.Title},
+		{Name: "body", Value: result.Markdown()},
+		{Name: "labels", Value: strings.Join(result.Labels, ",")},
+		{Name: "change_type", Value: result.ChangeType},
+	}
+	if result.Risk != nil {
+		outputs = append(outputs, ci.Output{Name: "risk", Value: result.Risk.Level})
+	}
+	var handles []string
+	for _, reviewer := range result.Reviewers {
+		if handle := reviewer.Handle(); handle != "" {
+			handles = append(handles, handle)
+		}
+	}
+	outputs = append(outputs, ci.Output{Name: "reviewers", Value: strings.Join(handles, ",")})
+	if ciEnv.PRNumber > 0 {
+		outputs = append(outputs, ci.Output{Name: "pr_number", Value: strconv.Itoa(ciEnv.PRNumber)})
+	}
+	if err := ciEnv.WriteOutputs(os.Stderr, outputs); err != nil {
+		return fmt.Errorf("failed to write CI outputs: %w", err)
+	}
+	if err := ciEnv.WriteSummary(fmt.Sprintf("## %s\n\n%s", result.Title, result.Markdown())); err != nil {
+		return fmt.Errorf("failed to write CI job summary: %w", err)
+	}
+	termUI.Success(fmt.Sprintf("Result published to %s outputs", ciEnv.Provider))
+	return nil
+}
+
 func run(cmd *cobra.Command, args []string) error {
 	// Check if version flag was used
 	if versionFlag, _ := cmd.Flags().GetBool("version"); versionFlag {
@@ -392,10 +936,16 @@ func run(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	startedAt := time.Now()
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
 	// Load configuration file
 	fileConfig, err := config.LoadConfigFile()
 	if err != nil {
-		fmt.Printf("⚠️  Warning: Failed to load config file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to load config file: %v\n", err)
 		fileConfig = &config.FileConfig{UI: config.DefaultUIConfig()}
 	}
 
@@ -408,232 +958,208 @@ func run(cmd *cobra.Command, args []string) error {
 		Theme:        fileConfig.UI.Theme,
 	}
 	termUI := ui.New(uiConfig)
-
-	termUI.Section("Starting PullPoet")
-
-	// Merge file config values with CLI flags (file config has lower priority)
-	// Git configuration from file
-	if repo == "" && fileConfig.Repo != "" {
-		repo = fileConfig.Repo
-		termUI.Verbose(fmt.Sprintf("Using repo from config file: %s", repo))
-	}
-	if source == "" && fileConfig.Source != "" {
-		source = fileConfig.Source
-		termUI.Verbose(fmt.Sprintf("Using source branch from config file: %s", source))
-	}
-	if target == "" && fileConfig.Target != "" {
-		target = fileConfig.Target
-		termUI.Verbose(fmt.Sprintf("Using target branch from config file: %s", target))
+	if format.MachineReadable() {
+		termUI.SetOutput(os.Stderr)
 	}
 
-	// AI Provider configuration from file
-	if provider == "" && fileConfig.Provider != "" {
-		provider = fileConfig.Provider
-		termUI.Verbose(fmt.Sprintf("Using provider from config file: %s", provider))
-	}
-	if model == "" && fileConfig.Model != "" {
-		model = fileConfig.Model
-		termUI.Verbose(fmt.Sprintf("Using model from config file: %s", model))
-	}
-	if apiKey == "" && fileConfig.APIKey != "" {
-		apiKey = fileConfig.APIKey
-		termUI.Verbose("Using API key from config file")
-	}
-	if systemPrompt == "" && fileConfig.SystemPrompt != "" {
-		systemPrompt = fileConfig.SystemPrompt
-		termUI.Verbose(fmt.Sprintf("Using system prompt from config file: %s", systemPrompt))
-	}
-	if language == "" && fileConfig.Language != "" {
-		language = fileConfig.Language
-		termUI.Verbose(fmt.Sprintf("Using language from config file: %s", language))
-	}
-
-	// Fast mode from config file (only if not set via CLI flag)
-	// Note: For bool flags, cobra sets them to false by default, so we need to check if flag was actually provided
-	if !cmd.Flags().Changed("fast") && fileConfig.FastMode {
-		fastMode = fileConfig.FastMode
-		termUI.Verbose(fmt.Sprintf("Using fast mode from config file: %v", fastMode))
-	}
-
-	// Output file from config
-	if outputFile == "" && fileConfig.Output != "" {
-		outputFile = fileConfig.Output
-		termUI.Verbose(fmt.Sprintf("Using output file from config file: %s", outputFile))
-	}
-
-	// Manual validation for required fields (including environment variables)
-	finalProvider := getProviderFromEnvOrFlag()
-	finalModel := getModelFromEnvOrFlag()
-
-	if finalProvider == "" {
-		return fmt.Errorf("provider is required (can be set via --provider flag, .pullpoet.yml, or PULLPOET_PROVIDER environment variable)")
-	}
+	termUI.Section("Starting PullPoet")
 
-	if finalModel == "" {
-		return fmt.Errorf("model is required (can be set via --model flag, .pullpoet.yml, or PULLPOET_MODEL environment variable)")
+	// CI job values take precedence over the config file, CLI flags over both
+	if ciMode {
+		if err := applyCIEnvironment(termUI); err != nil {
+			return err
+		}
 	}
 
-	// Auto-detect git information if not provided (after config file merge)
-	if repo == "" || source == "" || target == "" {
-		termUI.Info("Auto-detecting git repository information...")
-		gitClient := git.NewClient()
-		gitInfo, err := gitClient.GetGitInfoFromCurrentDir()
-		if err != nil {
-			return fmt.Errorf("auto-detection failed: %w", err)
-		}
-		if !gitInfo.IsGitRepo {
-			return fmt.Errorf("not in a git repository - please provide --repo, --source and --target flags")
-		}
-		if repo == "" {
-			repo = gitInfo.RepoURL
-			fmt.Printf("✅ Auto-detected repository: %s\n", repo)
-		}
-		if source == "" {
-			source = gitInfo.CurrentBranch
-			fmt.Printf("✅ Auto-detected source branch: %s\n", source)
-		}
-		if target == "" {
-			target = gitInfo.DefaultBranch
-			fmt.Printf("✅ Auto-detected target branch (default branch): %s\n", target)
-		}
+	cfg, err := resolveRunConfig(cmd, termUI, fileConfig)
+	if err != nil {
+		return err
 	}
 
 	// Validate configuration
-	fmt.Println("📋 Validating configuration...")
-	cfg := &config.Config{
-		Repo:            repo,
-		Source:          source,
-		Target:          target,
-		Description:     description,
-		Provider:        finalProvider,
-		APIKey:          getAPIKeyFromEnvOrFlag(),
-		ProviderBaseURL: getProviderBaseURLFromEnvOrFlag(),
-		Model:           finalModel,
-		SystemPrompt:    systemPrompt,
-		ClickUpPAT:      getClickUpPATFromEnvOrFlag(),
-		ClickUpTaskID:   clickupTaskID,
-		JiraBaseURL:     getJiraBaseURLFromEnvOrFlag(),
-		JiraUsername:    getJiraUsernameFromEnvOrFlag(),
-		JiraAPIToken:    getJiraAPITokenFromEnvOrFlag(),
-		JiraTaskID:      jiraTaskID,
-		Language:        getLanguageFromEnvOrFlag(),
-	}
+	termUI.Print("📋 Validating configuration...")
 
 	if err := config.Validate(cfg); err != nil {
 		return fmt.Errorf("configuration error: %w", err)
 	}
-	fmt.Printf("✅ Configuration validated - Provider: %s, Model: %s\n", cfg.Provider, cfg.Model)
-
-	// Fetch task description from ClickUp or Jira
-	var finalDescription string
-	if cfg.ClickUpPAT != "" && cfg.ClickUpTaskID != "" {
-		var err error
-		finalDescription, err = fetchClickUpTasks(cfg.ClickUpPAT, cfg.ClickUpTaskID)
-		if err != nil {
-			return err
-		}
-		fmt.Println("✅ All ClickUp tasks fetched successfully")
-	} else if cfg.JiraBaseURL != "" && cfg.JiraUsername != "" && cfg.JiraAPIToken != "" && cfg.JiraTaskID != "" {
-		var err error
-		finalDescription, err = fetchJiraIssues(cfg.JiraBaseURL, cfg.JiraUsername, cfg.JiraAPIToken, cfg.JiraTaskID)
-		if err != nil {
-			return err
-		}
-		fmt.Println("✅ All Jira issues fetched successfully")
-	} else {
-		finalDescription = cfg.Description
-		if finalDescription != "" {
-			fmt.Println("📝 Using manually provided description")
-		} else {
-			fmt.Println("📝 No task description provided")
-		}
+	redactor, err := newRedactor(fileConfig, cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
 	}
+	termUI.Printf("✅ Configuration validated - Provider: %s, Model: %s\n", cfg.Provider, cfg.Model)
+
+	var timings output.Timings
 
 	// Clone repository and get diff with commit information
-	fmt.Printf("📦 Cloning repository: %s\n", cfg.Repo)
-	fmt.Printf("🔄 Analyzing changes between '%s' and '%s' branches...\n", cfg.Source, cfg.Target)
+	termUI.Printf("📦 Cloning repository: %s\n", pr.SanitizeRepoURL(cfg.Repo))
+	termUI.Printf("🔄 Analyzing changes between '%s' and '%s' branches...\n", cfg.Source, cfg.Target)
+
+	// Branches of forks are fetched through the pull request ref of the CI job
+	fetchSource := cfg.Source
+	if ciEnv != nil && ciEnv.SourceRef != "" && cfg.Source == ciEnv.Source {
+		fetchSource = ciEnv.SourceRef
+	}
 
 	var gitResult *git.GitResult
+	gitStartedAt := time.Now()
 
 	if fastMode {
-		fmt.Println("⚡ Using fast mode (native git commands)...")
+		termUI.Print("⚡ Using fast mode (native git commands)...")
 		fastClient := git.NewFastClient()
-		gitResult, err = fastClient.GetDiffWithCommits(cfg.Repo, cfg.Source, cfg.Target)
+		fastClient.SetLogger(termUI)
+		gitResult, err = fastClient.GetDiffWithCommits(cfg.Repo, fetchSource, cfg.Target)
 	} else {
-		fmt.Println("🐹 Using go-git library (optimized)...")
+		termUI.Print("🐹 Using go-git library (optimized)...")
 		gitClient := git.NewClient()
-		gitResult, err = gitClient.GetDiffWithCommits(cfg.Repo, cfg.Source, cfg.Target)
+		gitClient.SetLogger(termUI)
+		gitResult, err = gitClient.GetDiffWithCommits(cfg.Repo, fetchSource, cfg.Target)
 	}
+	timings.Git = time.Since(gitStartedAt).Milliseconds()
 
 	if err != nil {
 		return fmt.Errorf("failed to analyze git changes: %w", err)
 	}
-	fmt.Printf("✅ Git analysis completed successfully (%d characters diff, %d commits)\n", len(gitResult.Diff), len(gitResult.Commits))
+	termUI.Printf("✅ Git analysis completed successfully (%d characters diff, %d commits)\n", len(gitResult.Diff), len(gitResult.Commits))
+
+	// Fetch task description from the configured issue trackers
+	issuesStartedAt := time.Now()
+	var finalDescription string
+	detected, err := detectIssueRefs(termUI, fileConfig, cfg, gitResult.Commits)
+	if err != nil {
+		return err
+	}
+	fetchedIssues, err := fetchIssues(termUI, cfg, detected)
+	if err != nil {
+		return err
+	}
+	if len(fetchedIssues) > 0 {
+		finalDescription = issues.Combine(fetchedIssues)
+		termUI.Print("✅ All issues fetched successfully")
+	} else {
+		finalDescription = cfg.Description
+		if finalDescription != "" {
+			termUI.Print("📝 Using manually provided description")
+		} else {
+			termUI.Print("📝 No task description provided")
+		}
+	}
+	timings.Issues = time.Since(issuesStartedAt).Milliseconds()
 
 	// Create AI client
-	fmt.Printf("🤖 Initializing %s AI client with model '%s'...\n", cfg.Provider, cfg.Model)
-	var aiClient ai.Client
-	switch cfg.Provider {
-	case "openai":
-		aiClient = ai.NewOpenAIClient(cfg.APIKey, cfg.Model)
-	case "ollama":
-		aiClient = ai.NewOllamaClient(cfg.GetProviderBaseURL(), cfg.Model)
-	case "gemini":
-		var geminiErr error
-		aiClient, geminiErr = ai.NewGeminiClient(cfg.APIKey, cfg.Model)
-		if geminiErr != nil {
-			return fmt.Errorf("failed to create Gemini client: %w", geminiErr)
-		}
-	case "openwebui":
-		aiClient = ai.NewOpenWebUIClient(cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model)
-	default:
-		return fmt.Errorf("unsupported provider: %s", cfg.Provider)
-	}
-	fmt.Println("✅ AI client initialized successfully")
+	termUI.Printf("🤖 Initializing %s AI client with model '%s'...\n", cfg.Provider, cfg.Model)
+	aiClient, err := ai.New(cfg.Provider, cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model, termUI)
+	if err != nil {
+		return err
+	}
+	aiClient = withResponseCache(termUI, cfg, aiClient)
+	termUI.Print("✅ AI client initialized successfully")
 
 	// Generate PR description
-	fmt.Println("💭 Building prompt and sending to AI...")
+	termUI.Print("💭 Building prompt and sending to AI...")
 	if cfg.SystemPrompt != "" {
-		fmt.Printf("📝 Using custom system prompt from: %s\n", cfg.SystemPrompt)
+		termUI.Printf("📝 Using custom system prompt from: %s\n", cfg.SystemPrompt)
 	} else {
-		fmt.Println("📝 Using default embedded system prompt")
+		termUI.Print("📝 Using default embedded system prompt")
 	}
 	generator := pr.NewGenerator(aiClient, cfg.SystemPrompt)
+	generator.SetLogger(termUI)
+	generator.SetRedactor(redactor)
+	generator.SetFailOnSecrets(shouldFailOnSecrets(fileConfig))
+	generator.SetLabels(cfg.Labels)
+	policy, err := newPolicy(cfg, fetchedIssues)
+	if err != nil {
+		return err
+	}
+	generator.SetPolicy(policy)
+	generationStartedAt := time.Now()
 	result, err := generator.Generate(gitResult, finalDescription, cfg.Repo, cfg.Language, true)
+	timings.Generation = time.Since(generationStartedAt).Milliseconds()
 	if err != nil {
 		return fmt.Errorf("failed to generate PR description: %w", err)
 	}
-	fmt.Println("✅ AI response received and parsed successfully")
+	termUI.Print("✅ AI response received and parsed successfully")
+
+	if tuiMode {
+		// Regenerating in the review must not return the cached response again
+		if cached, ok := aiClient.(*ai.CachedClient); ok {
+			cached.SetRefresh(true)
+		}
+		result, err = reviewResult(termUI, generator, gitResult, result, finalDescription, cfg.Repo, cfg.Language, true)
+		if errors.Is(err, ui.ErrReviewAborted) {
+			termUI.Warning("Review cancelled, nothing was output")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("review failed: %w", err)
+		}
+	}
+
+	if !cfg.ReviewersDisabled {
+		result.Reviewers = reviewers.Suggest(gitResult, reviewers.OptionsFromConfig(cfg))
+	}
 
 	// Output result
-	fmt.Println("\n" + strings.Repeat("═", 60))
-	fmt.Println("🎉 Generated PR Description")
-	fmt.Println(strings.Repeat("═", 60))
-	fmt.Printf("\n📋 **Title:**\n%s\n", result.Title)
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Printf("\n📝 **Description:**\n%s\n", result.Body)
-	fmt.Println("\n" + strings.Repeat("═", 60))
-	fmt.Println("✅ PR description generated successfully!")
+	if format.MachineReadable() {
+		timings.Total = time.Since(startedAt).Milliseconds()
+		if err := writeDocument(format, aiClient, cfg, gitResult, result, timings); err != nil {
+			return err
+		}
+	} else {
+		termUI.Print("\n" + strings.Repeat("═", 60))
+		termUI.Print("🎉 Generated PR Description")
+		termUI.Print(strings.Repeat("═", 60))
+		termUI.Printf("\n📋 **Title:**\n%s\n", result.Title)
+		termUI.Print(strings.Repeat("-", 60))
+		termUI.Printf("\n📝 **Description:**\n%s\n", result.Markdown())
+		if len(result.Reviewers) > 0 {
+			termUI.Print(strings.Repeat("-", 60))
+			termUI.Print("\n👥 **Suggested Reviewers:**")
+			for _, reviewer := range result.Reviewers {
+				termUI.Printf("   %s (%.2f): %s\n", reviewer.Name, reviewer.Score, strings.Join(reviewer.Reasons, "; "))
+			}
+		}
+		termUI.Print("\n" + strings.Repeat("═", 60))
+		termUI.Print("✅ PR description generated successfully!")
+	}
 
 	// Save to file if output path is provided
 	if outputFile != "" {
 		if err := savePRToFile(result, outputFile); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to save PR to file: %v\n", err)
+			termUI.Printf("⚠️  Warning: Failed to save PR to file: %v\n", err)
 		} else {
-			fmt.Printf("💾 PR content saved to: %s\n", outputFile)
+			termUI.Printf("💾 PR content saved to: %s\n", outputFile)
 		}
 	}
 
-	fmt.Println("💡 You can now copy this content to your pull request.")
+	if ciEnv != nil {
+		if err := publishCIResult(termUI, result); err != nil {
+			return err
+		}
+	}
+
+	if err := writeBackToJira(termUI, fileConfig, cfg, fetchedIssues, result); err != nil {
+		return err
+	}
+	if err := writeBackToClickUp(termUI, fileConfig, cfg, fetchedIssues, result); err != nil {
+		return err
+	}
+
+	termUI.Print("💡 You can now copy this content to your pull request.")
 
 	return nil
 }
 
 func runPreview(cmd *cobra.Command, args []string) error {
+	startedAt := time.Now()
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return err
+	}
+
 	// Load configuration file
 	fileConfig, err := config.LoadConfigFile()
 	if err != nil {
-		fmt.Printf("⚠️  Warning: Failed to load config file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to load config file: %v\n", err)
 		fileConfig = &config.FileConfig{UI: config.DefaultUIConfig()}
 	}
 
@@ -646,225 +1172,156 @@ func runPreview(cmd *cobra.Command, args []string) error {
 		Theme:        fileConfig.UI.Theme,
 	}
 	termUI := ui.New(uiConfig)
-
-	termUI.Section("Starting PullPoet Preview Mode")
-
-	// Merge file config values with CLI flags (file config has lower priority)
-	// Git configuration from file
-	if repo == "" && fileConfig.Repo != "" {
-		repo = fileConfig.Repo
-		termUI.Verbose(fmt.Sprintf("Using repo from config file: %s", repo))
-	}
-	if source == "" && fileConfig.Source != "" {
-		source = fileConfig.Source
-		termUI.Verbose(fmt.Sprintf("Using source branch from config file: %s", source))
-	}
-	if target == "" && fileConfig.Target != "" {
-		target = fileConfig.Target
-		termUI.Verbose(fmt.Sprintf("Using target branch from config file: %s", target))
-	}
-
-	// AI Provider configuration from file
-	if provider == "" && fileConfig.Provider != "" {
-		provider = fileConfig.Provider
-		termUI.Verbose(fmt.Sprintf("Using provider from config file: %s", provider))
-	}
-	if model == "" && fileConfig.Model != "" {
-		model = fileConfig.Model
-		termUI.Verbose(fmt.Sprintf("Using model from config file: %s", model))
-	}
-	if apiKey == "" && fileConfig.APIKey != "" {
-		apiKey = fileConfig.APIKey
-		termUI.Verbose("Using API key from config file")
-	}
-	if systemPrompt == "" && fileConfig.SystemPrompt != "" {
-		systemPrompt = fileConfig.SystemPrompt
-		termUI.Verbose(fmt.Sprintf("Using system prompt from config file: %s", systemPrompt))
-	}
-	if language == "" && fileConfig.Language != "" {
-		language = fileConfig.Language
-		termUI.Verbose(fmt.Sprintf("Using language from config file: %s", language))
+	if format.MachineReadable() {
+		termUI.SetOutput(os.Stderr)
 	}
 
-	// Fast mode from config file (only if not set via CLI flag)
-	if !cmd.Flags().Changed("fast") && fileConfig.FastMode {
-		fastMode = fileConfig.FastMode
-		termUI.Verbose(fmt.Sprintf("Using fast mode from config file: %v", fastMode))
-	}
-
-	// Output file from config
-	if outputFile == "" && fileConfig.Output != "" {
-		outputFile = fileConfig.Output
-		termUI.Verbose(fmt.Sprintf("Using output file from config file: %s", outputFile))
-	}
-
-	// Manual validation for required fields (including environment variables)
-	finalProvider := getProviderFromEnvOrFlag()
-	finalModel := getModelFromEnvOrFlag()
-
-	if finalProvider == "" {
-		return fmt.Errorf("provider is required (can be set via --provider flag, .pullpoet.yml, or PULLPOET_PROVIDER environment variable)")
-	}
-
-	if finalModel == "" {
-		return fmt.Errorf("model is required (can be set via --model flag, .pullpoet.yml, or PULLPOET_MODEL environment variable)")
-	}
+	termUI.Section("Starting PullPoet Preview Mode")
 
-	// Auto-detect git information if not provided (after config file merge)
-	if repo == "" || source == "" || target == "" {
-		termUI.Info("Auto-detecting git repository information...")
-		gitClient := git.NewClient()
-		gitInfo, err := gitClient.GetGitInfoFromCurrentDir()
-		if err != nil {
-			return fmt.Errorf("auto-detection failed: %w", err)
-		}
-		if !gitInfo.IsGitRepo {
-			return fmt.Errorf("not in a git repository - please provide --repo, --source and --target flags or set them in .pullpoet.yml")
-		}
-		if repo == "" {
-			repo = gitInfo.RepoURL
-			termUI.Step(fmt.Sprintf("Repository: %s", repo))
-		}
-		if source == "" {
-			source = gitInfo.CurrentBranch
-			termUI.Step(fmt.Sprintf("Source branch: %s", source))
-		}
-		if target == "" {
-			target = gitInfo.DefaultBranch
-			termUI.Step(fmt.Sprintf("Target branch: %s", target))
-		}
-		termUI.Success("Git repository information detected")
+	cfg, err := resolveRunConfig(cmd, termUI, fileConfig)
+	if err != nil {
+		return err
 	}
 
 	// Validate configuration
-	fmt.Println("📋 Validating configuration...")
-	cfg := &config.Config{
-		Repo:            repo,
-		Source:          source,
-		Target:          target,
-		Description:     description,
-		Provider:        finalProvider,
-		APIKey:          getAPIKeyFromEnvOrFlag(),
-		ProviderBaseURL: getProviderBaseURLFromEnvOrFlag(),
-		Model:           finalModel,
-		SystemPrompt:    systemPrompt,
-		ClickUpPAT:      getClickUpPATFromEnvOrFlag(),
-		ClickUpTaskID:   clickupTaskID,
-		JiraBaseURL:     getJiraBaseURLFromEnvOrFlag(),
-		JiraUsername:    getJiraUsernameFromEnvOrFlag(),
-		JiraAPIToken:    getJiraAPITokenFromEnvOrFlag(),
-		JiraTaskID:      jiraTaskID,
-		Language:        getLanguageFromEnvOrFlag(),
-	}
+	termUI.Print("📋 Validating configuration...")
 
 	if err := config.Validate(cfg); err != nil {
 		return fmt.Errorf("configuration error: %w", err)
 	}
-	fmt.Printf("✅ Configuration validated - Provider: %s, Model: %s\n", cfg.Provider, cfg.Model)
+	redactor, err := newRedactor(fileConfig, cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	termUI.Printf("✅ Configuration validated - Provider: %s, Model: %s\n", cfg.Provider, cfg.Model)
 
-	// Fetch task description from ClickUp or Jira
+	// Fetch task description from the configured issue trackers
+	var timings output.Timings
+	issuesStartedAt := time.Now()
 	var finalDescription string
-	if cfg.ClickUpPAT != "" && cfg.ClickUpTaskID != "" {
-		var err error
-		finalDescription, err = fetchClickUpTasks(cfg.ClickUpPAT, cfg.ClickUpTaskID)
-		if err != nil {
-			return err
-		}
-		fmt.Println("✅ All ClickUp tasks fetched successfully")
-	} else if cfg.JiraBaseURL != "" && cfg.JiraUsername != "" && cfg.JiraAPIToken != "" && cfg.JiraTaskID != "" {
-		var err error
-		finalDescription, err = fetchJiraIssues(cfg.JiraBaseURL, cfg.JiraUsername, cfg.JiraAPIToken, cfg.JiraTaskID)
-		if err != nil {
-			return err
-		}
-		fmt.Println("✅ All Jira issues fetched successfully")
+	detected, err := detectIssueRefs(termUI, fileConfig, cfg, nil)
+	if err != nil {
+		return err
+	}
+	fetchedIssues, err := fetchIssues(termUI, cfg, detected)
+	if err != nil {
+		return err
+	}
+	if len(fetchedIssues) > 0 {
+		finalDescription = issues.Combine(fetchedIssues)
+		termUI.Print("✅ All issues fetched successfully")
 	} else {
 		finalDescription = cfg.Description
 		if finalDescription != "" {
-			fmt.Println("📝 Using manually provided description")
+			termUI.Print("📝 Using manually provided description")
 		} else {
-			fmt.Println("📝 No task description provided")
+			termUI.Print("📝 No task description provided")
 		}
 	}
+	timings.Issues = time.Since(issuesStartedAt).Milliseconds()
 
 	// Get staged changes
-	fmt.Println("📊 Analyzing staged changes...")
+	termUI.Print("📊 Analyzing staged changes...")
+	gitStartedAt := time.Now()
 	gitClient := git.NewClient()
+	gitClient.SetLogger(termUI)
 	stagedDiff, err := gitClient.GetStagedDiff()
+	timings.Git = time.Since(gitStartedAt).Milliseconds()
 	if err != nil {
 		return fmt.Errorf("failed to get staged changes: %w", err)
 	}
 
 	if stagedDiff == "" {
-		fmt.Println("⚠️  No staged changes found. Please run 'git add' to stage your changes first.")
+		termUI.Print("⚠️  No staged changes found. Please run 'git add' to stage your changes first.")
 		return nil
 	}
 
-	fmt.Printf("✅ Found staged changes (%d characters)\n", len(stagedDiff))
+	termUI.Printf("✅ Found staged changes (%d characters)\n", len(stagedDiff))
 
 	// Create AI client
-	fmt.Printf("🤖 Initializing %s AI client with model '%s'...\n", cfg.Provider, cfg.Model)
-	var aiClient ai.Client
-	switch cfg.Provider {
-	case "openai":
-		aiClient = ai.NewOpenAIClient(cfg.APIKey, cfg.Model)
-	case "ollama":
-		aiClient = ai.NewOllamaClient(cfg.GetProviderBaseURL(), cfg.Model)
-	case "gemini":
-		var geminiErr error
-		aiClient, geminiErr = ai.NewGeminiClient(cfg.APIKey, cfg.Model)
-		if geminiErr != nil {
-			return fmt.Errorf("failed to create Gemini client: %w", geminiErr)
-		}
-	case "openwebui":
-		aiClient = ai.NewOpenWebUIClient(cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model)
-	default:
-		return fmt.Errorf("unsupported provider: %s", cfg.Provider)
-	}
-	fmt.Println("✅ AI client initialized successfully")
+	termUI.Printf("🤖 Initializing %s AI client with model '%s'...\n", cfg.Provider, cfg.Model)
+	aiClient, err := ai.New(cfg.Provider, cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model, termUI)
+	if err != nil {
+		return err
+	}
+	aiClient = withResponseCache(termUI, cfg, aiClient)
+	termUI.Print("✅ AI client initialized successfully")
 
 	// Generate preview
-	fmt.Println("💭 Analyzing changes and generating preview...")
+	termUI.Print("💭 Analyzing changes and generating preview...")
 	if cfg.SystemPrompt != "" {
-		fmt.Printf("📝 Using custom system prompt from: %s\n", cfg.SystemPrompt)
+		termUI.Printf("📝 Using custom system prompt from: %s\n", cfg.SystemPrompt)
 	} else {
-		fmt.Println("📝 Using default embedded system prompt")
+		termUI.Print("📝 Using default embedded system prompt")
 	}
 	generator := pr.NewGenerator(aiClient, cfg.SystemPrompt)
+	generator.SetLogger(termUI)
+	generator.SetRedactor(redactor)
+	generator.SetFailOnSecrets(shouldFailOnSecrets(fileConfig))
+	generator.SetLabels(cfg.Labels)
+	policy, err := newPolicy(cfg, fetchedIssues)
+	if err != nil {
+		return err
+	}
+	generator.SetPolicy(policy)
 
 	// Create a GitResult with staged diff
 	gitResult := &git.GitResult{
 		Diff:          stagedDiff,
 		Commits:       []git.CommitInfo{}, // No commits for staged changes
-		DefaultBranch: target,
+		DefaultBranch: cfg.Target,
 	}
 
+	generationStartedAt := time.Now()
 	result, err := generator.Generate(gitResult, finalDescription, cfg.Repo, cfg.Language, false)
+	timings.Generation = time.Since(generationStartedAt).Milliseconds()
 	if err != nil {
 		return fmt.Errorf("failed to generate preview: %w", err)
 	}
-	fmt.Println("✅ Analysis completed successfully")
+	termUI.Print("✅ Analysis completed successfully")
+
+	if tuiMode {
+		// Regenerating in the review must not return the cached response again
+		if cached, ok := aiClient.(*ai.CachedClient); ok {
+			cached.SetRefresh(true)
+		}
+		result, err = reviewResult(termUI, generator, gitResult, result, finalDescription, cfg.Repo, cfg.Language, false)
+		if errors.Is(err, ui.ErrReviewAborted) {
+			termUI.Warning("Review cancelled, nothing was output")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("review failed: %w", err)
+		}
+	}
 
 	// Output result
-	fmt.Println("\n" + strings.Repeat("═", 60))
-	fmt.Println("🔍 Preview of Changes (Staged)")
-	fmt.Println(strings.Repeat("═", 60))
-	fmt.Printf("\n📋 **Analysis Summary:**\n%s\n", result.Title)
-	fmt.Println(strings.Repeat("-", 60))
-	fmt.Printf("\n📝 **Detailed Analysis:**\n%s\n", result.Body)
-	fmt.Println("\n" + strings.Repeat("═", 60))
-	fmt.Println("✅ Preview generated successfully!")
+	if format.MachineReadable() {
+		timings.Total = time.Since(startedAt).Milliseconds()
+		if err := writeDocument(format, aiClient, cfg, gitResult, result, timings); err != nil {
+			return err
+		}
+	} else {
+		termUI.Print("\n" + strings.Repeat("═", 60))
+		termUI.Print("🔍 Preview of Changes (Staged)")
+		termUI.Print(strings.Repeat("═", 60))
+		termUI.Printf("\n📋 **Analysis Summary:**\n%s\n", result.Title)
+		termUI.Print(strings.Repeat("-", 60))
+		termUI.Printf("\n📝 **Detailed Analysis:**\n%s\n", result.Markdown())
+		termUI.Print("\n" + strings.Repeat("═", 60))
+		termUI.Print("✅ Preview generated successfully!")
+	}
 
 	// Save to file if output path is provided
 	if outputFile != "" {
 		if err := savePRToFile(result, outputFile); err != nil {
-			fmt.Printf("⚠️  Warning: Failed to save preview to file: %v\n", err)
+			termUI.Printf("⚠️  Warning: Failed to save preview to file: %v\n", err)
 		} else {
-			fmt.Printf("💾 Preview content saved to: %s\n", outputFile)
+			termUI.Printf("💾 Preview content saved to: %s\n", outputFile)
 		}
 	}
 
-	fmt.Println("💡 You can review these changes before committing.")
+	termUI.Print("💡 You can review these changes before committing.")
 
 	return nil
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/mcp"
+
+	"github.com/spf13/cobra"
+)
+
+var mcpVerbose bool
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run an MCP server over stdio for editors and agents",
+	Long: `Starts a Model Context Protocol server that speaks JSON-RPC over stdin/stdout.
+Editors and coding agents can call the following tools:
+
+  describe_branch          Describe the changes between two branches
+  describe_staged          Describe the staged changes
+  fetch_issue              Fetch a Jira, Linear, GitHub or GitLab issue or ClickUp task
+  generate_commit_message  Write a commit message for the staged changes
+
+Configuration is resolved like the other commands: flags, .pullpoet.yml, then environment variables.`,
+	RunE: runMCP,
+}
+
+func init() {
+	mcpCmd.Flags().StringVar(&provider, "provider", "", "AI provider: 'openai', 'ollama', 'gemini', or 'openwebui' (can also be set via PULLPOET_PROVIDER env var)")
+	mcpCmd.Flags().StringVar(&apiKey, "api-key", "", "API key for OpenAI or Gemini (can also be set via PULLPOET_API_KEY env var)")
+	mcpCmd.Flags().StringVar(&providerBaseURL, "provider-base-url", "", "Base URL for AI provider (can also be set via PULLPOET_PROVIDER_BASE_URL env var)")
+	mcpCmd.Flags().StringVar(&model, "model", "", "AI model to use (can also be set via PULLPOET_MODEL env var)")
+	mcpCmd.Flags().StringVar(&systemPrompt, "system-prompt", "", "Custom system prompt file path to override default (optional)")
+	mcpCmd.Flags().StringVar(&language, "language", "", "Language for the generated content (default: en, can also be set via PULLPOET_LANGUAGE env var)")
+	mcpCmd.Flags().BoolVar(&fastMode, "fast", false, "Use native git commands for branch diffs")
+	mcpCmd.Flags().StringVar(&clickupPAT, "clickup-pat", "", "ClickUp Personal Access Token used by fetch_issue (can also be set via PULLPOET_CLICKUP_PAT env var)")
+	mcpCmd.Flags().StringVar(&jiraBaseURL, "jira-base-url", "", "Jira base URL used by fetch_issue (can also be set via PULLPOET_JIRA_BASE_URL env var)")
+	mcpCmd.Flags().StringVar(&jiraUsername, "jira-username", "", "Jira username/email (can also be set via PULLPOET_JIRA_USERNAME env var)")
+	mcpCmd.Flags().StringVar(&jiraAPIToken, "jira-api-token", "", "Jira API token (can also be set via PULLPOET_JIRA_API_TOKEN env var)")
+	mcpCmd.Flags().StringVar(&jiraDeployment, "jira-deployment", "", "Jira deployment: cloud or server, detected if not set (can also be set via PULLPOET_JIRA_DEPLOYMENT env var)")
+	mcpCmd.Flags().StringVar(&linearAPIKey, "linear-api-key", "", "Linear API key used by fetch_issue (can also be set via PULLPOET_LINEAR_API_KEY env var)")
+	mcpCmd.Flags().StringVar(&githubToken, "github-token", "", "GitHub token used by fetch_issue (can also be set via PULLPOET_GITHUB_TOKEN env var)")
+	mcpCmd.Flags().StringVar(&gitlabToken, "gitlab-token", "", "GitLab token used by fetch_issue (can also be set via PULLPOET_GITLAB_TOKEN env var)")
+	mcpCmd.Flags().BoolVar(&failOnSecrets, "fail-on-secrets", false, "Fail tool calls instead of redacting when secrets are detected")
+	mcpCmd.Flags().BoolVar(&mcpVerbose, "verbose", false, "Log tool progress to stderr")
+
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	// stdout carries the protocol, everything else goes to stderr
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to load config file: %v\n", err)
+		fileConfig = &config.FileConfig{UI: config.DefaultUIConfig()}
+	}
+
+	cfg := resolveConfig(fileConfig)
+	if cfg.Provider == "" || cfg.Model == "" {
+		return fmt.Errorf("provider and model are required (can be set via flags, .pullpoet.yml, or PULLPOET_PROVIDER/PULLPOET_MODEL environment variables)")
+	}
+	if !cmd.Flags().Changed("fast") && fileConfig.FastMode {
+		fastMode = true
+	}
+
+	redactor, err := newRedactor(fileConfig, cfg)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	log := logger.Discard
+	if mcpVerbose || fileConfig.UI.Verbose {
+		log = logger.New(os.Stderr)
+	}
+
+	// Fail fast on provider misconfiguration instead of on the first tool call
+	if _, err := ai.New(cfg.Provider, cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model, logger.Discard); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	server := mcp.New(mcp.Config{
+		Settings: cfg,
+		NewAIClient: func() (ai.Client, error) {
+			return ai.New(cfg.Provider, cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model, log)
+		},
+		Redactor:      redactor,
+		FailOnSecrets: shouldFailOnSecrets(fileConfig),
+		Fast:          fastMode,
+		Version:       version,
+		Log:           log,
+	})
+	fmt.Fprintf(os.Stderr, "🔌 PullPoet MCP server ready (provider: %s, model: %s)\n", cfg.Provider, cfg.Model)
+	return server.Serve(os.Stdin, os.Stdout)
+}
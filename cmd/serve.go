@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/forge"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/pr"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+	"github.com/erkineren/pullpoet/internal/server"
+	"github.com/erkineren/pullpoet/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr           string
+	serveWorkers        int
+	serveQueueSize      int
+	serveMode           string
+	githubToken         string
+	githubAPIURL        string
+	githubWebhookSecret string
+	gitlabToken         string
+	gitlabAPIURL        string
+	gitlabWebhookSecret string
+	serveInsecure       bool
+)
+
+// Environment variable names for the webhook server
+const (
+	EnvGitHubToken         = "PULLPOET_GITHUB_TOKEN"
+	EnvGitHubWebhookSecret = "PULLPOET_GITHUB_WEBHOOK_SECRET"
+	EnvGitLabToken         = "PULLPOET_GITLAB_TOKEN"
+	EnvGitLabWebhookSecret = "PULLPOET_GITLAB_WEBHOOK_SECRET"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run a webhook server that describes newly opened pull requests",
+	Long: `Starts an HTTP server that receives GitHub pull_request and GitLab merge request webhooks.
+New pull requests without a human-written description are analyzed in the background and
+their description is updated (or a comment is posted) with the generated content.
+
+Endpoints: /webhooks/github, /webhooks/gitlab, /healthz and /metrics (Prometheus).`,
+	RunE: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveAddr, "addr", ":8080", "Address to listen on")
+	serveCmd.Flags().IntVar(&serveWorkers, "workers", 2, "Number of pull requests described concurrently")
+	serveCmd.Flags().IntVar(&serveQueueSize, "queue-size", 100, "Maximum number of queued pull requests, further webhooks are rejected with 503")
+	serveCmd.Flags().StringVar(&serveMode, "mode", server.ModeUpdate, "How to publish the description: 'update' replaces the PR body, 'comment' posts a comment")
+
+	serveCmd.Flags().StringVar(&provider, "provider", "", "AI provider: 'openai', 'ollama', 'gemini', or 'openwebui' (can also be set via PULLPOET_PROVIDER env var)")
+	serveCmd.Flags().StringVar(&apiKey, "api-key", "", "API key for OpenAI or Gemini (can also be set via PULLPOET_API_KEY env var)")
+	serveCmd.Flags().StringVar(&providerBaseURL, "provider-base-url", "", "Base URL for AI provider (can also be set via PULLPOET_PROVIDER_BASE_URL env var)")
+	serveCmd.Flags().StringVar(&model, "model", "", "AI model to use (can also be set via PULLPOET_MODEL env var)")
+	serveCmd.Flags().StringVar(&systemPrompt, "system-prompt", "", "Custom system prompt file path to override default (optional)")
+	serveCmd.Flags().StringVar(&language, "language", "", "Language for the generated PR description (default: en, can also be set via PULLPOET_LANGUAGE env var)")
+
+	serveCmd.Flags().StringVar(&githubToken, "github-token", "", "GitHub token used to clone repositories and update pull requests (can also be set via PULLPOET_GITHUB_TOKEN env var)")
+	serveCmd.Flags().StringVar(&githubAPIURL, "github-api-url", "", "GitHub API URL (default: https://api.github.com, set for GitHub Enterprise)")
+	serveCmd.Flags().StringVar(&githubWebhookSecret, "github-webhook-secret", "", "Secret used to verify GitHub webhook signatures (can also be set via PULLPOET_GITHUB_WEBHOOK_SECRET env var)")
+	serveCmd.Flags().StringVar(&gitlabToken, "gitlab-token", "", "GitLab token used to clone repositories and update merge requests (can also be set via PULLPOET_GITLAB_TOKEN env var)")
+	serveCmd.Flags().StringVar(&gitlabAPIURL, "gitlab-api-url", "", "GitLab API URL (default: https://gitlab.com/api/v4)")
+	serveCmd.Flags().StringVar(&gitlabWebhookSecret, "gitlab-webhook-secret", "", "Secret token configured on GitLab webhooks (can also be set via PULLPOET_GITLAB_WEBHOOK_SECRET env var)")
+	serveCmd.Flags().BoolVar(&serveInsecure, "insecure", false, "Accept unverified webhooks when no webhook secret is configured (local testing only)")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	// Load configuration file
+	fileConfig, err := config.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: Failed to load config file: %v\n", err)
+		fileConfig = &config.FileConfig{UI: config.DefaultUIConfig()}
+	}
+
+	termUI := ui.New(ui.Config{
+		Colors:       fileConfig.UI.Colors,
+		ProgressBars: false,
+		Emoji:        fileConfig.UI.Emoji,
+		Verbose:      fileConfig.UI.Verbose,
+		Theme:        fileConfig.UI.Theme,
+	})
+	termUI.Section("Starting PullPoet Webhook Server")
+
+	cfg := resolveConfig(fileConfig)
+	if cfg.Provider == "" || cfg.Model == "" {
+		return fmt.Errorf("provider and model are required (can be set via flags, .pullpoet.yml, or PULLPOET_PROVIDER/PULLPOET_MODEL environment variables)")
+	}
+	if serveMode != server.ModeUpdate && serveMode != server.ModeComment {
+		return fmt.Errorf("invalid mode %q: must be '%s' or '%s'", serveMode, server.ModeUpdate, server.ModeComment)
+	}
+
+	// Fail fast on provider misconfiguration instead of on the first webhook
+	if _, err := ai.New(cfg.Provider, cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model, logger.Discard); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	// Issues are not fetched for webhooks, any issue key satisfies require_issue_key
+	policy, err := pr.NewPolicy(cfg.Policy, nil)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if _, err := newRedactor(fileConfig, cfg); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	serverConfig := server.Config{
+		GitHubSecret:     flagOrEnv(githubWebhookSecret, EnvGitHubWebhookSecret),
+		GitLabToken:      flagOrEnv(gitlabWebhookSecret, EnvGitLabWebhookSecret),
+		Mode:             serveMode,
+		RequestReviewers: cfg.RequestReviewers && !cfg.ReviewersDisabled,
+		Workers:          serveWorkers,
+		QueueSize:        serveQueueSize,
+		Insecure:         serveInsecure,
+		Log:              termUI,
+	}
+
+	if cfg.GitHubToken != "" {
+		serverConfig.GitHub = forge.NewGitHubClient(cfg.GitHubAPIURL, cfg.GitHubToken)
+		termUI.Step("GitHub webhooks enabled at /webhooks/github")
+		if serverConfig.GitHubSecret == "" {
+			if !serveInsecure {
+				return fmt.Errorf("no GitHub webhook secret configured: set --github-webhook-secret or %s (or pass --insecure for local testing)", EnvGitHubWebhookSecret)
+			}
+			termUI.Warning("No GitHub webhook secret configured, signatures are not verified (--insecure)")
+		}
+	}
+	if cfg.GitLabToken != "" {
+		serverConfig.GitLab = forge.NewGitLabClient(cfg.GitLabAPIURL, cfg.GitLabToken)
+		termUI.Step("GitLab webhooks enabled at /webhooks/gitlab")
+		if serverConfig.GitLabToken == "" {
+			if !serveInsecure {
+				return fmt.Errorf("no GitLab webhook secret configured: set --gitlab-webhook-secret or %s (or pass --insecure for local testing)", EnvGitLabWebhookSecret)
+			}
+			termUI.Warning("No GitLab webhook secret configured, tokens are not verified (--insecure)")
+		}
+	}
+	if serverConfig.GitHub == nil && serverConfig.GitLab == nil {
+		return fmt.Errorf("no forge configured: set --github-token and/or --gitlab-token")
+	}
+
+	// Per-job git and AI progress is only shown in verbose mode, jobs run concurrently
+	jobLog := logger.Discard
+	if fileConfig.UI.Verbose {
+		jobLog = termUI
+	}
+	serverConfig.Describer = &server.GitDescriber{
+		NewAIClient: func() (ai.Client, error) {
+			return ai.New(cfg.Provider, cfg.GetProviderBaseURL(), cfg.APIKey, cfg.Model, jobLog)
+		},
+		SystemPrompt: cfg.SystemPrompt,
+		Language:     cfg.Language,
+		Labels:       cfg.Labels,
+		Policy:       policy,
+		NewRedactor: func() (*redact.Redactor, error) {
+			return newRedactor(fileConfig, cfg)
+		},
+		FailOnSecrets:     shouldFailOnSecrets(fileConfig),
+		Reviewers:         reviewers.OptionsFromConfig(cfg),
+		ReviewersDisabled: cfg.ReviewersDisabled,
+		GitHubToken:       cfg.GitHubToken,
+		GitLabToken:       cfg.GitLabToken,
+		Log:               jobLog,
+	}
+
+	srv := server.New(serverConfig)
+	srv.Start()
+
+	httpServer := &http.Server{
+		Addr:              serveAddr,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	termUI.Success(fmt.Sprintf("Listening on %s (provider: %s, model: %s, mode: %s, workers: %d)", serveAddr, cfg.Provider, cfg.Model, serveMode, serveWorkers))
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-errCh:
+		srv.Stop()
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-stop:
+	}
+
+	termUI.Info("Shutting down, waiting for queued jobs to finish...")
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	srv.Stop()
+	termUI.Success("Server stopped")
+	return nil
+}
@@ -3,6 +3,16 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
+)
+
+// DefaultIssueCacheTTL is how long tracker responses are cached on disk
+const DefaultIssueCacheTTL = 5 * time.Minute
+
+// Defaults of the on-disk cache of AI responses
+const (
+	DefaultResponseCacheTTL     = 7 * 24 * time.Hour
+	DefaultResponseCacheMaxSize = 50 << 20
 )
 
 // Config holds the application configuration
@@ -17,14 +27,61 @@ type Config struct {
 	Model           string
 	SystemPrompt    string
 	Language        string
+	// Labels the AI may suggest, none are suggested if empty
+	Labels []string
+	// Policy the generated title and body must follow, nil if none
+	Policy *PolicyConfig
 	// ClickUp integration fields
-	ClickUpPAT    string
-	ClickUpTaskID string
+	ClickUpPAT     string
+	ClickUpTaskID  string
+	ClickUpBaseURL string
+	// ClickUpCustomFields are custom field names or IDs included in the task context, all if empty
+	ClickUpCustomFields []string
 	// Jira integration fields
 	JiraBaseURL  string
 	JiraUsername string
 	JiraAPIToken string
 	JiraTaskID   string
+	// JiraCustomFields are custom field IDs included in the issue context, e.g. acceptance criteria
+	JiraCustomFields []string
+	// JiraDeployment is "cloud", "server" or empty to detect it
+	JiraDeployment string
+	// Linear integration fields
+	LinearAPIKey  string
+	LinearIssueID string
+	// Issue references (e.g. "jira:HIP-12,#123"), fetched from all configured trackers
+	IssueRef string
+	// GitHub/GitLab fields, tokens are shared with the forge publisher
+	GitHubToken  string
+	GitHubAPIURL string
+	GitLabToken  string
+	GitLabAPIURL string
+	// Issue fetching: concurrent issues, requests per second per host and
+	// response cache TTL; zero uses the defaults, a zero TTL disables the cache
+	IssueFetchConcurrency  int
+	IssueRequestsPerSecond float64
+	IssueCacheTTL          time.Duration
+	// Issue context: newest comments kept (0 keeps all), whether bot comments
+	// are kept, extra bot author names and the share of the model's context
+	// window used for issues; ModelContextTokens overrides the known window
+	IssueMaxComments   int
+	IssueKeepBots      bool
+	IssueBotAuthors    []string
+	IssueContextShare  float64
+	ModelContextTokens int
+	// Reviewer suggestions from CODEOWNERS and the git history: number of
+	// reviewers and commit half-life (zero uses the defaults), names never
+	// suggested and whether the forge is asked to request the reviewers
+	ReviewersDisabled bool
+	ReviewersMax      int
+	ReviewersHalfLife time.Duration
+	ReviewersExclude  []string
+	RequestReviewers  bool
+	// AI response cache: TTL (zero disables the cache), total size in bytes
+	// and whether cached responses are replaced by new ones
+	ResponseCacheTTL     time.Duration
+	ResponseCacheMaxSize int64
+	ResponseCacheRefresh bool
 }
 
 // GetProviderBaseURL returns the appropriate base URL for the provider
@@ -96,17 +153,23 @@ func Validate(cfg *Config) error {
 		if cfg.JiraBaseURL == "" {
 			return fmt.Errorf("Jira base URL is required when task ID is provided (can be set via --jira-base-url flag or PULLPOET_JIRA_BASE_URL environment variable)")
 		}
-		if cfg.JiraUsername == "" {
-			return fmt.Errorf("Jira username is required when task ID is provided (can be set via --jira-username flag or PULLPOET_JIRA_USERNAME environment variable)")
+		if cfg.JiraUsername == "" && strings.EqualFold(cfg.JiraDeployment, "cloud") {
+			return fmt.Errorf("Jira username is required for Jira Cloud when task ID is provided (can be set via --jira-username flag or PULLPOET_JIRA_USERNAME environment variable)")
 		}
 		if cfg.JiraAPIToken == "" {
 			return fmt.Errorf("Jira API token is required when task ID is provided (can be set via --jira-api-token flag or PULLPOET_JIRA_API_TOKEN environment variable)")
 		}
 	}
 
-	// Cannot use both ClickUp and Jira task IDs at the same time
-	if cfg.ClickUpTaskID != "" && cfg.JiraTaskID != "" {
-		return fmt.Errorf("cannot use both ClickUp and Jira task IDs at the same time - please provide only one")
+	switch strings.ToLower(cfg.JiraDeployment) {
+	case "", "auto", "cloud", "server", "datacenter", "data-center":
+	default:
+		return fmt.Errorf("unknown Jira deployment %q (use cloud or server)", cfg.JiraDeployment)
+	}
+
+	// Linear validation: issue ID requires API key
+	if cfg.LinearIssueID != "" && cfg.LinearAPIKey == "" {
+		return fmt.Errorf("Linear API key is required when issue ID is provided (can be set via --linear-api-key flag or PULLPOET_LINEAR_API_KEY environment variable)")
 	}
 
 	return nil
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -26,21 +27,54 @@ type FileConfig struct {
 	Language     string `yaml:"language,omitempty"`
 	FastMode     bool   `yaml:"fast_mode,omitempty"`
 	Output       string `yaml:"output,omitempty"`
+	// Labels the AI may suggest for a pull request
+	Labels []string `yaml:"labels,omitempty"`
 
 	// Integrations
 	ClickUp *ClickUpConfig `yaml:"clickup,omitempty"`
 	Jira    *JiraConfig    `yaml:"jira,omitempty"`
+	Linear  *LinearConfig  `yaml:"linear,omitempty"`
+	GitHub  *ForgeConfig   `yaml:"github,omitempty"`
+	GitLab  *ForgeConfig   `yaml:"gitlab,omitempty"`
+
+	// Issue key detection from branch names and commit messages
+	IssueDetection *IssueDetectionConfig `yaml:"issue_detection,omitempty"`
+
+	// Concurrency, rate limiting and caching of issue tracker requests
+	IssueFetch *IssueFetchConfig `yaml:"issue_fetch,omitempty"`
+
+	// Comment filtering and the token budget of the issue context
+	IssueContext *IssueContextConfig `yaml:"issue_context,omitempty"`
+
+	// Reviewer suggestions from CODEOWNERS and the git history
+	Reviewers *ReviewersConfig `yaml:"reviewers,omitempty"`
+
+	// Rules the generated PR title and body must follow
+	Policy *PolicyConfig `yaml:"policy,omitempty"`
+
+	// On-disk cache of AI responses
+	Cache *CacheConfig `yaml:"cache,omitempty"`
 
 	// UI Settings
 	UI *UIConfig `yaml:"ui,omitempty"`
 
+	// Secret redaction
+	Redact *RedactConfig `yaml:"redact,omitempty"`
+
 	// Custom fields
 	CustomFields map[string]interface{} `yaml:",inline"`
 }
 
 // ClickUpConfig holds ClickUp-specific configuration
 type ClickUpConfig struct {
-	PAT string `yaml:"pat,omitempty"`
+	PAT     string `yaml:"pat,omitempty"`
+	BaseURL string `yaml:"base_url,omitempty"`
+	// CustomFields are custom field names or IDs included in the task context, all if empty
+	CustomFields []string `yaml:"custom_fields,omitempty"`
+	// Write-back after generation
+	Comment bool   `yaml:"comment,omitempty"`
+	Status  string `yaml:"status,omitempty"`
+	PRField string `yaml:"pr_field,omitempty"`
 }
 
 // JiraConfig holds Jira-specific configuration
@@ -48,6 +82,99 @@ type JiraConfig struct {
 	BaseURL  string `yaml:"base_url,omitempty"`
 	Username string `yaml:"username,omitempty"`
 	APIToken string `yaml:"api_token,omitempty"`
+	// Deployment is "cloud" or "server" (Server/Data Center), detected if empty
+	Deployment string `yaml:"deployment,omitempty"`
+	// CustomFields are custom field IDs included in the issue context
+	CustomFields []string `yaml:"custom_fields,omitempty"`
+	// Write-back after generation
+	Comment    bool   `yaml:"comment,omitempty"`
+	Transition string `yaml:"transition,omitempty"`
+}
+
+// LinearConfig holds Linear-specific configuration
+type LinearConfig struct {
+	APIKey string `yaml:"api_key,omitempty"`
+}
+
+// ForgeConfig holds GitHub or GitLab configuration, used for issues and publishing
+type ForgeConfig struct {
+	Token  string `yaml:"token,omitempty"`
+	APIURL string `yaml:"api_url,omitempty"`
+}
+
+// IssueDetectionConfig holds the settings for detecting issue keys
+type IssueDetectionConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"`
+	// Tracker the detected keys are fetched from (default: jira, then linear)
+	Tracker  string   `yaml:"tracker,omitempty"`
+	Patterns []string `yaml:"patterns,omitempty"`
+	Projects []string `yaml:"projects,omitempty"`
+}
+
+// IssueFetchConfig holds the settings for fetching issues from the trackers
+type IssueFetchConfig struct {
+	Concurrency       int           `yaml:"concurrency,omitempty"`
+	RequestsPerSecond float64       `yaml:"requests_per_second,omitempty"`
+	CacheTTL          time.Duration `yaml:"cache_ttl,omitempty"`
+	NoCache           bool          `yaml:"no_cache,omitempty"`
+}
+
+// IssueContextConfig holds the settings for trimming the issue context
+type IssueContextConfig struct {
+	// MaxComments is the number of newest comments kept per issue, -1 keeps all
+	MaxComments int      `yaml:"max_comments,omitempty"`
+	KeepBots    bool     `yaml:"keep_bots,omitempty"`
+	BotAuthors  []string `yaml:"bot_authors,omitempty"`
+	// BudgetShare is the share of the model's context window used for issues
+	BudgetShare float64 `yaml:"budget_share,omitempty"`
+	// ModelContextTokens overrides the context window of the model
+	ModelContextTokens int `yaml:"model_context_tokens,omitempty"`
+}
+
+// ReviewersConfig holds the settings for reviewer suggestions
+type ReviewersConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"`
+	Max      int  `yaml:"max,omitempty"`
+	// HalfLife is the age at which a commit counts half
+	HalfLife time.Duration `yaml:"half_life,omitempty"`
+	// Exclude are names, emails or handles never suggested, e.g. bots
+	Exclude []string `yaml:"exclude,omitempty"`
+	// Request asks GitHub/GitLab to request the reviewers in pullpoet serve
+	Request bool `yaml:"request,omitempty"`
+}
+
+// PolicyConfig holds the rules for the generated PR title and body; zero values disable a rule
+type PolicyConfig struct {
+	TitleMaxLength int `yaml:"title_max_length,omitempty"`
+	// TitlePrefix is a regular expression the title must start with, or "conventional"
+	TitlePrefix string `yaml:"title_prefix,omitempty"`
+	// RequireIssueKey requires the key of a referenced issue in the title
+	RequireIssueKey bool     `yaml:"require_issue_key,omitempty"`
+	ForbiddenWords  []string `yaml:"forbidden_words,omitempty"`
+	// RequiredSections are Markdown headings the body must contain
+	RequiredSections []string `yaml:"required_sections,omitempty"`
+	BodyMaxLength    int      `yaml:"body_max_length,omitempty"`
+}
+
+// CacheConfig holds the settings of the AI response cache
+type CacheConfig struct {
+	Disabled bool          `yaml:"disabled,omitempty"`
+	TTL      time.Duration `yaml:"ttl,omitempty"`
+	// MaxSizeMB is the total size of the cached responses, the oldest are removed above it
+	MaxSizeMB int `yaml:"max_size_mb,omitempty"`
+}
+
+// RedactConfig holds secret redaction settings
+type RedactConfig struct {
+	Disabled      bool            `yaml:"disabled,omitempty"`
+	FailOnSecrets bool            `yaml:"fail_on_secrets,omitempty"`
+	Patterns      []RedactPattern `yaml:"patterns,omitempty"`
+}
+
+// RedactPattern is a custom regular expression whose matches are redacted
+type RedactPattern struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
 }
 
 // UIConfig holds UI-specific configuration
@@ -161,6 +288,15 @@ func expandEnvVars(config *FileConfig) {
 		config.Jira.Username = os.ExpandEnv(config.Jira.Username)
 		config.Jira.APIToken = os.ExpandEnv(config.Jira.APIToken)
 	}
+	if config.Linear != nil {
+		config.Linear.APIKey = os.ExpandEnv(config.Linear.APIKey)
+	}
+	if config.GitHub != nil {
+		config.GitHub.Token = os.ExpandEnv(config.GitHub.Token)
+	}
+	if config.GitLab != nil {
+		config.GitLab.Token = os.ExpandEnv(config.GitLab.Token)
+	}
 }
 
 // MergeWithConfig merges FileConfig into runtime Config
@@ -198,11 +334,23 @@ func (fc *FileConfig) MergeWithConfig(cfg *Config) {
 	if cfg.Language == "" && fc.Language != "" {
 		cfg.Language = fc.Language
 	}
+	if len(cfg.Labels) == 0 {
+		cfg.Labels = fc.Labels
+	}
+	if cfg.Policy == nil {
+		cfg.Policy = fc.Policy
+	}
 
 	// ClickUp config
 	if cfg.ClickUpPAT == "" && fc.ClickUp != nil && fc.ClickUp.PAT != "" {
 		cfg.ClickUpPAT = fc.ClickUp.PAT
 	}
+	if cfg.ClickUpBaseURL == "" && fc.ClickUp != nil && fc.ClickUp.BaseURL != "" {
+		cfg.ClickUpBaseURL = fc.ClickUp.BaseURL
+	}
+	if len(cfg.ClickUpCustomFields) == 0 && fc.ClickUp != nil {
+		cfg.ClickUpCustomFields = fc.ClickUp.CustomFields
+	}
 
 	// Jira config
 	if cfg.JiraBaseURL == "" && fc.Jira != nil && fc.Jira.BaseURL != "" {
@@ -214,6 +362,35 @@ func (fc *FileConfig) MergeWithConfig(cfg *Config) {
 	if cfg.JiraAPIToken == "" && fc.Jira != nil && fc.Jira.APIToken != "" {
 		cfg.JiraAPIToken = fc.Jira.APIToken
 	}
+	if cfg.JiraDeployment == "" && fc.Jira != nil {
+		cfg.JiraDeployment = fc.Jira.Deployment
+	}
+	if len(cfg.JiraCustomFields) == 0 && fc.Jira != nil {
+		cfg.JiraCustomFields = fc.Jira.CustomFields
+	}
+
+	// Linear config
+	if cfg.LinearAPIKey == "" && fc.Linear != nil && fc.Linear.APIKey != "" {
+		cfg.LinearAPIKey = fc.Linear.APIKey
+	}
+
+	// GitHub/GitLab config
+	if fc.GitHub != nil {
+		if cfg.GitHubToken == "" {
+			cfg.GitHubToken = fc.GitHub.Token
+		}
+		if cfg.GitHubAPIURL == "" {
+			cfg.GitHubAPIURL = fc.GitHub.APIURL
+		}
+	}
+	if fc.GitLab != nil {
+		if cfg.GitLabToken == "" {
+			cfg.GitLabToken = fc.GitLab.Token
+		}
+		if cfg.GitLabAPIURL == "" {
+			cfg.GitLabAPIURL = fc.GitLab.APIURL
+		}
+	}
 }
 
 // GenerateExampleConfig generates an example .pullpoet.yml file
@@ -238,16 +415,84 @@ language: en  # Language for generated content (en, tr, es, fr, de, etc.)
 # fast_mode: true  # Use fast native git commands for large repos
 # output: pr-description.md  # Save output to file
 # system_prompt: /path/to/custom-prompt.md  # Custom system prompt
+# labels: [bug, enhancement, documentation, breaking-change]  # Labels the AI may suggest
 
 # ClickUp Integration
 clickup:
   pat: ${PULLPOET_CLICKUP_PAT}  # ClickUp Personal Access Token
+  # base_url: https://api.clickup.com/api/v2  # API base URL (e.g. a proxy)
+  # custom_fields: [Acceptance Criteria]  # Custom fields to include by name or ID (default: all)
+  # comment: true  # Comment the PR title, link and summary on referenced tasks
+  # status: in review  # Move referenced tasks to this status
+  # pr_field: 0a1b2c3d-...  # ID of a URL custom field set to the PR link
 
 # Jira Integration
 jira:
   base_url: ${PULLPOET_JIRA_BASE_URL}  # e.g., https://company.atlassian.net
-  username: ${PULLPOET_JIRA_USERNAME}  # Your Jira email
-  api_token: ${PULLPOET_JIRA_API_TOKEN}  # Jira API token
+  username: ${PULLPOET_JIRA_USERNAME}  # Your Jira email (leave empty for a Server/Data Center PAT)
+  api_token: ${PULLPOET_JIRA_API_TOKEN}  # Jira API token or Server/Data Center personal access token
+  # deployment: server  # cloud or server (Server/Data Center), detected if not set
+  # custom_fields: [customfield_10042]  # Custom fields to include, e.g. acceptance criteria
+  # comment: true  # Comment the PR title, link and summary on referenced issues
+  # transition: In Review  # Move referenced issues to this status
+
+# Linear Integration
+# linear:
+#   api_key: ${PULLPOET_LINEAR_API_KEY}  # Linear personal API key
+
+# Issue key detection from branch names and commit messages (e.g. feature/HIP-1234-add-login)
+# issue_detection:
+#   disabled: false
+#   tracker: jira  # Tracker the keys are fetched from (default: jira, then linear)
+#   patterns:
+#     - '\b([A-Z][A-Z0-9]+-\d+)\b'  # First capture group is the key
+#   projects: [HIP, OPS]  # Only keep keys of these projects
+
+# Issue fetching (all trackers)
+# issue_fetch:
+#   concurrency: 4            # Issues fetched at the same time
+#   requests_per_second: 5    # Per tracker host
+#   cache_ttl: 5m             # On-disk cache of tracker responses
+#   no_cache: false
+
+# Issue context sent to the AI
+# issue_context:
+#   max_comments: 20          # Newest comments kept per issue, older ones are summarised (-1 keeps all)
+#   keep_bots: false          # Keep comments by bots and automation accounts
+#   bot_authors: [Deploy Notifier]
+#   budget_share: 0.25        # Share of the model's context window used for issues
+#   model_context_tokens: 0   # Override the model's context window
+
+# Reviewer suggestions from CODEOWNERS and the git history of the changed files
+# reviewers:
+#   disabled: false
+#   max: 5               # Suggested reviewers
+#   half_life: 2160h     # Age at which a commit counts half (90 days)
+#   exclude: [release-bot@example.com]
+#   request: false       # Request the reviewers on GitHub/GitLab in pullpoet serve
+
+# Rules for the generated title and body; violations are sent back to the AI to be fixed
+# policy:
+#   title_max_length: 72
+#   title_prefix: conventional  # Conventional Commits, or a regular expression like '\[[A-Z]+-\d+\] '
+#   require_issue_key: true     # The title must contain the key of a referenced issue
+#   forbidden_words: [WIP, TODO]
+#   required_sections: [Summary, Testing]  # Markdown headings the body must contain
+#   body_max_length: 4000
+
+# On-disk cache of AI responses, identical requests are answered from it (see pullpoet cache)
+# cache:
+#   disabled: false
+#   ttl: 168h          # 7 days
+#   max_size_mb: 50    # The oldest responses are removed above this size
+
+# GitHub/GitLab Integration (issues and the webhook server)
+# github:
+#   token: ${PULLPOET_GITHUB_TOKEN}
+#   api_url: https://github.example.com/api/v3  # GitHub Enterprise only
+# gitlab:
+#   token: ${PULLPOET_GITLAB_TOKEN}
+#   api_url: https://gitlab.example.com/api/v4  # Self-hosted GitLab only
 
 # UI Configuration
 ui:
@@ -257,6 +502,14 @@ ui:
   verbose: false  # Show detailed logs
   theme: auto  # auto, light, dark
 
+# Secret Redaction (built-in detectors are always on unless disabled)
+# redact:
+#   disabled: false  # Send diffs verbatim (not recommended)
+#   fail_on_secrets: false  # Abort instead of redacting, useful in CI
+#   patterns:  # Custom regular expressions, the first capture group is redacted if present
+#     - name: internal-host
+#       pattern: '[a-z0-9-]+\.corp\.example\.com'
+
 # Advanced: Custom fields (preserved but not used by core)
 # custom_field: value
 `
@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/cache"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+// CacheEntry is a response stored in the response cache
+type CacheEntry struct {
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Response string    `json:"response"`
+	Usage    Usage     `json:"usage"`
+	Created  time.Time `json:"created"`
+}
+
+// CachedClient serves repeated requests from an on-disk cache of responses.
+// Responses served from the cache report no token usage.
+type CachedClient struct {
+	client  Client
+	store   *cache.Store
+	refresh bool
+	log     logger.Logger
+	usage   Usage
+}
+
+// NewCachedClient wraps client with the response cache in store. With refresh
+// set, cached responses are ignored and replaced by new ones.
+func NewCachedClient(client Client, store *cache.Store, refresh bool) *CachedClient {
+	return &CachedClient{client: client, store: store, refresh: refresh, log: logger.Stdout}
+}
+
+// SetLogger sets where progress messages are written
+func (c *CachedClient) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// SetRefresh makes later requests ignore and replace cached responses
+func (c *CachedClient) SetRefresh(refresh bool) {
+	c.refresh = refresh
+}
+
+// Complete returns the cached response of an identical request or asks the provider
+func (c *CachedClient) Complete(request Request) (string, error) {
+	provider, model := c.client.GetProviderInfo()
+	key := CacheKey(provider, model, request)
+
+	if !c.refresh {
+		if data, ok := c.store.Get(key); ok {
+			var entry CacheEntry
+			if err := json.Unmarshal(data, &entry); err == nil {
+				c.log.Printf("   💾 Using cached AI response from %s\n", entry.Created.Format("2006-01-02 15:04"))
+				c.usage = Usage{}
+				return entry.Response, nil
+			}
+		}
+	}
+
+	response, err := c.client.Complete(request)
+	if err != nil {
+		return "", err
+	}
+	c.usage = LastUsage(c.client)
+
+	data, err := json.Marshal(CacheEntry{Provider: provider, Model: model, Response: response, Usage: c.usage, Created: time.Now()})
+	if err == nil {
+		err = c.store.Put(key, data)
+	}
+	if err == nil {
+		_, err = c.store.Prune()
+	}
+	if err != nil {
+		c.log.Printf("   ⚠️  Warning: Failed to cache AI response: %v\n", err)
+	}
+	return response, nil
+}
+
+// GetProviderInfo returns the provider and model of the wrapped client
+func (c *CachedClient) GetProviderInfo() (string, string) {
+	return c.client.GetProviderInfo()
+}
+
+// LastUsage returns the token usage of the last request, zero if it was cached
+func (c *CachedClient) LastUsage() Usage {
+	return c.usage
+}
+
+// CacheKey returns the cache key of a request: the provider, model and the
+// request with normalised line endings and surrounding whitespace
+func CacheKey(provider, model string, request Request) string {
+	parts := []string{provider, model, normalize(request.System)}
+	for _, block := range request.User {
+		parts = append(parts, normalize(block))
+	}
+	if request.Schema != nil {
+		schema, _ := json.Marshal(request.Schema)
+		parts = append(parts, string(schema))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// normalize replaces CRLF line endings and trims surrounding whitespace
+func normalize(text string) string {
+	return strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+}
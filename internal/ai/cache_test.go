@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/cache"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+// countingClient answers with the number of requests it received
+type countingClient struct {
+	model    string
+	requests int
+}
+
+func (c *countingClient) Complete(request Request) (string, error) {
+	c.requests++
+	return string(rune('0' + c.requests)), nil
+}
+
+func (c *countingClient) GetProviderInfo() (string, string) {
+	return "fake", c.model
+}
+
+func (c *countingClient) LastUsage() Usage {
+	return Usage{TotalTokens: 10}
+}
+
+func TestCachedClient(t *testing.T) {
+	store := cache.New(t.TempDir(), time.Hour)
+	client := &countingClient{model: "a"}
+	cached := NewCachedClient(client, store, false)
+	cached.SetLogger(logger.Discard)
+	request := Request{System: "Describe", User: []string{"diff\r\n+line\n"}}
+
+	complete := func(request Request) string {
+		t.Helper()
+		response, err := cached.Complete(request)
+		if err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		return response
+	}
+
+	if got := complete(request); got != "1" || cached.LastUsage().TotalTokens != 10 {
+		t.Errorf("first Complete() = %q, usage %+v", got, cached.LastUsage())
+	}
+	// Line endings and surrounding whitespace do not change the key
+	if got := complete(Request{System: "Describe ", User: []string{"diff\n+line"}}); got != "1" || cached.LastUsage().TotalTokens != 0 {
+		t.Errorf("cached Complete() = %q, usage %+v, want the cached response without usage", got, cached.LastUsage())
+	}
+	if got := complete(Request{System: "Describe", User: []string{"diff\n+other"}}); got != "2" {
+		t.Errorf("Complete() of another request = %q, want a new response", got)
+	}
+
+	cached.SetRefresh(true)
+	if got := complete(request); got != "3" {
+		t.Errorf("refreshed Complete() = %q, want a new response", got)
+	}
+	cached.SetRefresh(false)
+	if got := complete(request); got != "3" {
+		t.Errorf("Complete() after refresh = %q, want the replaced response", got)
+	}
+
+	// Another model never shares responses
+	other := NewCachedClient(&countingClient{model: "b"}, store, false)
+	other.SetLogger(logger.Discard)
+	if got, _ := other.Complete(request); got != "1" {
+		t.Errorf("Complete() with another model = %q, want a new response", got)
+	}
+}
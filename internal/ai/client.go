@@ -1,13 +1,98 @@
 package ai
 
-// Client defines the interface for AI providers
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+// Client defines the interface for AI providers. Providers map the request to
+// their native system message, content and structured output settings, so the
+// model receives the same prompt regardless of the provider.
 type Client interface {
-	GenerateDescription(prompt string) (string, error)
+	Complete(request Request) (string, error)
 	GetProviderInfo() (provider, model string)
 }
 
+// Request is a structured request to an AI provider
+type Request struct {
+	// System holds the instructions sent as the system message
+	System string
+	// User holds the content blocks of the user message, in order
+	User []string
+	// Schema is the JSON schema of the expected output, nil for free text
+	Schema *Schema
+}
+
+// Text returns the whole request as a single text
+func (r Request) Text() string {
+	return strings.Join(append([]string{r.System}, r.User...), "\n\n")
+}
+
+// UserText returns the user content blocks joined into a single message
+func (r Request) UserText() string {
+	return strings.Join(r.User, "\n\n")
+}
+
+// Usage holds the token counts reported by a provider for a single request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Add returns the sum of two usages, e.g. of a request and its repairs
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:      u.TotalTokens + other.TotalTokens,
+	}
+}
+
+// UsageReporter is implemented by clients that can report token usage
+type UsageReporter interface {
+	LastUsage() Usage
+}
+
+// LastUsage returns the token usage of the client's last request, if it reports any
+func LastUsage(client Client) Usage {
+	if reporter, ok := client.(UsageReporter); ok {
+		return reporter.LastUsage()
+	}
+	return Usage{}
+}
+
 // Response represents the AI response structure
 type Response struct {
 	Title string
 	Body  string
 }
+
+// New creates the client for the given provider and routes its progress messages to log
+func New(provider, baseURL, apiKey, model string, log logger.Logger) (Client, error) {
+	switch provider {
+	case "openai":
+		client := NewOpenAIClient(apiKey, model)
+		client.SetLogger(log)
+		return client, nil
+	case "ollama":
+		client := NewOllamaClient(baseURL, model)
+		client.SetLogger(log)
+		return client, nil
+	case "gemini":
+		client, err := NewGeminiClient(apiKey, model)
+		if err != nil {
+			return nil, err
+		}
+		client.SetLogger(log)
+		return client, nil
+	case "openwebui":
+		client := NewOpenWebUIClient(baseURL, apiKey, model)
+		client.SetLogger(log)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", provider)
+	}
+}
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+func TestProvidersSendSystemAndUserMessages(t *testing.T) {
+	request := Request{
+		System: "You write PR descriptions.",
+		User:   []string{"## Diff", "+package main"},
+		Schema: &Schema{Type: "object", Properties: map[string]*Schema{"title": {Type: "string"}}, Required: []string{"title"}},
+	}
+	wantMessages := []map[string]interface{}{
+		{"role": "system", "content": "You write PR descriptions."},
+		{"role": "user", "content": "## Diff\n\n+package main"},
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		response string
+		client   func(url string) Client
+	}{
+		{
+			name:     "ollama",
+			path:     "/api/chat",
+			response: `{"message": {"content": "{\"title\": \"Add main\"}"}, "done": true}`,
+			client: func(url string) Client {
+				client := NewOllamaClient(url, "llama3")
+				client.SetLogger(logger.Discard)
+				return client
+			},
+		},
+		{
+			name:     "openwebui",
+			path:     "/api/chat/completions",
+			response: `{"choices": [{"message": {"role": "assistant", "content": "{\"title\": \"Add main\"}"}}]}`,
+			client: func(url string) Client {
+				client := NewOpenWebUIClient(url, "", "llama3")
+				client.SetLogger(logger.Discard)
+				return client
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Messages []map[string]interface{} `json:"messages"`
+				Format   map[string]interface{}   `json:"format"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != tt.path {
+					t.Errorf("path = %s, want %s", r.URL.Path, tt.path)
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			content, err := tt.client(server.URL).Complete(request)
+			if err != nil {
+				t.Fatalf("Complete() error = %v", err)
+			}
+			if content != `{"title": "Add main"}` {
+				t.Errorf("Complete() = %q, want the raw content", content)
+			}
+			if !reflect.DeepEqual(body.Messages, wantMessages) {
+				t.Errorf("messages = %v, want %v", body.Messages, wantMessages)
+			}
+			if tt.name == "ollama" && body.Format["type"] != "object" {
+				t.Errorf("format = %v, want the schema", body.Format)
+			}
+		})
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := map[string]int{
+		"gpt-4o-mini":              128000,
+		"gpt-4":                    8192,
+		"gemini-2.0-flash":         1048576,
+		"library/llama3.1:8b":      131072,
+		"some-unknown-local-model": DefaultContextWindow,
+	}
+	for model, want := range tests {
+		if got := ContextWindow(model); got != want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", model, got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package ai
+
+import "strings"
+
+// DefaultContextWindow is the context window assumed for unknown models
+const DefaultContextWindow = 8192
+
+// contextWindows are the context windows of known models by name prefix, most specific first
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"gpt-5", 400000},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"gemini-1.5", 1048576},
+	{"gemini-2", 1048576},
+	{"gemini", 32768},
+	{"claude", 200000},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3", 8192},
+	{"mistral", 32768},
+	{"qwen2.5", 32768},
+}
+
+// ContextWindow returns the context window of a model in tokens, DefaultContextWindow if it is unknown
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	// Ollama and OpenWebUI models may carry a namespace, e.g. "library/llama3:8b"
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	for _, window := range contextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			return window.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+// EstimateTokens estimates the number of tokens in text, about four characters per token
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
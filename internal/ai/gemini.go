@@ -2,8 +2,10 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strings"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 
 	"google.golang.org/genai"
 )
@@ -13,6 +15,8 @@ type GeminiClient struct {
 	apiKey string
 	model  string
 	client *genai.Client
+	log    logger.Logger
+	usage  Usage
 }
 
 // NewGeminiClient creates a new Gemini client
@@ -30,80 +34,94 @@ func NewGeminiClient(apiKey, model string) (*GeminiClient, error) {
 		apiKey: apiKey,
 		model:  model,
 		client: client,
+		log:    logger.Stdout,
 	}, nil
 }
 
-// GenerateDescription sends a prompt to Gemini and returns the response
-func (c *GeminiClient) GenerateDescription(prompt string) (string, error) {
-	fmt.Printf("   🌐 Sending request to Gemini API (model: %s)...\n", c.model)
+// SetLogger sets where progress messages are written
+func (c *GeminiClient) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// LastUsage returns the token usage reported for the most recent request
+func (c *GeminiClient) LastUsage() Usage {
+	return c.usage
+}
+
+// Complete sends a request to Gemini and returns the response content
+func (c *GeminiClient) Complete(request Request) (string, error) {
+	c.log.Printf("   🌐 Sending request to Gemini API (model: %s)...\n", c.model)
 
 	ctx := context.Background()
 
-	// Configure the model for structured JSON output
 	config := &genai.GenerateContentConfig{
-		ResponseMIMEType: "application/json",
-		ResponseSchema: &genai.Schema{
-			Type: genai.TypeObject,
-			Properties: map[string]*genai.Schema{
-				"title": {
-					Type:        genai.TypeString,
-					Description: "A concise one-line summary of the pull request changes",
-				},
-				"body": {
-					Type:        genai.TypeString,
-					Description: "A detailed markdown description explaining the changes made in the pull request",
-				},
-			},
-			PropertyOrdering: []string{"title", "body"},
-			Required:         []string{"title", "body"},
-		},
+		SystemInstruction: genai.NewContentFromText(request.System, genai.RoleUser),
+	}
+	if request.Schema != nil {
+		config.ResponseMIMEType = "application/json"
+		config.ResponseSchema = geminiSchema(request.Schema)
 	}
 
-	// Create a focused prompt for pull request analysis
-	analysisPrompt := fmt.Sprintf(`Analyze the following git diff and generate a pull request title and description:
-
-%s
-
-Please provide:
-- title: A clear, concise summary of what this pull request does
-- body: A detailed explanation in markdown format covering what was changed, why it was changed, and any relevant implementation details`, prompt)
+	parts := make([]*genai.Part, 0, len(request.User))
+	for _, block := range request.User {
+		parts = append(parts, genai.NewPartFromText(block))
+	}
 
 	// Generate content using the Models API
 	result, err := c.client.Models.GenerateContent(
 		ctx,
 		c.model,
-		genai.Text(analysisPrompt),
+		[]*genai.Content{genai.NewContentFromParts(parts, genai.RoleUser)},
 		config,
 	)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate content: %w", err)
 	}
 
-	fmt.Println("   ✅ Gemini API responded successfully")
+	c.log.Printf("   ✅ Gemini API responded successfully\n")
+
+	c.usage = Usage{}
+	if result.UsageMetadata != nil {
+		c.usage = Usage{
+			PromptTokens:     int(result.UsageMetadata.PromptTokenCount),
+			CompletionTokens: int(result.UsageMetadata.CandidatesTokenCount),
+			TotalTokens:      int(result.UsageMetadata.TotalTokenCount),
+		}
+	}
 
-	// Get the response text (should be structured JSON)
 	content := result.Text()
 	if content == "" {
 		return "", fmt.Errorf("no response generated")
 	}
+	return content, nil
+}
 
-	// Parse the JSON response (guaranteed by structured output)
-	var response Response
-	if err := json.Unmarshal([]byte(content), &response); err != nil {
-		return "", fmt.Errorf("failed to parse structured JSON response: %w", err)
+// geminiSchema converts a JSON schema to a Gemini response schema
+func geminiSchema(schema *Schema) *genai.Schema {
+	if schema == nil {
+		return nil
 	}
-
-	// Validate required fields
-	if response.Title == "" {
-		return "", fmt.Errorf("title field is empty in response")
+	converted := &genai.Schema{
+		Type:        genai.Type(strings.ToUpper(schema.Type)),
+		Description: schema.Description,
+		Required:    schema.Required,
+		Enum:        schema.Enum,
+		Items:       geminiSchema(schema.Items),
 	}
-	if response.Body == "" {
-		return "", fmt.Errorf("body field is empty in response")
+	if schema.Type == "object" {
+		converted.PropertyOrdering = propertyNames(schema)
 	}
-
-	// Format the response
-	result_formatted := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-	return result_formatted, nil
+	if schema.MinLength > 0 {
+		minLength := int64(schema.MinLength)
+		converted.MinLength = &minLength
+	}
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			converted.Properties[name] = geminiSchema(property)
+		}
+	}
+	return converted
 }
 
 // GetProviderInfo returns the provider name and model
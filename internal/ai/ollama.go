@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 )
 
 // OllamaClient implements the Client interface for Ollama
@@ -17,6 +19,8 @@ type OllamaClient struct {
 	Auth    string
 	Model   string
 	Client  *http.Client
+	log     logger.Logger
+	usage   Usage
 }
 
 // NewOllamaClient creates a new Ollama client
@@ -24,6 +28,7 @@ func NewOllamaClient(ollamaURL, model string) *OllamaClient {
 	client := &OllamaClient{
 		Model:  model,
 		Client: &http.Client{},
+		log:    logger.Stdout,
 	}
 
 	// Parse URL to extract credentials
@@ -50,12 +55,22 @@ func NewOllamaClient(ollamaURL, model string) *OllamaClient {
 	return client
 }
 
+// SetLogger sets where progress messages are written
+func (c *OllamaClient) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// LastUsage returns the token usage reported for the most recent request
+func (c *OllamaClient) LastUsage() Usage {
+	return c.usage
+}
+
 // Ollama API request structure
 type ollamaRequest struct {
-	Model    string                 `json:"model"`
-	Messages []ollamaMessage        `json:"messages"`
-	Stream   bool                   `json:"stream"`
-	Format   map[string]interface{} `json:"format,omitempty"`
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   *Schema         `json:"format,omitempty"`
 }
 
 type ollamaMessage struct {
@@ -65,45 +80,33 @@ type ollamaMessage struct {
 
 // Ollama API response structure
 type ollamaResponse struct {
-	Message ollamaResponseMessage `json:"message"`
-	Done    bool                  `json:"done"`
+	Message         ollamaResponseMessage `json:"message"`
+	Done            bool                  `json:"done"`
+	PromptEvalCount int                   `json:"prompt_eval_count"`
+	EvalCount       int                   `json:"eval_count"`
 }
 
 type ollamaResponseMessage struct {
 	Content string `json:"content"`
 }
 
-// GenerateDescription sends a prompt to Ollama and returns the response
-func (c *OllamaClient) GenerateDescription(prompt string) (string, error) {
-	fmt.Printf("   🌐 Sending request to Ollama API (model: %s) with structured outputs...\n", c.Model)
-	apiURL := strings.TrimSuffix(c.BaseURL, "/") + "/api/chat"
-
-	// Define JSON schema for structured output
-	schema := map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"title": map[string]interface{}{
-				"type":        "string",
-				"description": "A concise PR title with appropriate emoji (max 80 characters)",
-			},
-			"body": map[string]interface{}{
-				"type":        "string",
-				"description": "A detailed markdown PR description following professional format",
-			},
-		},
-		"required": []string{"title", "body"},
+// Complete sends a request to Ollama and returns the response content
+func (c *OllamaClient) Complete(request Request) (string, error) {
+	if request.Schema != nil {
+		c.log.Printf("   🌐 Sending request to Ollama API (model: %s) with structured outputs...\n", c.Model)
+	} else {
+		c.log.Printf("   🌐 Sending request to Ollama API (model: %s)...\n", c.Model)
 	}
+	apiURL := strings.TrimSuffix(c.BaseURL, "/") + "/api/chat"
 
 	reqBody := ollamaRequest{
 		Model: c.Model,
 		Messages: []ollamaMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
+			{Role: "system", Content: request.System},
+			{Role: "user", Content: request.UserText()},
 		},
 		Stream: false,
-		Format: schema,
+		Format: request.Schema,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -131,7 +134,7 @@ func (c *OllamaClient) GenerateDescription(prompt string) (string, error) {
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("Ollama API error: %s - %s", resp.Status, string(body))
 	}
-	fmt.Println("   ✅ Ollama API responded successfully")
+	c.log.Printf("   ✅ Ollama API responded successfully\n")
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -143,7 +146,12 @@ func (c *OllamaClient) GenerateDescription(prompt string) (string, error) {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	fmt.Println("   ✅ Received structured JSON response from Ollama")
+	c.usage = Usage{
+		PromptTokens:     ollamaResp.PromptEvalCount,
+		CompletionTokens: ollamaResp.EvalCount,
+		TotalTokens:      ollamaResp.PromptEvalCount + ollamaResp.EvalCount,
+	}
+
 	return ollamaResp.Message.Content, nil
 }
 
@@ -7,28 +7,125 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 )
 
 // OpenAIClient implements the Client interface for OpenAI
 type OpenAIClient struct {
-	apiKey string
-	model  string
-	client *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+	log     logger.Logger
+	usage   Usage
+	// jsonMode is set once the model rejected json_schema structured outputs
+	jsonMode bool
 }
 
 // NewOpenAIClient creates a new OpenAI client
 func NewOpenAIClient(apiKey, model string) *OpenAIClient {
 	return &OpenAIClient{
-		apiKey: apiKey,
-		model:  model,
-		client: &http.Client{},
+		baseURL: "https://api.openai.com/v1",
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{},
+		log:     logger.Stdout,
 	}
 }
 
+// SetLogger sets where progress messages are written
+func (c *OpenAIClient) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// LastUsage returns the token usage reported for the most recent request
+func (c *OpenAIClient) LastUsage() Usage {
+	return c.usage
+}
+
 // OpenAI API request structure
 type openAIRequest struct {
-	Model    string    `json:"model"`
-	Messages []message `json:"messages"`
+	Model          string                `json:"model"`
+	Messages       []openAIMessage       `json:"messages"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// openAIMessage is a chat message; Content is a string or a list of content parts
+type openAIMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// openAIContentPart is a text block of a user message
+type openAIContentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// openAIResponseFormat requests JSON output, matching a schema if JSONSchema is set
+type openAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *openAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+// openAIJSONSchema is a named schema for structured outputs
+type openAIJSONSchema struct {
+	Name   string                 `json:"name"`
+	Schema map[string]interface{} `json:"schema"`
+	Strict bool                   `json:"strict"`
+}
+
+// responseFormat returns the structured output format for a schema. Without
+// structured outputs JSON mode is used, the schema is then validated afterwards.
+func responseFormat(schema *Schema, structured bool) *openAIResponseFormat {
+	if schema == nil {
+		return nil
+	}
+	if !structured {
+		return &openAIResponseFormat{Type: "json_object"}
+	}
+	name := schema.Name
+	if name == "" {
+		name = "response"
+	}
+	return &openAIResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &openAIJSONSchema{Name: name, Schema: strictSchema(schema), Strict: true},
+	}
+}
+
+// jsonSchemaModels are the prefixes of OpenAI models with json_schema structured outputs
+var jsonSchemaModels = []string{"gpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4"}
+
+// noJSONSchemaModels are the models matching jsonSchemaModels that predate structured outputs
+var noJSONSchemaModels = []string{"gpt-4o-2024-05-13", "o1-preview", "o1-mini"}
+
+// supportsJSONSchema reports whether an OpenAI model supports json_schema
+// structured outputs. Fine-tuned models ("ft:gpt-4o-mini-2024-07-18:org::id")
+// are judged by their base model.
+func supportsJSONSchema(model string) bool {
+	model = strings.TrimPrefix(strings.ToLower(model), "ft:")
+	for _, prefix := range noJSONSchemaModels {
+		if strings.HasPrefix(model, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range jsonSchemaModels {
+		if model == prefix || strings.HasPrefix(model, prefix+"-") || strings.HasPrefix(model, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// rejectsJSONSchema reports whether an error response rejects the json_schema response format
+func rejectsJSONSchema(format *openAIResponseFormat, status int, body []byte) bool {
+	if format == nil || format.Type != "json_schema" || status != http.StatusBadRequest {
+		return false
+	}
+	text := strings.ToLower(string(body))
+	return strings.Contains(text, "response_format") || strings.Contains(text, "json_schema")
 }
 
 type message struct {
@@ -39,120 +136,87 @@ type message struct {
 // OpenAI API response structure
 type openAIResponse struct {
 	Choices []choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
 }
 
 type choice struct {
 	Message message `json:"message"`
 }
 
-// GenerateDescription sends a prompt to OpenAI and returns the response
-func (c *OpenAIClient) GenerateDescription(prompt string) (string, error) {
-	fmt.Printf("   🌐 Sending request to OpenAI API (model: %s)...\n", c.model)
-	url := "https://api.openai.com/v1/chat/completions"
+// Complete sends a request to OpenAI and returns the response content. If the
+// model rejects json_schema structured outputs, the request is retried in JSON mode.
+func (c *OpenAIClient) Complete(request Request) (string, error) {
+	c.log.Printf("   🌐 Sending request to OpenAI API (model: %s)...\n", c.model)
 
+	parts := make([]openAIContentPart, 0, len(request.User))
+	for _, block := range request.User {
+		parts = append(parts, openAIContentPart{Type: "text", Text: block})
+	}
 	reqBody := openAIRequest{
 		Model: c.model,
-		Messages: []message{
-			{
-				Role:    "system",
-				Content: "You are a helpful assistant that generates pull request titles and descriptions. Respond with a JSON object containing 'title' and 'body' fields. The title should be a concise one-line summary, and the body should be a detailed markdown description explaining the changes.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
+		Messages: []openAIMessage{
+			{Role: "system", Content: request.System},
+			{Role: "user", Content: parts},
 		},
 	}
+	reqBody.ResponseFormat = responseFormat(request.Schema, !c.jsonMode && supportsJSONSchema(c.model))
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	status, body, err := c.post(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("OpenAI API error: %s - %s", resp.Status, string(body))
+	if rejectsJSONSchema(reqBody.ResponseFormat, status, body) {
+		c.log.Printf("   ⚠️  %s does not support json_schema, retrying in JSON mode\n", c.model)
+		c.jsonMode = true
+		reqBody.ResponseFormat = responseFormat(request.Schema, false)
+		if status, body, err = c.post(reqBody); err != nil {
+			return "", err
+		}
 	}
-	fmt.Println("   ✅ OpenAI API responded successfully")
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	if status != http.StatusOK {
+		return "", fmt.Errorf("OpenAI API error: %d %s - %s", status, http.StatusText(status), string(body))
 	}
+	c.log.Printf("   ✅ OpenAI API responded successfully\n")
 
 	var openAIResp openAIResponse
 	if err := json.Unmarshal(body, &openAIResp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	c.usage = openAIResp.Usage
+
 	if len(openAIResp.Choices) == 0 {
 		return "", fmt.Errorf("no choices in OpenAI response")
 	}
+	return openAIResp.Choices[0].Message.Content, nil
+}
 
-	content := openAIResp.Choices[0].Message.Content
-
-	// Try to parse as JSON first
-	var response Response
-
-	// First attempt: try to parse the entire content as JSON
-	if err := json.Unmarshal([]byte(content), &response); err == nil {
-		// Format the response
-		result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-		return result, nil
+// post sends a chat completion request and returns the status code and body of the response
+func (c *OpenAIClient) post(reqBody openAIRequest) (int, []byte, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Second attempt: Look for JSON object within the content
-	jsonStart := strings.Index(content, "{")
-	jsonEnd := strings.LastIndex(content, "}")
-
-	if jsonStart >= 0 && jsonEnd > jsonStart {
-		jsonStr := content[jsonStart : jsonEnd+1]
-		if err := json.Unmarshal([]byte(jsonStr), &response); err == nil {
-			// Format the response
-			result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-			return result, nil
-		}
+	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Third attempt: Look for JSON block with ```json markers
-	if jsonStart := strings.Index(content, "```json"); jsonStart >= 0 {
-		jsonStart += len("```json")
-		if jsonEnd := strings.Index(content[jsonStart:], "```"); jsonEnd >= 0 {
-			jsonStr := strings.TrimSpace(content[jsonStart : jsonStart+jsonEnd])
-			if err := json.Unmarshal([]byte(jsonStr), &response); err == nil {
-				// Format the response
-				result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-				return result, nil
-			}
-		}
-	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
-	// Fallback: treat as plain text and extract title/body
-	lines := strings.Split(strings.TrimSpace(content), "\n")
-	if len(lines) > 0 {
-		response.Title = strings.TrimSpace(lines[0])
-		if len(lines) > 1 {
-			response.Body = strings.TrimSpace(strings.Join(lines[1:], "\n"))
-		}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Format the response
-	result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-	return result, nil
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
 }
 
 // GetProviderInfo returns the provider name and model
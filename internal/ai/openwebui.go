@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 )
 
 // OpenWebUIClient implements the Client interface for OpenWebUI
@@ -15,6 +17,10 @@ type OpenWebUIClient struct {
 	apiKey  string
 	model   string
 	client  *http.Client
+	log     logger.Logger
+	usage   Usage
+	// jsonMode is set once the backend rejected json_schema structured outputs
+	jsonMode bool
 }
 
 // NewOpenWebUIClient creates a new OpenWebUI client
@@ -27,130 +33,107 @@ func NewOpenWebUIClient(baseURL, apiKey, model string) *OpenWebUIClient {
 		apiKey:  apiKey,
 		model:   model,
 		client:  &http.Client{},
+		log:     logger.Stdout,
 	}
 }
 
+// SetLogger sets where progress messages are written
+func (c *OpenWebUIClient) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// LastUsage returns the token usage reported for the most recent request
+func (c *OpenWebUIClient) LastUsage() Usage {
+	return c.usage
+}
+
 // OpenWebUI API request structure (similar to OpenAI but with OpenWebUI endpoint)
 type openWebUIRequest struct {
-	Model    string    `json:"model"`
-	Messages []message `json:"messages"`
+	Model          string                `json:"model"`
+	Messages       []message             `json:"messages"`
+	ResponseFormat *openAIResponseFormat `json:"response_format,omitempty"`
 }
 
 // OpenWebUI API response structure (same as OpenAI)
 type openWebUIResponse struct {
 	Choices []choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
 }
 
-// GenerateDescription sends a prompt to OpenWebUI and returns the response
-func (c *OpenWebUIClient) GenerateDescription(prompt string) (string, error) {
-	fmt.Printf("   🌐 Sending request to OpenWebUI API (model: %s)...\n", c.model)
-	url := c.baseURL + "/api/chat/completions"
+// Complete sends a request to OpenWebUI and returns the response content. If the
+// backend rejects json_schema structured outputs, the request is retried in JSON mode.
+func (c *OpenWebUIClient) Complete(request Request) (string, error) {
+	c.log.Printf("   🌐 Sending request to OpenWebUI API (model: %s)...\n", c.model)
 
+	// Not every OpenWebUI backend accepts content parts, the user blocks are sent as one message
 	reqBody := openWebUIRequest{
 		Model: c.model,
 		Messages: []message{
-			{
-				Role:    "system",
-				Content: "You are a helpful assistant that generates pull request titles and descriptions. Respond with a JSON object containing 'title' and 'body' fields. The title should be a concise one-line summary, and the body should be a detailed markdown description explaining the changes.",
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
+			{Role: "system", Content: request.System},
+			{Role: "user", Content: request.UserText()},
 		},
 	}
+	// OpenWebUI forwards OpenAI structured outputs to the backend, including Ollama
+	reqBody.ResponseFormat = responseFormat(request.Schema, !c.jsonMode)
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	}
-
-	resp, err := c.client.Do(req)
+	status, body, err := c.post(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("OpenWebUI API error: %s - %s", resp.Status, string(body))
+	if rejectsJSONSchema(reqBody.ResponseFormat, status, body) {
+		c.log.Printf("   ⚠️  %s does not support json_schema, retrying in JSON mode\n", c.model)
+		c.jsonMode = true
+		reqBody.ResponseFormat = responseFormat(request.Schema, false)
+		if status, body, err = c.post(reqBody); err != nil {
+			return "", err
+		}
 	}
-	fmt.Println("   ✅ OpenWebUI API responded successfully")
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
+	if status != http.StatusOK {
+		return "", fmt.Errorf("OpenWebUI API error: %d %s - %s", status, http.StatusText(status), string(body))
 	}
+	c.log.Printf("   ✅ OpenWebUI API responded successfully\n")
 
 	var openWebUIResp openWebUIResponse
 	if err := json.Unmarshal(body, &openWebUIResp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	c.usage = openWebUIResp.Usage
+
 	if len(openWebUIResp.Choices) == 0 {
 		return "", fmt.Errorf("no choices in OpenWebUI response")
 	}
+	return openWebUIResp.Choices[0].Message.Content, nil
+}
 
-	content := openWebUIResp.Choices[0].Message.Content
-
-	// Try to parse as JSON first
-	var response Response
-
-	// First attempt: try to parse the entire content as JSON
-	if err := json.Unmarshal([]byte(content), &response); err == nil {
-		// Format the response
-		result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-		return result, nil
+// post sends a chat completion request and returns the status code and body of the response
+func (c *OpenWebUIClient) post(reqBody openWebUIRequest) (int, []byte, error) {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Second attempt: Look for JSON object within the content
-	jsonStart := strings.Index(content, "{")
-	jsonEnd := strings.LastIndex(content, "}")
-
-	if jsonStart >= 0 && jsonEnd > jsonStart {
-		jsonStr := content[jsonStart : jsonEnd+1]
-		if err := json.Unmarshal([]byte(jsonStr), &response); err == nil {
-			// Format the response
-			result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-			return result, nil
-		}
+	req, err := http.NewRequest("POST", c.baseURL+"/api/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Third attempt: Look for JSON block with ```json markers
-	if jsonStart := strings.Index(content, "```json"); jsonStart >= 0 {
-		jsonStart += len("```json")
-		if jsonEnd := strings.Index(content[jsonStart:], "```"); jsonEnd >= 0 {
-			jsonStr := strings.TrimSpace(content[jsonStart : jsonStart+jsonEnd])
-			if err := json.Unmarshal([]byte(jsonStr), &response); err == nil {
-				// Format the response
-				result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-				return result, nil
-			}
-		}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
 
-	// Fallback: treat as plain text and extract title/body
-	lines := strings.Split(strings.TrimSpace(content), "\n")
-	if len(lines) > 0 {
-		response.Title = strings.TrimSpace(lines[0])
-		if len(lines) > 1 {
-			response.Body = strings.TrimSpace(strings.Join(lines[1:], "\n"))
-		}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Format the response
-	result := fmt.Sprintf("TITLE: %s\n\nBODY:\n%s", response.Title, response.Body)
-	return result, nil
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	return resp.StatusCode, body, nil
 }
 
 // GetProviderInfo returns the provider name and model
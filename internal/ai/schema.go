@@ -0,0 +1,218 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON schema supported by all providers' structured outputs
+type Schema struct {
+	Type        string             `json:"type"`
+	Description string             `json:"description,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	MinLength   int                `json:"minLength,omitempty"`
+	// Name identifies the schema for providers that require one
+	Name string `json:"-"`
+	// Order is the order of the properties, used by providers that keep it
+	Order []string `json:"-"`
+}
+
+// ValidationError lists every way a response does not match its schema
+type ValidationError struct {
+	Problems []string
+}
+
+// Error returns the problems on one line
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that content is a JSON document matching schema. Code fences
+// and text around the JSON object are ignored. It returns the JSON document.
+func Validate(schema *Schema, content string) ([]byte, error) {
+	document := ExtractJSON(content)
+	if document == "" {
+		return nil, &ValidationError{Problems: []string{"the response does not contain a JSON object"}}
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("the response is not valid JSON: %v", err)}}
+	}
+
+	var problems []string
+	validateValue(schema, value, "", &problems)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return []byte(document), nil
+}
+
+// ExtractJSON returns the JSON object in content, which may be wrapped in a
+// ```json code fence or surrounded by text; empty if there is none
+func ExtractJSON(content string) string {
+	content = strings.TrimSpace(content)
+	if start := strings.Index(content, "```json"); start >= 0 {
+		rest := content[start+len("```json"):]
+		if end := strings.Index(rest, "```"); end >= 0 {
+			content = strings.TrimSpace(rest[:end])
+		}
+	}
+
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return content[start : end+1]
+}
+
+// validateValue appends the problems of value at path to problems
+func validateValue(schema *Schema, value interface{}, path string, problems *[]string) {
+	if schema == nil {
+		return
+	}
+	name := path
+	if name == "" {
+		name = "the response"
+	}
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, name+" "+fmt.Sprintf(format, args...))
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, required := range schema.Required {
+			if _, ok := object[required]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s is required", join(path, required)))
+			}
+		}
+		names := make([]string, 0, len(object))
+		for key := range object {
+			names = append(names, key)
+		}
+		sort.Strings(names)
+		for _, key := range names {
+			property, ok := schema.Properties[key]
+			// Optional properties may be null, as in OpenAI strict mode
+			if !ok || (object[key] == nil && !contains(schema.Required, key)) {
+				continue
+			}
+			validateValue(property, object[key], join(path, key), problems)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		for i, item := range items {
+			validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", name, i), problems)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if len(strings.TrimSpace(text)) < schema.MinLength {
+			if schema.MinLength == 1 {
+				fail("must not be empty")
+			} else {
+				fail("must be at least %d characters", schema.MinLength)
+			}
+		}
+		if len(schema.Enum) > 0 && !contains(schema.Enum, text) {
+			fail("must be one of %s, got %q", strings.Join(schema.Enum, ", "), text)
+		}
+	case "integer", "number":
+		number, ok := value.(float64)
+		if !ok {
+			fail("must be a number")
+		} else if schema.Type == "integer" && number != float64(int64(number)) {
+			fail("must be an integer")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+		}
+	}
+}
+
+// strictSchema returns the schema in the form of OpenAI strict structured outputs:
+// every object is closed and lists all of its properties as required, optional
+// properties become nullable instead
+func strictSchema(schema *Schema) map[string]interface{} {
+	converted := map[string]interface{}{"type": schema.Type}
+	if schema.Description != "" {
+		converted["description"] = schema.Description
+	}
+	if len(schema.Enum) > 0 {
+		converted["enum"] = schema.Enum
+	}
+	if schema.Items != nil {
+		converted["items"] = strictSchema(schema.Items)
+	}
+	if schema.Type == "object" {
+		properties := make(map[string]interface{}, len(schema.Properties))
+		names := propertyNames(schema)
+		for _, name := range names {
+			property := strictSchema(schema.Properties[name])
+			if !contains(schema.Required, name) {
+				property["type"] = []string{schema.Properties[name].Type, "null"}
+			}
+			properties[name] = property
+		}
+		converted["properties"] = properties
+		converted["required"] = names
+		converted["additionalProperties"] = false
+	}
+	return converted
+}
+
+// propertyNames returns the property names of an object schema in order;
+// names in Order without a property are skipped
+func propertyNames(schema *Schema) []string {
+	var names []string
+	for _, name := range schema.Order {
+		if _, ok := schema.Properties[name]; ok && !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	var rest []string
+	for name := range schema.Properties {
+		if !contains(names, name) {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// join returns the path of a property
+func join(path, property string) string {
+	if path == "" {
+		return property
+	}
+	return path + "." + property
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package ai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+func TestValidate(t *testing.T) {
+	schema := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"title":  {Type: "string", MinLength: 1},
+			"body":   {Type: "string"},
+			"risk":   {Type: "string", Enum: []string{"low", "high"}},
+			"labels": {Type: "array", Items: &Schema{Type: "string"}},
+		},
+		Required: []string{"title", "body"},
+	}
+
+	tests := []struct {
+		name         string
+		content      string
+		wantDocument string
+		wantProblems []string
+	}{
+		{
+			name:         "valid",
+			content:      `{"title": "Add config", "body": "Adds configuration", "labels": ["feature"]}`,
+			wantDocument: `{"title": "Add config", "body": "Adds configuration", "labels": ["feature"]}`,
+		},
+		{
+			name:         "code fence and surrounding text",
+			content:      "Here you go:\n```json\n{\"title\": \"Add config\", \"body\": \"\"}\n```\nThanks",
+			wantDocument: `{"title": "Add config", "body": ""}`,
+		},
+		{
+			name:         "optional property may be null",
+			content:      `{"title": "Add config", "body": "", "risk": null}`,
+			wantDocument: `{"title": "Add config", "body": "", "risk": null}`,
+		},
+		{
+			name:         "no JSON",
+			content:      "TITLE: Add config",
+			wantProblems: []string{"the response does not contain a JSON object"},
+		},
+		{
+			name:    "wrong values",
+			content: `{"title": "", "risk": "medium", "labels": ["feature", 3]}`,
+			wantProblems: []string{
+				"body is required",
+				"labels[1] must be a string",
+				`risk must be one of low, high, got "medium"`,
+				"title must not be empty",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := Validate(schema, tt.content)
+			if tt.wantProblems == nil {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				if string(document) != tt.wantDocument {
+					t.Errorf("Validate() = %s, want %s", document, tt.wantDocument)
+				}
+				return
+			}
+			validationErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want a ValidationError", err)
+			}
+			if !reflect.DeepEqual(validationErr.Problems, tt.wantProblems) {
+				t.Errorf("Problems = %q, want %q", validationErr.Problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestResponseFormat(t *testing.T) {
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"title": {Type: "string"}, "body": {Type: "string"}, "notes": {Type: "string"}},
+		Required:   []string{"title", "body"},
+		Name:       "pull_request",
+		Order:      []string{"title", "body"},
+	}
+
+	if format := responseFormat(schema, false); format.Type != "json_object" || format.JSONSchema != nil {
+		t.Errorf("responseFormat(unstructured) = %+v, want json_object", format)
+	}
+
+	format := responseFormat(schema, true)
+	if format.Type != "json_schema" || format.JSONSchema.Name != "pull_request" || !format.JSONSchema.Strict {
+		t.Fatalf("responseFormat(structured) = %+v, want a strict json_schema", format)
+	}
+	strict := format.JSONSchema.Schema
+	if !reflect.DeepEqual(strict["required"], []string{"title", "body", "notes"}) || strict["additionalProperties"] != false {
+		t.Errorf("strict schema = %v, want every property required and no additional properties", strict)
+	}
+	notes := strict["properties"].(map[string]interface{})["notes"].(map[string]interface{})
+	if !reflect.DeepEqual(notes["type"], []string{"string", "null"}) {
+		t.Errorf("optional property type = %v, want nullable", notes["type"])
+	}
+}
+
+func TestSupportsJSONSchema(t *testing.T) {
+	tests := []struct {
+		model string
+		want  bool
+	}{
+		{"gpt-4o", true},
+		{"gpt-4o-mini", true},
+		{"gpt-4o-2024-08-06", true},
+		{"gpt-4.1-nano", true},
+		{"o1", true},
+		{"o3-mini", true},
+		{"ft:gpt-4o-mini-2024-07-18:acme::abc123", true},
+		{"gpt-4o-2024-05-13", false},
+		{"chatgpt-4o-latest", false},
+		{"o1-preview", false},
+		{"o1-mini", false},
+		{"gpt-4", false},
+		{"gpt-4-turbo", false},
+		{"gpt-3.5-turbo", false},
+		{"ft:gpt-3.5-turbo-0125:acme::abc123", false},
+	}
+
+	for _, tt := range tests {
+		if got := supportsJSONSchema(tt.model); got != tt.want {
+			t.Errorf("supportsJSONSchema(%q) = %v, want %v", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestJSONSchemaFallback(t *testing.T) {
+	request := Request{System: "system", User: []string{"user"}, Schema: &Schema{Type: "object", Properties: map[string]*Schema{"title": {Type: "string"}}}}
+
+	tests := []struct {
+		name   string
+		client func(url string) Client
+	}{
+		{
+			name: "openai",
+			client: func(url string) Client {
+				client := NewOpenAIClient("key", "gpt-4o")
+				client.baseURL = url
+				client.SetLogger(logger.Discard)
+				return client
+			},
+		},
+		{
+			name: "openwebui",
+			client: func(url string) Client {
+				client := NewOpenWebUIClient(url, "", "llama3")
+				client.SetLogger(logger.Discard)
+				return client
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var formats []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					ResponseFormat struct {
+						Type string `json:"type"`
+					} `json:"response_format"`
+				}
+				json.NewDecoder(r.Body).Decode(&body)
+				formats = append(formats, body.ResponseFormat.Type)
+				if body.ResponseFormat.Type == "json_schema" {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(`{"error": {"message": "Invalid parameter: 'response_format' of type 'json_schema' is not supported with this model."}}`))
+					return
+				}
+				w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"title\": \"Add main\"}"}}]}`))
+			}))
+			defer server.Close()
+
+			client := tt.client(server.URL)
+			for i := 0; i < 2; i++ {
+				if _, err := client.Complete(request); err != nil {
+					t.Fatalf("Complete() error = %v", err)
+				}
+			}
+			// Later requests use JSON mode right away
+			if want := []string{"json_schema", "json_object", "json_object"}; !reflect.DeepEqual(formats, want) {
+				t.Errorf("response formats = %v, want %v", formats, want)
+			}
+		})
+	}
+}
+
+func TestSchemaOrderWithoutProperty(t *testing.T) {
+	// Order may name optional properties that are left out, e.g. labels without a label set
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"title": {Type: "string"}, "body": {Type: "string"}},
+		Required:   []string{"title", "body"},
+		Order:      []string{"title", "labels", "body"},
+	}
+
+	strict := strictSchema(schema)
+	if !reflect.DeepEqual(strict["required"], []string{"title", "body"}) {
+		t.Errorf("strict required = %v, want [title body]", strict["required"])
+	}
+	if ordering := geminiSchema(schema).PropertyOrdering; !reflect.DeepEqual(ordering, []string{"title", "body"}) {
+		t.Errorf("Gemini property ordering = %v, want [title body]", ordering)
+	}
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Store is an on-disk cache whose entries expire after a TTL
+type Store struct {
+	dir string
+	ttl time.Duration
+	// maxSize is the total size in bytes kept by Prune, unlimited if 0
+	maxSize int64
+}
+
+// Entry describes a file of the store
+type Entry struct {
+	// Name is the hash of the key
+	Name     string
+	Path     string
+	Size     int64
+	Modified time.Time
+	Expired  bool
+}
+
+// New creates a store in dir; entries older than ttl are treated as missing
+func New(dir string, ttl time.Duration) *Store {
+	return &Store{dir: dir, ttl: ttl}
+}
+
+// SetMaxSize limits the total size of the entries in bytes; Prune removes the oldest entries above it
+func (s *Store) SetMaxSize(bytes int64) {
+	s.maxSize = bytes
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// DefaultDir returns the pullpoet cache directory for a kind of entries, e.g. "issues"
+func DefaultDir(kind string) string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "pullpoet", kind)
+}
+
+// Get returns the data stored under key if it has not expired
+func (s *Store) Get(key string) ([]byte, bool) {
+	path := s.path(key)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > s.ttl {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put stores data under key
+func (s *Store) Put(key string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see partial entries
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), s.path(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// List returns the entries of the store, newest first
+func (s *Store) List() ([]Entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		info, err := file.Info()
+		if err != nil || file.IsDir() || strings.HasPrefix(file.Name(), ".tmp-") {
+			continue
+		}
+		entries = append(entries, Entry{
+			Name:     file.Name(),
+			Path:     filepath.Join(s.dir, file.Name()),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			Expired:  time.Since(info.ModTime()) > s.ttl,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Modified.After(entries[j].Modified)
+	})
+	return entries, nil
+}
+
+// Prune removes the expired entries and, with a size limit, the oldest entries
+// above it. It returns how many were removed.
+func (s *Store) Prune() (int, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	var size int64
+	for _, entry := range entries {
+		if !entry.Expired && (s.maxSize <= 0 || size+entry.Size <= s.maxSize) {
+			size += entry.Size
+			continue
+		}
+		if err := os.Remove(entry.Path); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// Clear removes all entries and returns how many were removed
+func (s *Store) Clear() (int, error) {
+	entries, err := s.List()
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if err := os.Remove(entry.Path); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// path returns the file of a key; keys are hashed as they may contain credentials
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
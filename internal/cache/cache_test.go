@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	store := New(t.TempDir(), time.Minute)
+	if _, ok := store.Get("missing"); ok {
+		t.Fatal("Get() found a missing key")
+	}
+	if err := store.Put("fresh", []byte("one")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := store.Put("stale", []byte("two")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(store.path("stale"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, ok := store.Get("fresh"); !ok || string(data) != "one" {
+		t.Errorf("Get(fresh) = %q, %v", data, ok)
+	}
+	if _, ok := store.Get("stale"); ok {
+		t.Error("Get(stale) returned an expired entry")
+	}
+
+	removed, err := store.Prune()
+	if err != nil || removed != 1 {
+		t.Errorf("Prune() = %d, %v, want 1 removed", removed, err)
+	}
+	if _, ok := store.Get("fresh"); !ok {
+		t.Error("Prune() removed a fresh entry")
+	}
+}
+
+func TestStoreMaxSize(t *testing.T) {
+	store := New(t.TempDir(), time.Hour)
+	store.SetMaxSize(8)
+	for i, key := range []string{"oldest", "older", "newest"} {
+		if err := store.Put(key, []byte("four")); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		modified := time.Now().Add(time.Duration(i-3) * time.Minute)
+		if err := os.Chtimes(store.path(key), modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := store.Prune()
+	if err != nil || removed != 1 {
+		t.Errorf("Prune() = %d, %v, want 1 removed", removed, err)
+	}
+	if _, ok := store.Get("oldest"); ok {
+		t.Error("Prune() kept the oldest entry above the size limit")
+	}
+	entries, err := store.List()
+	if err != nil || len(entries) != 2 || entries[0].Name != filepath.Base(store.path("newest")) {
+		t.Errorf("List() = %+v, %v, want the 2 newest entries", entries, err)
+	}
+
+	if removed, err := store.Clear(); err != nil || removed != 2 {
+		t.Errorf("Clear() = %d, %v, want 2 removed", removed, err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Store: New(t.TempDir(), time.Minute)}}
+	get := func(path, auth string) (string, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Header.Set("Authorization", auth)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), resp.Header.Get("X-Pullpoet-Cache")
+	}
+
+	if body, hit := get("/task", "alice"); body != "alice" || hit != "" {
+		t.Errorf("first GET = %q, cache %q", body, hit)
+	}
+	if body, hit := get("/task", "alice"); body != "alice" || hit != "hit" {
+		t.Errorf("second GET = %q, cache %q", body, hit)
+	}
+	// Other credentials and failed responses are not served from the cache
+	if body, _ := get("/task", "bob"); body != "bob" {
+		t.Errorf("GET with other credentials = %q", body)
+	}
+	get("/missing", "alice")
+	get("/missing", "alice")
+	if requests != 4 {
+		t.Errorf("server requests = %d, want 4", requests)
+	}
+}
+
+func TestTransportKeys(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Header.Get("PRIVATE-TOKEN") + string(body)))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{Store: New(t.TempDir(), time.Minute)}}
+	do := func(method, token, body string) (string, string) {
+		req, _ := http.NewRequest(method, server.URL, strings.NewReader(body))
+		req.Header.Set("PRIVATE-TOKEN", token)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s error = %v", method, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return string(data), resp.Header.Get("X-Pullpoet-Cache")
+	}
+
+	query := `{"query": "query Issue($id: String!) { issue(id: $id) { title } }", "variables": {"id": "ENG-1"}}`
+	tests := []struct {
+		name    string
+		method  string
+		token   string
+		body    string
+		wantHit bool
+	}{
+		{name: "first GET", method: http.MethodGet, token: "alice"},
+		{name: "repeated GET", method: http.MethodGet, token: "alice", wantHit: true},
+		{name: "GET with other token", method: http.MethodGet, token: "bob"},
+		{name: "first GraphQL query", method: http.MethodPost, token: "alice", body: query},
+		{name: "repeated GraphQL query", method: http.MethodPost, token: "alice", body: query, wantHit: true},
+		{name: "GraphQL query with other variables", method: http.MethodPost, token: "alice", body: strings.Replace(query, "ENG-1", "ENG-2", 1)},
+		{name: "GraphQL mutation", method: http.MethodPost, token: "alice", body: `{"query": "mutation { issueUpdate }"}`},
+		{name: "repeated GraphQL mutation", method: http.MethodPost, token: "alice", body: `{"query": "mutation { issueUpdate }"}`},
+	}
+
+	for _, tt := range tests {
+		body, hit := do(tt.method, tt.token, tt.body)
+		if body != tt.token+tt.body {
+			t.Errorf("%s: body = %q, want %q", tt.name, body, tt.token+tt.body)
+		}
+		if (hit == "hit") != tt.wantHit {
+			t.Errorf("%s: cache hit = %v, want %v", tt.name, hit == "hit", tt.wantHit)
+		}
+	}
+	if requests != 6 {
+		t.Errorf("server requests = %d, want 6", requests)
+	}
+}
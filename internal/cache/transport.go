@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Transport caches successful GET responses and GraphQL queries in a store. All
+// request headers are part of the key, so responses are never shared between
+// credentials, whichever header carries them (Authorization, PRIVATE-TOKEN, ...).
+type Transport struct {
+	Store *Store
+	// Base performs the requests on a cache miss, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+// RoundTrip serves cacheable requests from the cache, storing 200 responses on a miss
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var body []byte
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if req.Body == nil {
+			return base.RoundTrip(req)
+		}
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		if !isGraphQLQuery(data) {
+			return base.RoundTrip(req)
+		}
+		body = data
+	default:
+		return base.RoundTrip(req)
+	}
+
+	key := req.Method + " " + req.URL.String() + "\x00" + headerKey(req.Header) + "\x00" + string(body)
+	if cached, ok := t.Store.Get(key); ok {
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}, "X-Pullpoet-Cache": []string{"hit"}},
+			Body:          io.NopCloser(bytes.NewReader(cached)),
+			ContentLength: int64(len(cached)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	// A failing cache must not fail the request
+	_ = t.Store.Put(key, data)
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return resp, nil
+}
+
+// headerKey returns the request headers in a stable order
+func headerKey(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(name + ": " + strings.Join(header[name], ", ") + "\n")
+	}
+	return builder.String()
+}
+
+// isGraphQLQuery reports whether a POST body is a GraphQL query, which is
+// idempotent, and not a mutation or subscription
+func isGraphQLQuery(body []byte) bool {
+	var request struct {
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return false
+	}
+	query := strings.TrimSpace(request.Query)
+	return query != "" && !strings.HasPrefix(query, "mutation") && !strings.HasPrefix(query, "subscription")
+}
@@ -0,0 +1,296 @@
+package ci
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/erkineren/pullpoet/internal/forge"
+)
+
+// Provider identifies a CI system
+type Provider string
+
+// Supported CI systems
+const (
+	GitHubActions     Provider = "GitHub Actions"
+	GitLabCI          Provider = "GitLab CI"
+	BitbucketPipeline Provider = "Bitbucket Pipelines"
+	AzurePipelines    Provider = "Azure Pipelines"
+)
+
+// Environment describes the CI job pullpoet is running in
+type Environment struct {
+	Provider Provider
+	// Repo is the clone URL, with job credentials embedded when the CI system provides them
+	Repo   string
+	Source string
+	// SourceRef is fetched from Repo instead of Source, e.g. refs/pull/7/head for forks
+	SourceRef string
+	Target    string
+	PRNumber  int
+
+	outputPath  string
+	summaryPath string
+}
+
+// Detect inspects the environment variables of the known CI systems.
+// It returns nil when pullpoet is not running in a supported CI system.
+func Detect(getenv func(string) string) (*Environment, error) {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return detectGitHub(getenv)
+	case getenv("GITLAB_CI") == "true":
+		return detectGitLab(getenv), nil
+	case getenv("BITBUCKET_BUILD_NUMBER") != "":
+		return detectBitbucket(getenv), nil
+	case strings.EqualFold(getenv("TF_BUILD"), "true"):
+		return detectAzure(getenv), nil
+	}
+	return nil, nil
+}
+
+// githubEvent holds the parts of the GitHub event payload pullpoet uses
+type githubEvent struct {
+	Number      int `json:"number"`
+	PullRequest *struct {
+		Number int `json:"number"`
+		Head   struct {
+			Ref  string `json:"ref"`
+			Repo *struct {
+				FullName string `json:"full_name"`
+			} `json:"repo"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName      string `json:"full_name"`
+		CloneURL      string `json:"clone_url"`
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+}
+
+// detectGitHub reads the GitHub Actions variables and the event payload
+func detectGitHub(getenv func(string) string) (*Environment, error) {
+	env := &Environment{
+		Provider:    GitHubActions,
+		Source:      getenv("GITHUB_HEAD_REF"),
+		Target:      getenv("GITHUB_BASE_REF"),
+		outputPath:  getenv("GITHUB_OUTPUT"),
+		summaryPath: getenv("GITHUB_STEP_SUMMARY"),
+	}
+
+	if repository := getenv("GITHUB_REPOSITORY"); repository != "" {
+		serverURL := getenv("GITHUB_SERVER_URL")
+		if serverURL == "" {
+			serverURL = "https://github.com"
+		}
+		env.Repo = strings.TrimSuffix(serverURL, "/") + "/" + repository + ".git"
+	}
+
+	if eventPath := getenv("GITHUB_EVENT_PATH"); eventPath != "" {
+		data, err := os.ReadFile(eventPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub event payload: %w", err)
+		}
+		var event githubEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return nil, fmt.Errorf("failed to parse GitHub event payload: %w", err)
+		}
+
+		if event.PullRequest != nil {
+			env.PRNumber = event.PullRequest.Number
+			env.Source = firstNonEmpty(event.PullRequest.Head.Ref, env.Source)
+			env.Target = firstNonEmpty(event.PullRequest.Base.Ref, env.Target)
+			// The branch of a fork (or of a deleted fork) is not in the base repository,
+			// GitHub mirrors it as the pull request ref
+			base := firstNonEmpty(getenv("GITHUB_REPOSITORY"), event.Repository.FullName)
+			if head := event.PullRequest.Head.Repo; head == nil || !strings.EqualFold(head.FullName, base) {
+				env.SourceRef = fmt.Sprintf("refs/pull/%d/head", env.PRNumber)
+			}
+		} else if event.Number != 0 {
+			env.PRNumber = event.Number
+		}
+		if env.Repo == "" {
+			env.Repo = event.Repository.CloneURL
+		}
+		env.Target = firstNonEmpty(env.Target, event.Repository.DefaultBranch)
+	}
+
+	// Push and dispatch events have no head ref, describe the pushed branch instead
+	env.Source = firstNonEmpty(env.Source, getenv("GITHUB_REF_NAME"))
+	env.Repo = forge.WithCredentials(env.Repo, "x-access-token", getenv("GITHUB_TOKEN"))
+
+	return env, nil
+}
+
+// detectGitLab reads the GitLab CI predefined variables
+func detectGitLab(getenv func(string) string) *Environment {
+	env := &Environment{
+		Provider: GitLabCI,
+		// CI_REPOSITORY_URL already contains the job token
+		Repo:   firstNonEmpty(getenv("CI_REPOSITORY_URL"), gitURL(getenv("CI_PROJECT_URL"))),
+		Source: firstNonEmpty(getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"), getenv("CI_COMMIT_REF_NAME")),
+		Target: firstNonEmpty(getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"), getenv("CI_DEFAULT_BRANCH")),
+	}
+	env.PRNumber, _ = strconv.Atoi(getenv("CI_MERGE_REQUEST_IID"))
+	return env
+}
+
+// detectBitbucket reads the Bitbucket Pipelines default variables
+func detectBitbucket(getenv func(string) string) *Environment {
+	env := &Environment{
+		Provider: BitbucketPipeline,
+		Repo:     gitURL(getenv("BITBUCKET_GIT_HTTP_ORIGIN")),
+		Source:   getenv("BITBUCKET_BRANCH"),
+		Target:   getenv("BITBUCKET_PR_DESTINATION_BRANCH"),
+	}
+	env.PRNumber, _ = strconv.Atoi(getenv("BITBUCKET_PR_ID"))
+	return env
+}
+
+// detectAzure reads the Azure Pipelines predefined variables
+func detectAzure(getenv func(string) string) *Environment {
+	env := &Environment{
+		Provider: AzurePipelines,
+		Repo:     getenv("BUILD_REPOSITORY_URI"),
+		Source: trimRef(firstNonEmpty(
+			getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH"),
+			getenv("BUILD_SOURCEBRANCH"),
+		)),
+		Target: trimRef(getenv("SYSTEM_PULLREQUEST_TARGETBRANCH")),
+	}
+	// PULLREQUESTNUMBER is set for GitHub-hosted repositories, PULLREQUESTID for Azure Repos
+	env.PRNumber, _ = strconv.Atoi(firstNonEmpty(
+		getenv("SYSTEM_PULLREQUEST_PULLREQUESTNUMBER"),
+		getenv("SYSTEM_PULLREQUEST_PULLREQUESTID"),
+	))
+	env.Repo = forge.WithCredentials(env.Repo, "pullpoet", getenv("SYSTEM_ACCESSTOKEN"))
+	return env
+}
+
+// Output is a named value published to later steps of the job
+type Output struct {
+	Name  string
+	Value string
+}
+
+// WriteOutputs publishes outputs to later steps; Azure logging commands are written to w
+func (e *Environment) WriteOutputs(w io.Writer, outputs []Output) error {
+	switch e.Provider {
+	case GitHubActions:
+		if e.outputPath == "" {
+			return nil
+		}
+		file, err := os.OpenFile(e.outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open GITHUB_OUTPUT: %w", err)
+		}
+		defer file.Close()
+
+		for _, output := range outputs {
+			delimiter, err := heredocDelimiter(output.Value)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(file, "%s<<%s\n%s\n%s\n", output.Name, delimiter, output.Value, delimiter); err != nil {
+				return fmt.Errorf("failed to write GITHUB_OUTPUT: %w", err)
+			}
+		}
+	case AzurePipelines:
+		for _, output := range outputs {
+			fmt.Fprintf(w, "##vso[task.setvariable variable=%s;isOutput=true]%s\n", output.Name, escapeAzure(output.Value))
+		}
+	}
+	return nil
+}
+
+// WriteSummary adds markdown to the job summary when the CI system supports it
+func (e *Environment) WriteSummary(markdown string) error {
+	if e.Provider != GitHubActions || e.summaryPath == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(e.summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open GITHUB_STEP_SUMMARY: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "%s\n", markdown); err != nil {
+		return fmt.Errorf("failed to write GITHUB_STEP_SUMMARY: %w", err)
+	}
+	return nil
+}
+
+// Annotate reports an error so that the CI system highlights it on the job page
+func (e *Environment) Annotate(w io.Writer, err error) {
+	switch e.Provider {
+	case GitHubActions:
+		fmt.Fprintf(w, "::error title=pullpoet::%s\n", escapeGitHub(err.Error()))
+	case AzurePipelines:
+		fmt.Fprintf(w, "##vso[task.logissue type=error]%s\n", escapeAzure(err.Error()))
+	default:
+		fmt.Fprintf(w, "ERROR: pullpoet: %s\n", err)
+	}
+}
+
+// heredocDelimiter returns a delimiter that does not occur in value
+func heredocDelimiter(value string) (string, error) {
+	for {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate output delimiter: %w", err)
+		}
+		delimiter := "PULLPOET_" + hex.EncodeToString(buf)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
+
+// escapeGitHub escapes workflow command data
+func escapeGitHub(value string) string {
+	value = strings.ReplaceAll(value, "%", "%25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	return strings.ReplaceAll(value, "\n", "%0A")
+}
+
+// escapeAzure escapes logging command data
+func escapeAzure(value string) string {
+	value = strings.ReplaceAll(value, "%", "%AZP25")
+	value = strings.ReplaceAll(value, "\r", "%0D")
+	value = strings.ReplaceAll(value, "\n", "%0A")
+	value = strings.ReplaceAll(value, "]", "%5D")
+	return strings.ReplaceAll(value, ";", "%3B")
+}
+
+// gitURL turns a project web URL into a clone URL
+func gitURL(projectURL string) string {
+	if projectURL == "" || strings.HasSuffix(projectURL, ".git") {
+		return projectURL
+	}
+	return strings.TrimSuffix(projectURL, "/") + ".git"
+}
+
+// trimRef removes the refs/heads/ prefix from a branch reference
+func trimRef(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
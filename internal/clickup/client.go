@@ -5,15 +5,25 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 )
 
+// replyWorkers is the number of comment replies fetched concurrently
+const replyWorkers = 4
+
 // Client handles ClickUp API operations
 type Client struct {
 	baseURL string
 	pat     string
 	client  *http.Client
+	log     logger.Logger
+	// customFields are the custom field names or IDs included in tasks, all if empty
+	customFields []string
 }
 
 // TaskResponse represents the response from ClickUp API for a single task
@@ -33,6 +43,59 @@ type TaskResponse struct {
 		Username string `json:"username"`
 		Email    string `json:"email"`
 	} `json:"creator"`
+	URL                 string `json:"url"`
+	MarkdownDescription string `json:"markdown_description"`
+	Tags                []struct {
+		Name string `json:"name"`
+	} `json:"tags"`
+	Priority *struct {
+		Priority string `json:"priority"`
+	} `json:"priority"`
+	DueDate      string            `json:"due_date"`
+	Checklists   []Checklist       `json:"checklists"`
+	CustomFields []CustomField     `json:"custom_fields"`
+	Subtasks     []SubtaskResponse `json:"subtasks"`
+	Attachments  []struct {
+		Title string `json:"title"`
+	} `json:"attachments"`
+}
+
+// Checklist is a task checklist
+type Checklist struct {
+	Name  string          `json:"name"`
+	Items []ChecklistItem `json:"items"`
+}
+
+// ChecklistItem is a checklist item, possibly with nested items
+type ChecklistItem struct {
+	Name     string          `json:"name"`
+	Resolved bool            `json:"resolved"`
+	Children []ChecklistItem `json:"children"`
+}
+
+// CustomField is a custom field as returned by the ClickUp API
+type CustomField struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Value      interface{} `json:"value"`
+	TypeConfig struct {
+		Options []struct {
+			ID         string      `json:"id"`
+			Name       string      `json:"name"`
+			Label      string      `json:"label"`
+			OrderIndex interface{} `json:"orderindex"`
+		} `json:"options"`
+	} `json:"type_config"`
+}
+
+// SubtaskResponse is a subtask embedded in a task response (include_subtasks=true)
+type SubtaskResponse struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status struct {
+		Status string `json:"status"`
+	} `json:"status"`
 	URL string `json:"url"`
 }
 
@@ -43,11 +106,9 @@ type CommentResponse struct {
 
 // Comment represents a single comment in ClickUp
 type Comment struct {
-	ID      string `json:"id"`
-	Comment []struct {
-		Text string `json:"text"`
-	} `json:"comment"`
-	User struct {
+	ID      string         `json:"id"`
+	Comment []CommentBlock `json:"comment"`
+	User    struct {
 		ID       int    `json:"id"`
 		Username string `json:"username"`
 		Email    string `json:"email"`
@@ -57,6 +118,20 @@ type Comment struct {
 	Replies     []Comment `json:"replies,omitempty"`
 }
 
+// CommentBlock is a block of rich text in a comment
+type CommentBlock struct {
+	Text string `json:"text"`
+}
+
+// Text returns the plain text of the comment
+func (c Comment) Text() string {
+	var builder strings.Builder
+	for _, block := range c.Comment {
+		builder.WriteString(block.Text)
+	}
+	return builder.String()
+}
+
 // Task represents a simplified task structure for our use
 type Task struct {
 	ID          string
@@ -66,6 +141,28 @@ type Task struct {
 	Creator     string
 	URL         string
 	Comments    []Comment
+	Tags        []string
+	Priority    string
+	DueDate     string
+	Checklists  []Checklist
+	// Fields are the included custom fields with a value, rendered as text
+	Fields      []Field
+	Subtasks    []Subtask
+	Attachments []string
+}
+
+// Field is a custom field value rendered as text
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Subtask is a subtask of a task
+type Subtask struct {
+	ID     string
+	Name   string
+	Status string
+	URL    string
 }
 
 // NewClient creates a new ClickUp API client
@@ -76,12 +173,35 @@ func NewClient(pat string) *Client {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		log: logger.Stdout,
 	}
 }
 
+// SetTransport sets the HTTP transport, e.g. for caching or rate limiting
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.client.Transport = rt
+}
+
+// SetLogger sets where progress messages are written
+func (c *Client) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// SetBaseURL overrides the API base URL, e.g. for a proxy or a local test server
+func (c *Client) SetBaseURL(baseURL string) {
+	if baseURL != "" {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// SetCustomFields sets the custom field names or IDs included in fetched tasks
+func (c *Client) SetCustomFields(fields []string) {
+	c.customFields = fields
+}
+
 // GetTask fetches a task by ID from ClickUp
 func (c *Client) GetTask(taskID string) (*Task, error) {
-	url := fmt.Sprintf("%s/task/%s", c.baseURL, taskID)
+	url := fmt.Sprintf("%s/task/%s?include_subtasks=true&include_markdown_description=true", c.baseURL, taskID)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -117,16 +237,43 @@ func (c *Client) GetTask(taskID string) (*Task, error) {
 		URL:         taskResp.URL,
 	}
 
+	// Prefer the Markdown description, it keeps lists and formatting
+	if taskResp.MarkdownDescription != "" {
+		task.Description = taskResp.MarkdownDescription
+	}
 	// If description is empty, use text_content as fallback
 	if task.Description == "" && taskResp.TextContent != "" {
 		task.Description = taskResp.TextContent
 	}
 
+	for _, tag := range taskResp.Tags {
+		task.Tags = append(task.Tags, tag.Name)
+	}
+	if taskResp.Priority != nil {
+		task.Priority = taskResp.Priority.Priority
+	}
+	task.DueDate = formatDate(taskResp.DueDate)
+	task.Checklists = taskResp.Checklists
+	for _, subtask := range taskResp.Subtasks {
+		task.Subtasks = append(task.Subtasks, Subtask{ID: subtask.ID, Name: subtask.Name, Status: subtask.Status.Status, URL: subtask.URL})
+	}
+	for _, attachment := range taskResp.Attachments {
+		task.Attachments = append(task.Attachments, attachment.Title)
+	}
+	for _, field := range taskResp.CustomFields {
+		if !c.includeField(field) {
+			continue
+		}
+		if value := field.Text(); value != "" {
+			task.Fields = append(task.Fields, Field{Name: field.Name, Value: value})
+		}
+	}
+
 	// Fetch comments for the task
 	comments, err := c.GetTaskComments(taskID)
 	if err != nil {
 		// Log the error but don't fail the entire request
-		fmt.Printf("Warning: Failed to fetch comments for task %s: %v\n", taskID, err)
+		c.log.Printf("Warning: Failed to fetch comments for task %s: %v\n", taskID, err)
 	} else {
 		task.Comments = comments
 	}
@@ -134,6 +281,104 @@ func (c *Client) GetTask(taskID string) (*Task, error) {
 	return task, nil
 }
 
+// includeField reports whether a custom field is selected by name or ID
+func (c *Client) includeField(field CustomField) bool {
+	if len(c.customFields) == 0 {
+		return true
+	}
+	for _, selected := range c.customFields {
+		if selected == field.ID || strings.EqualFold(selected, field.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text renders the value of a custom field, resolving dropdown and label options
+func (f CustomField) Text() string {
+	switch f.Type {
+	case "drop_down":
+		return f.optionName(f.Value)
+	case "labels":
+		values, _ := f.Value.([]interface{})
+		var names []string
+		for _, value := range values {
+			if name := f.optionName(value); name != "" {
+				names = append(names, name)
+			}
+		}
+		return strings.Join(names, ", ")
+	case "date":
+		return formatDate(valueText(f.Value))
+	case "checkbox":
+		if checked, ok := f.Value.(bool); ok && checked {
+			return "Yes"
+		}
+		if text, ok := f.Value.(string); ok && text == "true" {
+			return "Yes"
+		}
+		return ""
+	}
+	return valueText(f.Value)
+}
+
+// optionName returns the name of a dropdown or label option by ID or order index
+func (f CustomField) optionName(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	for _, option := range f.TypeConfig.Options {
+		if option.ID == fmt.Sprint(value) || fmt.Sprint(option.OrderIndex) == fmt.Sprint(value) {
+			if option.Name != "" {
+				return option.Name
+			}
+			return option.Label
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// valueText renders a plain custom field value: text, numbers, users, tasks and lists
+func valueText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if text := valueText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		if percent, ok := v["percent_completed"].(float64); ok {
+			return strconv.FormatFloat(percent, 'f', -1, 64) + "%"
+		}
+		for _, key := range []string{"name", "username", "email", "value"} {
+			if text, ok := v[key].(string); ok {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// formatDate formats a ClickUp timestamp (milliseconds since epoch) as YYYY-MM-DD
+func formatDate(value string) string {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return value
+	}
+	return time.UnixMilli(millis).UTC().Format("2006-01-02")
+}
+
 // GetTaskComments fetches comments for a task by ID from ClickUp
 func (c *Client) GetTaskComments(taskID string) ([]Comment, error) {
 	url := fmt.Sprintf("%s/task/%s/comment", c.baseURL, taskID)
@@ -162,18 +407,29 @@ func (c *Client) GetTaskComments(taskID string) ([]Comment, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Fetch replies for each comment that has replies
+	// Fetch replies for each comment that has replies, a few comments at a time
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, replyWorkers)
 	for i := range commentResp.Comments {
-		if commentResp.Comments[i].ReplyCount > 0 {
-			replies, err := c.GetCommentReplies(taskID, commentResp.Comments[i].ID)
+		if commentResp.Comments[i].ReplyCount == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(comment *Comment) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			replies, err := c.GetCommentReplies(taskID, comment.ID)
 			if err != nil {
 				// Log the error but don't fail the entire request
-				fmt.Printf("Warning: Failed to fetch replies for comment %s: %v\n", commentResp.Comments[i].ID, err)
-			} else {
-				commentResp.Comments[i].Replies = replies
+				c.log.Printf("Warning: Failed to fetch replies for comment %s: %v\n", comment.ID, err)
+				return
 			}
-		}
+			comment.Replies = replies
+		}(&commentResp.Comments[i])
 	}
+	wg.Wait()
 
 	return commentResp.Comments, nil
 }
@@ -223,6 +479,39 @@ func (t *Task) FormatTaskDescription() string {
 **Description:**
 %s`, t.Name, t.ID, t.Status, t.Creator, t.URL, t.Description))
 
+	var details []string
+	if t.Priority != "" {
+		details = append(details, "**Priority:** "+t.Priority)
+	}
+	if t.DueDate != "" {
+		details = append(details, "**Due Date:** "+t.DueDate)
+	}
+	if len(t.Tags) > 0 {
+		details = append(details, "**Tags:** "+strings.Join(t.Tags, ", "))
+	}
+	if len(t.Attachments) > 0 {
+		details = append(details, "**Attachments:** "+strings.Join(t.Attachments, ", "))
+	}
+	if len(details) > 0 {
+		builder.WriteString("\n\n" + strings.Join(details, "\n"))
+	}
+
+	for _, field := range t.Fields {
+		builder.WriteString(fmt.Sprintf("\n\n**%s:**\n%s", field.Name, field.Value))
+	}
+
+	for _, checklist := range t.Checklists {
+		builder.WriteString(fmt.Sprintf("\n\n**Checklist: %s**", checklist.Name))
+		writeChecklistItems(&builder, checklist.Items, "")
+	}
+
+	if len(t.Subtasks) > 0 {
+		builder.WriteString("\n\n**Subtasks:**")
+		for _, subtask := range t.Subtasks {
+			builder.WriteString(fmt.Sprintf("\n- %s (%s)", subtask.Name, subtask.Status))
+		}
+	}
+
 	// Add comments if available
 	if len(t.Comments) > 0 {
 		builder.WriteString("\n\n**Comments:**\n")
@@ -255,3 +544,15 @@ func (t *Task) FormatTaskDescription() string {
 
 	return builder.String()
 }
+
+// writeChecklistItems writes checklist items as Markdown task list items
+func writeChecklistItems(builder *strings.Builder, items []ChecklistItem, indent string) {
+	for _, item := range items {
+		box := "[ ]"
+		if item.Resolved {
+			box = "[x]"
+		}
+		builder.WriteString(fmt.Sprintf("\n%s- %s %s", indent, box, item.Name))
+		writeChecklistItems(builder, item.Children, indent+"  ")
+	}
+}
@@ -0,0 +1,98 @@
+package clickup
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+const richTaskResponse = `{
+	"id": "abc123", "name": "Password reset",
+	"description": "Plain description", "markdown_description": "Users **reset** passwords:\n- by email",
+	"status": {"status": "in progress"}, "creator": {"username": "ada"},
+	"url": "https://app.clickup.com/t/abc123",
+	"tags": [{"name": "auth"}, {"name": "web"}],
+	"priority": {"id": "2", "priority": "high"},
+	"due_date": "1717200000000",
+	"checklists": [{"name": "Acceptance", "items": [
+		{"name": "Email is sent", "resolved": true, "children": []},
+		{"name": "Link expires", "resolved": false, "children": [{"name": "after 30 minutes", "resolved": false}]}
+	]}],
+	"custom_fields": [
+		{"id": "f1", "name": "Acceptance Criteria", "type": "text", "value": "Reset works without support"},
+		{"id": "f2", "name": "Risk", "type": "drop_down", "value": 1, "type_config": {"options": [{"id": "o1", "name": "Low", "orderindex": 0}, {"id": "o2", "name": "High", "orderindex": 1}]}},
+		{"id": "f3", "name": "Platforms", "type": "labels", "value": ["l2", "l1"], "type_config": {"options": [{"id": "l1", "label": "iOS"}, {"id": "l2", "label": "Web"}]}},
+		{"id": "f4", "name": "Security review", "type": "checkbox", "value": "true"},
+		{"id": "f5", "name": "Owner", "type": "users", "value": [{"username": "bob"}]},
+		{"id": "f6", "name": "Empty", "type": "short_text"}
+	],
+	"subtasks": [{"id": "sub1", "name": "Email template", "status": {"status": "done"}, "url": "https://app.clickup.com/t/sub1"}],
+	"attachments": [{"title": "flow.png"}, {"title": "spec.pdf"}]
+}`
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/task/abc123":
+			if r.URL.Query().Get("include_subtasks") != "true" || r.URL.Query().Get("include_markdown_description") != "true" {
+				t.Errorf("query = %s", r.URL.RawQuery)
+			}
+			io.WriteString(w, richTaskResponse)
+		case "/task/abc123/comment":
+			io.WriteString(w, `{"comments": []}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("pk_test")
+	client.SetBaseURL(server.URL)
+	client.SetLogger(logger.Discard)
+	return client
+}
+
+func TestGetTaskRichFields(t *testing.T) {
+	task, err := newTestClient(t).GetTask("abc123")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+
+	description := task.FormatTaskDescription()
+	for _, want := range []string{
+		"**Description:**\nUsers **reset** passwords:\n- by email",
+		"**Priority:** high\n**Due Date:** 2024-06-01\n**Tags:** auth, web\n**Attachments:** flow.png, spec.pdf",
+		"**Acceptance Criteria:**\nReset works without support",
+		"**Risk:**\nHigh",
+		"**Platforms:**\nWeb, iOS",
+		"**Security review:**\nYes",
+		"**Owner:**\nbob",
+		"**Checklist: Acceptance**\n- [x] Email is sent\n- [ ] Link expires\n  - [ ] after 30 minutes",
+		"**Subtasks:**\n- Email template (done)",
+	} {
+		if !strings.Contains(description, want) {
+			t.Errorf("description missing %q:\n%s", want, description)
+		}
+	}
+	if strings.Contains(description, "Empty") || strings.Contains(description, "Plain description") {
+		t.Errorf("description contains empty field or plain description:\n%s", description)
+	}
+}
+
+func TestGetTaskSelectedCustomFields(t *testing.T) {
+	client := newTestClient(t)
+	client.SetCustomFields([]string{"acceptance criteria", "f2"})
+
+	task, err := client.GetTask("abc123")
+	if err != nil {
+		t.Fatalf("GetTask() error = %v", err)
+	}
+	if len(task.Fields) != 2 || task.Fields[0].Name != "Acceptance Criteria" || task.Fields[1].Value != "High" {
+		t.Errorf("fields = %+v, want Acceptance Criteria and Risk", task.Fields)
+	}
+}
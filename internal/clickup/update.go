@@ -0,0 +1,105 @@
+package clickup
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// CreateTaskComment posts a plain text comment on a task
+func (c *Client) CreateTaskComment(taskID, text string) error {
+	url := fmt.Sprintf("%s/task/%s/comment", c.baseURL, taskID)
+	return c.send("POST", url, map[string]interface{}{"comment_text": text, "notify_all": false})
+}
+
+// UpdateComment replaces the text of a comment
+func (c *Client) UpdateComment(commentID, text string) error {
+	url := fmt.Sprintf("%s/comment/%s", c.baseURL, commentID)
+	return c.send("PUT", url, map[string]interface{}{"comment_text": text, "notify_all": false})
+}
+
+// FindPullRequestComment returns the ID of the pull request comment linking url, or "" if there is none
+func (c *Client) FindPullRequestComment(taskID, url string) (string, error) {
+	comments, err := c.GetTaskComments(taskID)
+	if err != nil {
+		return "", err
+	}
+	for _, comment := range comments {
+		lines := strings.Split(comment.Text(), "\n")
+		// The link has a line of its own, so .../pull/7 does not match .../pull/70
+		if len(lines) > 1 && strings.HasPrefix(lines[0], "Pull request:") && strings.TrimSpace(lines[1]) == url {
+			return comment.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// SetPullRequestComment updates the comment linking the pull request, or adds one if
+// there is none, so reruns do not add duplicates. It reports whether a comment was updated.
+func (c *Client) SetPullRequestComment(taskID, title, url, summary string) (bool, error) {
+	text := PullRequestComment(title, url, summary)
+	commentID, err := c.FindPullRequestComment(taskID, url)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	if commentID == "" {
+		return false, c.CreateTaskComment(taskID, text)
+	}
+	return true, c.UpdateComment(commentID, text)
+}
+
+// UpdateTaskStatus sets the status of a task, e.g. "in review"
+func (c *Client) UpdateTaskStatus(taskID, status string) error {
+	url := fmt.Sprintf("%s/task/%s", c.baseURL, taskID)
+	return c.send("PUT", url, map[string]interface{}{"status": status})
+}
+
+// SetCustomField sets the value of a custom field on a task
+func (c *Client) SetCustomField(taskID, fieldID string, value interface{}) error {
+	url := fmt.Sprintf("%s/task/%s/field/%s", c.baseURL, taskID, fieldID)
+	return c.send("POST", url, map[string]interface{}{"value": value})
+}
+
+// send performs an authenticated request with a JSON payload
+func (c *Client) send(method, url string, payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", c.pat)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ClickUp API error (status %d): %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// PullRequestComment builds a comment linking a pull request, with a short summary
+func PullRequestComment(title, url, summary string) string {
+	var builder strings.Builder
+	builder.WriteString("Pull request: " + title)
+	if url != "" {
+		builder.WriteString("\n" + url)
+	}
+	if summary != "" {
+		builder.WriteString("\n\n" + summary)
+	}
+	return builder.String()
+}
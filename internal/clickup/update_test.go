@@ -0,0 +1,121 @@
+package clickup
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteBack(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		body   map[string]interface{}
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "pk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		recorded := request{method: r.Method, path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&recorded.body)
+		requests = append(requests, recorded)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("pk_test")
+	client.SetBaseURL(server.URL + "/")
+
+	link := "https://github.com/acme/app/pull/7"
+	if err := client.CreateTaskComment("abc123", PullRequestComment("Add login", link, "Adds the login form.")); err != nil {
+		t.Fatalf("CreateTaskComment() error = %v", err)
+	}
+	if err := client.UpdateTaskStatus("abc123", "in review"); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+	if err := client.SetCustomField("abc123", "field-1", link); err != nil {
+		t.Fatalf("SetCustomField() error = %v", err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		key    string
+		want   interface{}
+	}{
+		{"POST", "/task/abc123/comment", "comment_text", "Pull request: Add login\n" + link + "\n\nAdds the login form."},
+		{"PUT", "/task/abc123", "status", "in review"},
+		{"POST", "/task/abc123/field/field-1", "value", link},
+	}
+	if len(requests) != len(tests) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(tests))
+	}
+	for i, tt := range tests {
+		got := requests[i]
+		if got.method != tt.method || got.path != tt.path || got.body[tt.key] != tt.want {
+			t.Errorf("request %d = %s %s %v, want %s %s %s=%v", i, got.method, got.path, got.body, tt.method, tt.path, tt.key, tt.want)
+		}
+	}
+}
+
+func TestWriteBackError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"err": "Status does not exist"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("pk_test")
+	client.SetBaseURL(server.URL)
+	err := client.UpdateTaskStatus("abc123", "shipped")
+	if err == nil || err.Error() != `ClickUp API error (status 400): {"err": "Status does not exist"}` {
+		t.Errorf("UpdateTaskStatus() error = %v", err)
+	}
+}
+
+func TestSetPullRequestComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		wantUpdated bool
+		wantRequest string
+	}{
+		{name: "existing comment is updated", url: "https://github.com/acme/app/pull/7", wantUpdated: true, wantRequest: "PUT /comment/90001"},
+		{name: "comment of another pull request is kept", url: "https://github.com/acme/app/pull/8", wantRequest: "POST /task/abc123/comment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var writes []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" && r.URL.Path == "/task/abc123/comment" {
+					w.Write([]byte(`{"comments": [
+						{"id": "90000", "comment": [{"text": "See https://github.com/acme/app/pull/7"}]},
+						{"id": "90002", "comment": [{"text": "Pull request: Add signup\nhttps://github.com/acme/app/pull/70"}]},
+						{"id": "90001", "comment": [{"text": "Pull request: Add login\nhttps://github.com/acme/app/pull/7\n\nAdds the login form."}]}
+					]}`))
+					return
+				}
+				writes = append(writes, r.Method+" "+r.URL.Path)
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			client := NewClient("pk_test")
+			client.SetBaseURL(server.URL)
+			updated, err := client.SetPullRequestComment("abc123", "Add login", tt.url, "Adds the login form.")
+			if err != nil {
+				t.Fatalf("SetPullRequestComment() error = %v", err)
+			}
+			if updated != tt.wantUpdated {
+				t.Errorf("updated = %v, want %v", updated, tt.wantUpdated)
+			}
+			if len(writes) != 1 || writes[0] != tt.wantRequest {
+				t.Errorf("requests = %q, want %q", writes, tt.wantRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported forges
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// PullRequest identifies a pull/merge request and the branches it compares
+type PullRequest struct {
+	Forge string
+	// Repo is "owner/repo" on GitHub and the project ID or path on GitLab
+	Repo     string
+	Number   int
+	Title    string
+	Body     string
+	Author   string
+	Source   string
+	Target   string
+	CloneURL string
+	// SourceRef is fetched from CloneURL instead of Source, e.g. refs/pull/7/head
+	// for pull requests from forks whose branch is not in the base repository
+	SourceRef string
+	URL       string
+}
+
+// Publisher writes a generated description back to the forge
+type Publisher interface {
+	UpdateDescription(pull PullRequest, body string) error
+	Comment(pull PullRequest, body string) error
+	AddLabels(pull PullRequest, labels []string) error
+	// RequestReviewers asks users or teams ("org/team") to review, given without "@"
+	RequestReviewers(pull PullRequest, reviewers []string) error
+}
+
+// GitHubClient talks to the GitHub REST API
+type GitHubClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGitHubClient creates a GitHub client; baseURL defaults to https://api.github.com
+func NewGitHubClient(baseURL, token string) *GitHubClient {
+	if baseURL == "" {
+		baseURL = "https://api.github.com"
+	}
+	return &GitHubClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetTransport sets the HTTP transport, e.g. for caching or rate limiting
+func (c *GitHubClient) SetTransport(rt http.RoundTripper) {
+	c.client.Transport = rt
+}
+
+// UpdateDescription replaces the body of a pull request
+func (c *GitHubClient) UpdateDescription(pull PullRequest, body string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/pulls/%d", c.baseURL, pull.Repo, pull.Number)
+	return c.send("PATCH", endpoint, map[string]string{"body": body})
+}
+
+// Comment posts a comment on a pull request
+func (c *GitHubClient) Comment(pull PullRequest, body string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.baseURL, pull.Repo, pull.Number)
+	return c.send("POST", endpoint, map[string]string{"body": body})
+}
+
+// AddLabels adds labels to a pull request, keeping the existing ones
+func (c *GitHubClient) AddLabels(pull PullRequest, labels []string) error {
+	endpoint := fmt.Sprintf("%s/repos/%s/issues/%d/labels", c.baseURL, pull.Repo, pull.Number)
+	return c.send("POST", endpoint, map[string][]string{"labels": labels})
+}
+
+// RequestReviewers requests reviews from users and teams ("org/team") on a pull request
+func (c *GitHubClient) RequestReviewers(pull PullRequest, reviewers []string) error {
+	users, teams := []string{}, []string{}
+	for _, reviewer := range reviewers {
+		if _, team, ok := strings.Cut(reviewer, "/"); ok {
+			teams = append(teams, team)
+		} else {
+			users = append(users, reviewer)
+		}
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/pulls/%d/requested_reviewers", c.baseURL, pull.Repo, pull.Number)
+	return c.send("POST", endpoint, map[string][]string{"reviewers": users, "team_reviewers": teams})
+}
+
+// send performs an authenticated JSON request against the GitHub API
+func (c *GitHubClient) send(method, endpoint string, payload interface{}) error {
+	req, err := newJSONRequest(method, endpoint, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return do(c.client, req, "GitHub")
+}
+
+// GitLabClient talks to the GitLab REST API
+type GitLabClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewGitLabClient creates a GitLab client; baseURL defaults to https://gitlab.com/api/v4
+func NewGitLabClient(baseURL, token string) *GitLabClient {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com/api/v4"
+	}
+	return &GitLabClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// SetTransport sets the HTTP transport, e.g. for caching or rate limiting
+func (c *GitLabClient) SetTransport(rt http.RoundTripper) {
+	c.client.Transport = rt
+}
+
+// UpdateDescription replaces the description of a merge request
+func (c *GitLabClient) UpdateDescription(pull PullRequest, body string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%d", c.baseURL, url.PathEscape(pull.Repo), pull.Number)
+	return c.send("PUT", endpoint, map[string]string{"description": body})
+}
+
+// Comment posts a note on a merge request
+func (c *GitLabClient) Comment(pull PullRequest, body string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", c.baseURL, url.PathEscape(pull.Repo), pull.Number)
+	return c.send("POST", endpoint, map[string]string{"body": body})
+}
+
+// AddLabels adds labels to a merge request, keeping the existing ones
+func (c *GitLabClient) AddLabels(pull PullRequest, labels []string) error {
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%d", c.baseURL, url.PathEscape(pull.Repo), pull.Number)
+	return c.send("PUT", endpoint, map[string]string{"add_labels": strings.Join(labels, ",")})
+}
+
+// RequestReviewers sets the reviewers of a merge request. GitLab only accepts
+// users, groups and unknown usernames are skipped.
+func (c *GitLabClient) RequestReviewers(pull PullRequest, reviewers []string) error {
+	var ids []int
+	for _, reviewer := range reviewers {
+		if strings.Contains(reviewer, "/") {
+			continue
+		}
+		var users []struct {
+			ID int `json:"id"`
+		}
+		if err := c.get(fmt.Sprintf("%s/users?username=%s", c.baseURL, url.QueryEscape(reviewer)), &users); err != nil {
+			return fmt.Errorf("failed to look up GitLab user %s: %w", reviewer, err)
+		}
+		if len(users) > 0 {
+			ids = append(ids, users[0].ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests/%d", c.baseURL, url.PathEscape(pull.Repo), pull.Number)
+	return c.send("PUT", endpoint, map[string][]int{"reviewer_ids": ids})
+}
+
+// send performs an authenticated JSON request against the GitLab API
+func (c *GitLabClient) send(method, endpoint string, payload interface{}) error {
+	req, err := newJSONRequest(method, endpoint, payload)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+	return do(c.client, req, "GitLab")
+}
+
+// WithCredentials embeds a token into an HTTPS clone URL
+func WithCredentials(repoURL, username, token string) string {
+	if repoURL == "" || token == "" {
+		return repoURL
+	}
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Scheme != "https" || parsed.User != nil {
+		return repoURL
+	}
+	parsed.User = url.UserPassword(username, token)
+	return parsed.String()
+}
+
+// PullRequestURL returns the web URL of a pull/merge request, or "" for unknown hosts
+func PullRequestURL(repoURL string, number int) string {
+	host, repo, err := ParseRepoURL(repoURL)
+	if err != nil || number <= 0 {
+		return ""
+	}
+	switch {
+	case strings.Contains(host, "bitbucket"):
+		return fmt.Sprintf("https://%s/%s/pull-requests/%d", host, repo, number)
+	case strings.Contains(host, "dev.azure.com") || strings.Contains(host, "visualstudio.com"):
+		return ""
+	case DetectForge(host) == GitLab:
+		return fmt.Sprintf("https://%s/%s/-/merge_requests/%d", host, repo, number)
+	default:
+		return fmt.Sprintf("https://%s/%s/pull/%d", host, repo, number)
+	}
+}
+
+// newJSONRequest builds a request with a JSON encoded payload
+func newJSONRequest(method, endpoint string, payload interface{}) (*http.Request, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(method, endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// do sends the request and turns non-2xx responses into errors
+func do(client *http.Client, req *http.Request, name string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s API error: %s - %s", name, resp.Status, string(body))
+	}
+	return nil
+}
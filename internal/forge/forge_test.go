@@ -0,0 +1,97 @@
+package forge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAddLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		publisher func(url string) Publisher
+		pull      PullRequest
+		want      string
+	}{
+		{
+			name:      "github",
+			publisher: func(url string) Publisher { return NewGitHubClient(url, "token") },
+			pull:      PullRequest{Repo: "acme/app", Number: 7},
+			want:      `POST /repos/acme/app/issues/7/labels {"labels":["bug","security"]}`,
+		},
+		{
+			name:      "gitlab",
+			publisher: func(url string) Publisher { return NewGitLabClient(url, "token") },
+			pull:      PullRequest{Repo: "42", Number: 3},
+			want:      `PUT /projects/42/merge_requests/3 {"add_labels":"bug,security"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				got = r.Method + " " + r.URL.Path + " " + string(body)
+			}))
+			defer server.Close()
+
+			if err := tt.publisher(server.URL).AddLabels(tt.pull, []string{"bug", "security"}); err != nil {
+				t.Fatalf("AddLabels() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("request = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestReviewers(t *testing.T) {
+	tests := []struct {
+		name      string
+		publisher func(url string) Publisher
+		want      []string
+	}{
+		{
+			name:      "github users and teams",
+			publisher: func(url string) Publisher { return NewGitHubClient(url, "token") },
+			want:      []string{`POST /repos/acme/app/pulls/7/requested_reviewers {"reviewers":["octocat"],"team_reviewers":["core"]}`},
+		},
+		{
+			name:      "gitlab users by ID, groups skipped",
+			publisher: func(url string) Publisher { return NewGitLabClient(url, "token") },
+			want: []string{
+				"GET /users?username=octocat ",
+				`PUT /projects/acme%2Fapp/merge_requests/7 {"reviewer_ids":[42]}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				target := r.URL.EscapedPath()
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				got = append(got, r.Method+" "+target+" "+string(body))
+				if r.Method == http.MethodGet {
+					io.WriteString(w, `[{"id": 42}]`)
+				}
+			}))
+			defer server.Close()
+
+			pull := PullRequest{Repo: "acme/app", Number: 7}
+			if err := tt.publisher(server.URL).RequestReviewers(pull, []string{"octocat", "acme/core"}); err != nil {
+				t.Fatalf("RequestReviewers() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("requests = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Issue represents a GitHub or GitLab issue with its discussion
+type Issue struct {
+	Forge     string
+	Repo      string
+	Number    int
+	Title     string
+	Body      string
+	State     string
+	Author    string
+	Labels    []string
+	URL       string
+	Comments  []IssueComment
+	LinkedPRs []LinkedPR
+}
+
+// IssueComment represents a single comment on an issue
+type IssueComment struct {
+	Body      string
+	Author    string
+	CreatedAt string
+}
+
+// LinkedPR is a pull/merge request that references an issue
+type LinkedPR struct {
+	Number int
+	Title  string
+	State  string
+	URL    string
+}
+
+// IssueFetcher fetches issues from a forge
+type IssueFetcher interface {
+	GetIssue(repo string, number int) (*Issue, error)
+}
+
+// IssueRef identifies an issue on a forge host
+type IssueRef struct {
+	Host   string
+	Repo   string
+	Number int
+}
+
+var (
+	shortIssuePattern = regexp.MustCompile(`^#(\d+)$`)
+	repoIssuePattern  = regexp.MustCompile(`^([\w.-]+(?:/[\w.-]+)+)#(\d+)$`)
+	urlIssuePattern   = regexp.MustCompile(`^/(.+?)(?:/-)?/issues/(\d+)/?$`)
+)
+
+// ParseIssueRef parses "#123", "owner/repo#123" or a full issue URL.
+// Short references are resolved against the repository URL.
+func ParseIssueRef(ref, repoURL string) (IssueRef, error) {
+	ref = strings.TrimSpace(ref)
+
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		parsed, err := url.Parse(ref)
+		if err != nil {
+			return IssueRef{}, fmt.Errorf("invalid issue URL %s: %w", ref, err)
+		}
+		match := urlIssuePattern.FindStringSubmatch(parsed.Path)
+		if match == nil {
+			return IssueRef{}, fmt.Errorf("invalid issue URL %s: expected .../owner/repo/issues/<number>", ref)
+		}
+		number, _ := strconv.Atoi(match[2])
+		return IssueRef{Host: parsed.Host, Repo: match[1], Number: number}, nil
+	}
+
+	if match := shortIssuePattern.FindStringSubmatch(ref); match != nil {
+		host, repo, err := ParseRepoURL(repoURL)
+		if err != nil {
+			return IssueRef{}, fmt.Errorf("cannot resolve %s: %w", ref, err)
+		}
+		number, _ := strconv.Atoi(match[1])
+		return IssueRef{Host: host, Repo: repo, Number: number}, nil
+	}
+
+	if match := repoIssuePattern.FindStringSubmatch(ref); match != nil {
+		// Other repositories are looked up on the same host as the current one
+		host := "github.com"
+		if repoURL != "" {
+			if repoHost, _, err := ParseRepoURL(repoURL); err == nil {
+				host = repoHost
+			}
+		}
+		number, _ := strconv.Atoi(match[2])
+		return IssueRef{Host: host, Repo: match[1], Number: number}, nil
+	}
+
+	return IssueRef{}, fmt.Errorf("invalid issue reference %q: use #123, owner/repo#123 or an issue URL", ref)
+}
+
+// ParseRepoURL returns the host and "owner/repo" path of an HTTPS or SSH repository URL
+func ParseRepoURL(repoURL string) (string, string, error) {
+	if repoURL == "" {
+		return "", "", fmt.Errorf("repository URL is empty")
+	}
+
+	var host, path string
+	if !strings.Contains(repoURL, "://") && strings.Contains(repoURL, ":") {
+		// scp-like SSH syntax: git@host:owner/repo.git
+		hostPart, pathPart, _ := strings.Cut(repoURL, ":")
+		host = hostPart[strings.LastIndex(hostPart, "@")+1:]
+		path = pathPart
+	} else {
+		parsed, err := url.Parse(repoURL)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid repository URL: %w", err)
+		}
+		host = parsed.Hostname()
+		path = parsed.Path
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("invalid repository URL: %s", repoURL)
+	}
+	return host, path, nil
+}
+
+// DetectForge guesses the forge from a host name; hosts containing "gitlab" are GitLab
+func DetectForge(host string) string {
+	if strings.Contains(strings.ToLower(host), "gitlab") {
+		return GitLab
+	}
+	return GitHub
+}
+
+// APIURL returns the default REST API URL of a forge host
+func APIURL(forgeName, host string) string {
+	switch {
+	case forgeName == GitLab:
+		return "https://" + host + "/api/v4"
+	case host == "github.com":
+		return "https://api.github.com"
+	default:
+		// GitHub Enterprise Server
+		return "https://" + host + "/api/v3"
+	}
+}
+
+// GetIssue fetches an issue with its comments and the pull requests that reference it
+func (c *GitHubClient) GetIssue(repo string, number int) (*Issue, error) {
+	var data struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Labels []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	endpoint := fmt.Sprintf("%s/repos/%s/issues/%d", c.baseURL, repo, number)
+	if err := c.get(endpoint, &data); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue: %w", err)
+	}
+
+	issue := &Issue{
+		Forge:  GitHub,
+		Repo:   repo,
+		Number: data.Number,
+		Title:  data.Title,
+		Body:   data.Body,
+		State:  data.State,
+		Author: data.User.Login,
+		URL:    data.HTMLURL,
+	}
+	for _, label := range data.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+
+	var comments []struct {
+		Body      string `json:"body"`
+		CreatedAt string `json:"created_at"`
+		User      struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := c.get(endpoint+"/comments?per_page=100", &comments); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue comments: %w", err)
+	}
+	for _, comment := range comments {
+		issue.Comments = append(issue.Comments, IssueComment{Body: comment.Body, Author: comment.User.Login, CreatedAt: comment.CreatedAt})
+	}
+
+	// Pull requests mentioning the issue show up as cross-referenced timeline events
+	var timeline []struct {
+		Event  string `json:"event"`
+		Source struct {
+			Issue *struct {
+				Number      int       `json:"number"`
+				Title       string    `json:"title"`
+				State       string    `json:"state"`
+				HTMLURL     string    `json:"html_url"`
+				PullRequest *struct{} `json:"pull_request"`
+			} `json:"issue"`
+		} `json:"source"`
+	}
+	if err := c.get(endpoint+"/timeline?per_page=100", &timeline); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue timeline: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, event := range timeline {
+		source := event.Source.Issue
+		if event.Event != "cross-referenced" || source == nil || source.PullRequest == nil || seen[source.HTMLURL] {
+			continue
+		}
+		seen[source.HTMLURL] = true
+		issue.LinkedPRs = append(issue.LinkedPRs, LinkedPR{Number: source.Number, Title: source.Title, State: source.State, URL: source.HTMLURL})
+	}
+
+	return issue, nil
+}
+
+// get performs an authenticated GET request against the GitHub API
+func (c *GitHubClient) get(endpoint string, out interface{}) error {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return getJSON(c.client, req, "GitHub", out)
+}
+
+// GetIssue fetches an issue with its notes and related merge requests
+func (c *GitLabClient) GetIssue(repo string, number int) (*Issue, error) {
+	var data struct {
+		IID         int      `json:"iid"`
+		Title       string   `json:"title"`
+		Description string   `json:"description"`
+		State       string   `json:"state"`
+		WebURL      string   `json:"web_url"`
+		Labels      []string `json:"labels"`
+		Author      struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/issues/%d", c.baseURL, url.PathEscape(repo), number)
+	if err := c.get(endpoint, &data); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue: %w", err)
+	}
+
+	issue := &Issue{
+		Forge:  GitLab,
+		Repo:   repo,
+		Number: data.IID,
+		Title:  data.Title,
+		Body:   data.Description,
+		State:  data.State,
+		Author: data.Author.Username,
+		Labels: data.Labels,
+		URL:    data.WebURL,
+	}
+
+	var notes []struct {
+		Body      string `json:"body"`
+		CreatedAt string `json:"created_at"`
+		System    bool   `json:"system"`
+		Author    struct {
+			Username string `json:"username"`
+		} `json:"author"`
+	}
+	if err := c.get(endpoint+"/notes?sort=asc&order_by=created_at&per_page=100", &notes); err != nil {
+		return nil, fmt.Errorf("failed to fetch issue notes: %w", err)
+	}
+	for _, note := range notes {
+		// System notes are status changes like "changed the description"
+		if note.System {
+			continue
+		}
+		issue.Comments = append(issue.Comments, IssueComment{Body: note.Body, Author: note.Author.Username, CreatedAt: note.CreatedAt})
+	}
+
+	var mergeRequests []struct {
+		IID    int    `json:"iid"`
+		Title  string `json:"title"`
+		State  string `json:"state"`
+		WebURL string `json:"web_url"`
+	}
+	if err := c.get(endpoint+"/related_merge_requests", &mergeRequests); err != nil {
+		return nil, fmt.Errorf("failed to fetch related merge requests: %w", err)
+	}
+	for _, mr := range mergeRequests {
+		issue.LinkedPRs = append(issue.LinkedPRs, LinkedPR{Number: mr.IID, Title: mr.Title, State: mr.State, URL: mr.WebURL})
+	}
+
+	return issue, nil
+}
+
+// get performs an authenticated GET request against the GitLab API
+func (c *GitLabClient) get(endpoint string, out interface{}) error {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+	return getJSON(c.client, req, "GitLab", out)
+}
+
+// getJSON sends the request and decodes a successful JSON response into out
+func getJSON(client *http.Client, req *http.Request, name string, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s API error: %s - %s", name, resp.Status, string(body))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// FormatIssueDescription formats the issue information into a structured description
+func (i *Issue) FormatIssueDescription() string {
+	var builder strings.Builder
+
+	name := "GitHub"
+	if i.Forge == GitLab {
+		name = "GitLab"
+	}
+
+	builder.WriteString(fmt.Sprintf(`**%s Issue: %s - %s#%d**
+
+**Repository:** %s
+**State:** %s
+**Author:** %s
+**Labels:** %s
+**Issue URL:** %s
+`, name, i.Title, i.Repo, i.Number, i.Repo, i.State, i.Author, strings.Join(i.Labels, ", "), i.URL))
+
+	if len(i.LinkedPRs) > 0 {
+		builder.WriteString("**Linked Pull Requests:**\n")
+		for _, pull := range i.LinkedPRs {
+			builder.WriteString(fmt.Sprintf("- #%d %s (%s) %s\n", pull.Number, pull.Title, pull.State, pull.URL))
+		}
+	}
+
+	builder.WriteString(fmt.Sprintf("\n**Description:**\n%s", i.Body))
+
+	// Add comments if available
+	if len(i.Comments) > 0 {
+		builder.WriteString("\n\n**Comments:**\n")
+		for idx, comment := range i.Comments {
+			builder.WriteString("\n---\n")
+			builder.WriteString(fmt.Sprintf("**Comment %d** (by %s on %s):\n", idx+1, comment.Author, comment.CreatedAt))
+			builder.WriteString(comment.Body)
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,114 @@
+package forge
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseIssueRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		ref     string
+		repoURL string
+		want    IssueRef
+		wantErr bool
+	}{
+		{name: "short ref with HTTPS repo", ref: "#12", repoURL: "https://token@github.com/acme/app.git", want: IssueRef{Host: "github.com", Repo: "acme/app", Number: 12}},
+		{name: "short ref with SSH repo", ref: "#7", repoURL: "git@gitlab.com:group/sub/app.git", want: IssueRef{Host: "gitlab.com", Repo: "group/sub/app", Number: 7}},
+		{name: "repo ref uses current host", ref: "other/lib#3", repoURL: "https://gitlab.example.com/acme/app", want: IssueRef{Host: "gitlab.example.com", Repo: "other/lib", Number: 3}},
+		{name: "repo ref without repo URL", ref: "other/lib#3", want: IssueRef{Host: "github.com", Repo: "other/lib", Number: 3}},
+		{name: "GitHub URL", ref: "https://github.com/acme/app/issues/42", want: IssueRef{Host: "github.com", Repo: "acme/app", Number: 42}},
+		{name: "GitLab URL", ref: "https://gitlab.com/group/sub/app/-/issues/9", want: IssueRef{Host: "gitlab.com", Repo: "group/sub/app", Number: 9}},
+		{name: "short ref without repo URL", ref: "#1", wantErr: true},
+		{name: "pull request URL", ref: "https://github.com/acme/app/pull/4", wantErr: true},
+		{name: "garbage", ref: "issue-4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIssueRef(tt.ref, tt.repoURL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseIssueRef() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIssueRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// newFakeAPI serves canned JSON responses keyed by request path
+func newFakeAPI(t *testing.T, header, token string, responses map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(header) != token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		response, ok := responses[r.URL.EscapedPath()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitHubGetIssue(t *testing.T) {
+	server := newFakeAPI(t, "Authorization", "Bearer gh-token", map[string]string{
+		"/repos/acme/app/issues/42": `{"number": 42, "title": "Crash on start", "body": "Steps...", "state": "open",
+			"html_url": "https://github.com/acme/app/issues/42", "user": {"login": "ada"}, "labels": [{"name": "bug"}]}`,
+		"/repos/acme/app/issues/42/comments": `[{"body": "Same here", "created_at": "2024-05-01T10:00:00Z", "user": {"login": "bob"}}]`,
+		"/repos/acme/app/issues/42/timeline": `[
+			{"event": "labeled"},
+			{"event": "cross-referenced", "source": {"issue": {"number": 7, "title": "Other issue", "state": "open", "html_url": "https://github.com/acme/app/issues/7"}}},
+			{"event": "cross-referenced", "source": {"issue": {"number": 43, "title": "Fix crash", "state": "closed", "html_url": "https://github.com/acme/app/pull/43", "pull_request": {}}}}
+		]`,
+	})
+
+	issue, err := NewGitHubClient(server.URL, "gh-token").GetIssue("acme/app", 42)
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if len(issue.Comments) != 1 || len(issue.LinkedPRs) != 1 || issue.LinkedPRs[0].Number != 43 {
+		t.Errorf("issue = %+v", issue)
+	}
+
+	formatted := issue.FormatIssueDescription()
+	for _, want := range []string{"**GitHub Issue: Crash on start - acme/app#42**", "**Labels:** bug", "- #43 Fix crash (closed)", "**Comment 1** (by bob"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("formatted description missing %q:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestGitLabGetIssue(t *testing.T) {
+	server := newFakeAPI(t, "PRIVATE-TOKEN", "gl-token", map[string]string{
+		"/projects/group%2Fapp/issues/9": `{"iid": 9, "title": "Slow search", "description": "It is slow", "state": "opened",
+			"web_url": "https://gitlab.com/group/app/-/issues/9", "labels": ["performance"], "author": {"username": "ada"}}`,
+		"/projects/group%2Fapp/issues/9/notes": `[
+			{"body": "changed the description", "system": true, "author": {"username": "ada"}},
+			{"body": "Profiled it", "created_at": "2024-05-01T10:00:00Z", "author": {"username": "bob"}}
+		]`,
+		"/projects/group%2Fapp/issues/9/related_merge_requests": `[{"iid": 3, "title": "Add index", "state": "merged", "web_url": "https://gitlab.com/group/app/-/merge_requests/3"}]`,
+	})
+
+	issue, err := NewGitLabClient(server.URL, "gl-token").GetIssue("group/app", 9)
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Author != "bob" {
+		t.Errorf("comments = %+v, want system notes skipped", issue.Comments)
+	}
+	if len(issue.LinkedPRs) != 1 || issue.LinkedPRs[0].State != "merged" {
+		t.Errorf("linked merge requests = %+v", issue.LinkedPRs)
+	}
+	if !strings.Contains(issue.FormatIssueDescription(), "**GitLab Issue: Slow search - group/app#9**") {
+		t.Errorf("unexpected formatted description:\n%s", issue.FormatIssueDescription())
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/erkineren/pullpoet/internal/logger"
+
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -15,11 +17,18 @@ import (
 )
 
 // Client handles git operations
-type Client struct{}
+type Client struct {
+	log logger.Logger
+}
 
 // NewClient creates a new git client
 func NewClient() *Client {
-	return &Client{}
+	return &Client{log: logger.Stdout}
+}
+
+// SetLogger sets where progress messages are written
+func (c *Client) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
 }
 
 // GitInfo represents basic git repository information
@@ -184,6 +193,10 @@ type GitResult struct {
 	Diff          string
 	Commits       []CommitInfo
 	DefaultBranch string
+	// CodeOwners is the CODEOWNERS file of the target branch, empty if there is none
+	CodeOwners string
+	// History lists recent commits of the target branch with the files they changed
+	History []FileCommit
 }
 
 // GetDiff clones a repository and returns the diff between source and target branches
@@ -198,11 +211,11 @@ func (c *Client) GetDiff(repoURL, source, target string) (string, error) {
 	// Clean branch names (remove origin/ prefix if present)
 	sourceBranch := strings.TrimPrefix(source, "origin/")
 	targetBranch := strings.TrimPrefix(target, "origin/")
-	fmt.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
+	c.log.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
 
 	// Clone repository with optimization
-	fmt.Printf("   📁 Creating temporary directory: %s\n", tempDir)
-	fmt.Println("   🔄 Cloning repository (shallow clone for faster performance)...")
+	c.log.Printf("   📁 Creating temporary directory: %s\n", tempDir)
+	c.log.Printf("   🔄 Cloning repository (shallow clone for faster performance)...\n")
 	repo, err := git.PlainClone(tempDir, false, &git.CloneOptions{
 		URL:          repoURL,
 		Depth:        1,     // Shallow clone - only get latest commit
@@ -212,10 +225,10 @@ func (c *Client) GetDiff(repoURL, source, target string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to clone repository: %w", err)
 	}
-	fmt.Println("   ✅ Repository cloned successfully (optimized)")
+	c.log.Printf("   ✅ Repository cloned successfully (optimized)\n")
 
 	// Since shallow clone may not have all branches, fetch them specifically
-	fmt.Printf("   🔄 Fetching required branches ('%s' and '%s')...\n", sourceBranch, targetBranch)
+	c.log.Printf("   🔄 Fetching required branches ('%s' and '%s')...\n", sourceBranch, targetBranch)
 
 	// Get remote
 	remote, err := repo.Remote("origin")
@@ -226,8 +239,8 @@ func (c *Client) GetDiff(repoURL, source, target string) (string, error) {
 	// Fetch specific branches with shallow depth
 	err = remote.Fetch(&git.FetchOptions{
 		RefSpecs: []config.RefSpec{
-			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", targetBranch, targetBranch)),
-			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", sourceBranch, sourceBranch)),
+			remoteRefSpec(targetBranch),
+			remoteRefSpec(sourceBranch),
 		},
 		Depth: 50, // Get enough history to find common ancestor
 	})
@@ -237,10 +250,10 @@ func (c *Client) GetDiff(repoURL, source, target string) (string, error) {
 		}
 		return "", fmt.Errorf("failed to fetch branches: %w", err)
 	}
-	fmt.Println("   ✅ Required branches fetched successfully")
+	c.log.Printf("   ✅ Required branches fetched successfully\n")
 
 	// Get target branch commit
-	fmt.Printf("   🎯 Finding target branch '%s'...\n", targetBranch)
+	c.log.Printf("   🎯 Finding target branch '%s'...\n", targetBranch)
 	targetRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", targetBranch), true)
 	if err != nil {
 		return "", fmt.Errorf("failed to find target branch '%s': %w", targetBranch, err)
@@ -250,10 +263,10 @@ func (c *Client) GetDiff(repoURL, source, target string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get target commit: %w", err)
 	}
-	fmt.Printf("   ✅ Target branch found: %s\n", targetRef.Hash().String()[:8])
+	c.log.Printf("   ✅ Target branch found: %s\n", targetRef.Hash().String()[:8])
 
 	// Get source branch commit
-	fmt.Printf("   🎯 Finding source branch '%s'...\n", sourceBranch)
+	c.log.Printf("   🎯 Finding source branch '%s'...\n", sourceBranch)
 	sourceRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", sourceBranch), true)
 	if err != nil {
 		return "", fmt.Errorf("failed to find source branch '%s': %w", sourceBranch, err)
@@ -263,16 +276,16 @@ func (c *Client) GetDiff(repoURL, source, target string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to get source commit: %w", err)
 	}
-	fmt.Printf("   ✅ Source branch found: %s\n", sourceRef.Hash().String()[:8])
+	c.log.Printf("   ✅ Source branch found: %s\n", sourceRef.Hash().String()[:8])
 
 	// Get diff between commits
-	fmt.Println("   📊 Generating patch diff...")
+	c.log.Printf("   📊 Generating patch diff...\n")
 	patch, err := targetCommit.Patch(sourceCommit)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate patch: %w", err)
 	}
 
-	fmt.Printf("   ✅ Patch generated successfully\n")
+	c.log.Printf("   ✅ Patch generated successfully\n")
 	return patch.String(), nil
 }
 
@@ -349,11 +362,11 @@ func (c *Client) GetDiffWithCommits(repoURL, source, target string) (*GitResult,
 	// Clean branch names (remove origin/ prefix if present)
 	sourceBranch := strings.TrimPrefix(source, "origin/")
 	targetBranch := strings.TrimPrefix(target, "origin/")
-	fmt.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
+	c.log.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
 
 	// Clone repository with optimization
-	fmt.Printf("   📁 Creating temporary directory: %s\n", tempDir)
-	fmt.Println("   🔄 Cloning repository (shallow clone for faster performance)...")
+	c.log.Printf("   📁 Creating temporary directory: %s\n", tempDir)
+	c.log.Printf("   🔄 Cloning repository (shallow clone for faster performance)...\n")
 	repo, err := git.PlainClone(tempDir, false, &git.CloneOptions{
 		URL:          repoURL,
 		Depth:        50,    // Get more commits for commit history
@@ -363,10 +376,10 @@ func (c *Client) GetDiffWithCommits(repoURL, source, target string) (*GitResult,
 	if err != nil {
 		return nil, fmt.Errorf("failed to clone repository: %w", err)
 	}
-	fmt.Println("   ✅ Repository cloned successfully (optimized)")
+	c.log.Printf("   ✅ Repository cloned successfully (optimized)\n")
 
 	// Since shallow clone may not have all branches, fetch them specifically
-	fmt.Printf("   🔄 Fetching required branches ('%s' and '%s')...\n", sourceBranch, targetBranch)
+	c.log.Printf("   🔄 Fetching required branches ('%s' and '%s')...\n", sourceBranch, targetBranch)
 
 	// Get remote
 	remote, err := repo.Remote("origin")
@@ -377,23 +390,23 @@ func (c *Client) GetDiffWithCommits(repoURL, source, target string) (*GitResult,
 	// Fetch specific branches with shallow depth
 	err = remote.Fetch(&git.FetchOptions{
 		RefSpecs: []config.RefSpec{
-			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", targetBranch, targetBranch)),
-			config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", sourceBranch, sourceBranch)),
+			remoteRefSpec(targetBranch),
+			remoteRefSpec(sourceBranch),
 		},
 		Depth: 100, // Get more history to find commits between branches
 	})
 	if err != nil && err != git.NoErrAlreadyUpToDate {
 		return nil, fmt.Errorf("failed to fetch branches: %w", err)
 	}
-	fmt.Println("   ✅ Required branches fetched successfully")
+	c.log.Printf("   ✅ Required branches fetched successfully\n")
 
 	// Detect default branch
-	fmt.Println("   🔍 Detecting default branch...")
+	c.log.Printf("   🔍 Detecting default branch...\n")
 	defaultBranch := c.detectDefaultBranch(repo)
-	fmt.Printf("   ✅ Default branch detected: %s\n", defaultBranch)
+	c.log.Printf("   ✅ Default branch detected: %s\n", defaultBranch)
 
 	// Get target branch commit
-	fmt.Printf("   🎯 Finding target branch '%s'...\n", targetBranch)
+	c.log.Printf("   🎯 Finding target branch '%s'...\n", targetBranch)
 	targetRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", targetBranch), true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find target branch '%s': %w", targetBranch, err)
@@ -403,10 +416,10 @@ func (c *Client) GetDiffWithCommits(repoURL, source, target string) (*GitResult,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get target commit: %w", err)
 	}
-	fmt.Printf("   ✅ Target branch found: %s\n", targetRef.Hash().String()[:8])
+	c.log.Printf("   ✅ Target branch found: %s\n", targetRef.Hash().String()[:8])
 
 	// Get source branch commit
-	fmt.Printf("   🎯 Finding source branch '%s'...\n", sourceBranch)
+	c.log.Printf("   🎯 Finding source branch '%s'...\n", sourceBranch)
 	sourceRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", sourceBranch), true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find source branch '%s': %w", sourceBranch, err)
@@ -416,31 +429,36 @@ func (c *Client) GetDiffWithCommits(repoURL, source, target string) (*GitResult,
 	if err != nil {
 		return nil, fmt.Errorf("failed to get source commit: %w", err)
 	}
-	fmt.Printf("   ✅ Source branch found: %s\n", sourceRef.Hash().String()[:8])
+	c.log.Printf("   ✅ Source branch found: %s\n", sourceRef.Hash().String()[:8])
 
 	// Get diff between commits
-	fmt.Println("   📊 Generating patch diff...")
+	c.log.Printf("   📊 Generating patch diff...\n")
 	patch, err := targetCommit.Patch(sourceCommit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate patch: %w", err)
 	}
 
 	// Get commit information from source branch that are ahead of target
-	fmt.Println("   📝 Collecting commit information...")
+	c.log.Printf("   📝 Collecting commit information...\n")
 	commits, err := c.getCommitsBetweenBranches(repo, sourceRef.Hash(), targetRef.Hash())
 	if err != nil {
-		fmt.Printf("   ⚠️  Warning: Failed to get commit info: %v\n", err)
+		c.log.Printf("   ⚠️  Warning: Failed to get commit info: %v\n", err)
 		// Continue without commit info
 		commits = []CommitInfo{}
 	}
 
-	fmt.Printf("   ✅ Found %d commits in source branch ahead of target\n", len(commits))
-	fmt.Printf("   ✅ Git analysis completed successfully\n")
+	c.log.Printf("   ✅ Found %d commits in source branch ahead of target\n", len(commits))
+
+	c.log.Printf("   👥 Reading CODEOWNERS and file history...\n")
+	codeOwners, history := c.readOwnership(repo, targetCommit)
+	c.log.Printf("   ✅ Git analysis completed successfully\n")
 
 	return &GitResult{
 		Diff:          patch.String(),
 		Commits:       commits,
 		DefaultBranch: defaultBranch,
+		CodeOwners:    codeOwners,
+		History:       history,
 	}, nil
 }
 
@@ -506,3 +524,12 @@ func (c *Client) GetStagedDiff() (string, error) {
 	}
 	return string(output), nil
 }
+
+// remoteRefSpec fetches a branch, or a full ref such as refs/pull/7/head, into refs/remotes/origin
+func remoteRefSpec(name string) config.RefSpec {
+	ref := "refs/heads/" + name
+	if strings.HasPrefix(name, "refs/") {
+		ref = name
+	}
+	return config.RefSpec(fmt.Sprintf("+%s:refs/remotes/origin/%s", ref, name))
+}
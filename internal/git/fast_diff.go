@@ -6,14 +6,23 @@ import (
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 )
 
 // FastClient uses native git commands for maximum speed
-type FastClient struct{}
+type FastClient struct {
+	log logger.Logger
+}
 
 // NewFastClient creates a new fast git client that uses native git commands
 func NewFastClient() *FastClient {
-	return &FastClient{}
+	return &FastClient{log: logger.Stdout}
+}
+
+// SetLogger sets where progress messages are written
+func (c *FastClient) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
 }
 
 // detectDefaultBranchFast uses native git commands to detect the default branch
@@ -54,16 +63,16 @@ func (c *FastClient) GetDiffFast(repoURL, source, target string) (string, error)
 	}
 	defer os.RemoveAll(tempDir)
 
-	fmt.Printf("   📁 Creating temporary directory: %s\n", tempDir)
+	c.log.Printf("   📁 Creating temporary directory: %s\n", tempDir)
 
 	// Clean branch names (remove origin/ prefix if present)
 	sourceBranch := strings.TrimPrefix(source, "origin/")
 	targetBranch := strings.TrimPrefix(target, "origin/")
 
-	fmt.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
+	c.log.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
 
 	// Initialize git repository
-	fmt.Println("   ⚡ Initializing git repository...")
+	c.log.Printf("   ⚡ Initializing git repository...\n")
 	cmd := exec.Command("git", "init")
 	cmd.Dir = tempDir
 	if err := cmd.Run(); err != nil {
@@ -71,7 +80,7 @@ func (c *FastClient) GetDiffFast(repoURL, source, target string) (string, error)
 	}
 
 	// Add remote
-	fmt.Println("   🔗 Adding remote origin...")
+	c.log.Printf("   🔗 Adding remote origin...\n")
 	cmd = exec.Command("git", "remote", "add", "origin", repoURL)
 	cmd.Dir = tempDir
 	if err := cmd.Run(); err != nil {
@@ -79,7 +88,7 @@ func (c *FastClient) GetDiffFast(repoURL, source, target string) (string, error)
 	}
 
 	// Fetch only the specific branches with minimal depth
-	fmt.Printf("   🚀 Fast fetching branches '%s' and '%s' (depth: 50)...\n", sourceBranch, targetBranch)
+	c.log.Printf("   🚀 Fast fetching branches '%s' and '%s' (depth: 50)...\n", sourceBranch, targetBranch)
 	cmd = exec.Command("git", "fetch", "origin",
 		fmt.Sprintf("%s:%s", sourceBranch, sourceBranch),
 		fmt.Sprintf("%s:%s", targetBranch, targetBranch),
@@ -89,20 +98,20 @@ func (c *FastClient) GetDiffFast(repoURL, source, target string) (string, error)
 	// Get detailed error output
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Printf("   ❌ Fetch failed. Git output: %s\n", string(output))
+		c.log.Printf("   ❌ Fetch failed. Git output: %s\n", string(output))
 		return "", fmt.Errorf("failed to fetch branches: %w", err)
 	}
-	fmt.Println("   ✅ Branches fetched successfully")
+	c.log.Printf("   ✅ Branches fetched successfully\n")
 
 	// Generate diff using native git
-	fmt.Println("   📊 Generating diff using native git...")
+	c.log.Printf("   📊 Generating diff using native git...\n")
 	cmd = exec.Command("git", "diff", targetBranch, sourceBranch)
 	cmd.Dir = tempDir
 
 	diffOutput, err := cmd.Output()
 	if err != nil {
 		// Try alternative diff approach if direct diff fails
-		fmt.Println("   🔄 Trying alternative diff approach...")
+		c.log.Printf("   🔄 Trying alternative diff approach...\n")
 		cmd = exec.Command("git", "diff", fmt.Sprintf("origin/%s", targetBranch), fmt.Sprintf("origin/%s", sourceBranch))
 		cmd.Dir = tempDir
 		diffOutput, err = cmd.Output()
@@ -111,7 +120,7 @@ func (c *FastClient) GetDiffFast(repoURL, source, target string) (string, error)
 		}
 	}
 
-	fmt.Printf("   ✅ Diff generated successfully (%d characters)\n", len(diffOutput))
+	c.log.Printf("   ✅ Diff generated successfully (%d characters)\n", len(diffOutput))
 	return string(diffOutput), nil
 }
 
@@ -129,16 +138,16 @@ func (c *FastClient) GetDiffWithCommits(repoURL, source, target string) (*GitRes
 	}
 	defer os.RemoveAll(tempDir)
 
-	fmt.Printf("   📁 Creating temporary directory: %s\n", tempDir)
+	c.log.Printf("   📁 Creating temporary directory: %s\n", tempDir)
 
 	// Clean branch names (remove origin/ prefix if present)
 	sourceBranch := strings.TrimPrefix(source, "origin/")
 	targetBranch := strings.TrimPrefix(target, "origin/")
 
-	fmt.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
+	c.log.Printf("   🔧 Cleaned branch names: source='%s', target='%s'\n", sourceBranch, targetBranch)
 
 	// Initialize git repository
-	fmt.Println("   ⚡ Initializing git repository...")
+	c.log.Printf("   ⚡ Initializing git repository...\n")
 	cmd := exec.Command("git", "init")
 	cmd.Dir = tempDir
 	if err := cmd.Run(); err != nil {
@@ -146,7 +155,7 @@ func (c *FastClient) GetDiffWithCommits(repoURL, source, target string) (*GitRes
 	}
 
 	// Add remote
-	fmt.Println("   🔗 Adding remote origin...")
+	c.log.Printf("   🔗 Adding remote origin...\n")
 	cmd = exec.Command("git", "remote", "add", "origin", repoURL)
 	cmd.Dir = tempDir
 	if err := cmd.Run(); err != nil {
@@ -154,7 +163,7 @@ func (c *FastClient) GetDiffWithCommits(repoURL, source, target string) (*GitRes
 	}
 
 	// Fetch only the specific branches with more depth for commit history
-	fmt.Printf("   🚀 Fast fetching branches '%s' and '%s' (depth: 100)...\n", sourceBranch, targetBranch)
+	c.log.Printf("   🚀 Fast fetching branches '%s' and '%s' (depth: 100)...\n", sourceBranch, targetBranch)
 	cmd = exec.Command("git", "fetch", "origin",
 		fmt.Sprintf("%s:%s", sourceBranch, sourceBranch),
 		fmt.Sprintf("%s:%s", targetBranch, targetBranch),
@@ -164,20 +173,20 @@ func (c *FastClient) GetDiffWithCommits(repoURL, source, target string) (*GitRes
 	// Get detailed error output
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		fmt.Printf("   ❌ Fetch failed. Git output: %s\n", string(output))
+		c.log.Printf("   ❌ Fetch failed. Git output: %s\n", string(output))
 		return nil, fmt.Errorf("failed to fetch branches: %w", err)
 	}
-	fmt.Println("   ✅ Branches fetched successfully")
+	c.log.Printf("   ✅ Branches fetched successfully\n")
 
 	// Generate diff using native git
-	fmt.Println("   📊 Generating diff using native git...")
+	c.log.Printf("   📊 Generating diff using native git...\n")
 	cmd = exec.Command("git", "diff", targetBranch, sourceBranch)
 	cmd.Dir = tempDir
 
 	diffOutput, err := cmd.Output()
 	if err != nil {
 		// Try alternative diff approach if direct diff fails
-		fmt.Println("   🔄 Trying alternative diff approach...")
+		c.log.Printf("   🔄 Trying alternative diff approach...\n")
 		cmd = exec.Command("git", "diff", fmt.Sprintf("origin/%s", targetBranch), fmt.Sprintf("origin/%s", sourceBranch))
 		cmd.Dir = tempDir
 		diffOutput, err = cmd.Output()
@@ -186,29 +195,34 @@ func (c *FastClient) GetDiffWithCommits(repoURL, source, target string) (*GitRes
 		}
 	}
 
-	fmt.Printf("   ✅ Diff generated successfully (%d characters)\n", len(diffOutput))
+	c.log.Printf("   ✅ Diff generated successfully (%d characters)\n", len(diffOutput))
 
 	// Get commit information
-	fmt.Println("   📝 Collecting commit information...")
+	c.log.Printf("   📝 Collecting commit information...\n")
 	commits, err := c.getCommitsBetweenBranchesFast(tempDir, sourceBranch, targetBranch)
 	if err != nil {
-		fmt.Printf("   ⚠️  Warning: Failed to get commit info: %v\n", err)
+		c.log.Printf("   ⚠️  Warning: Failed to get commit info: %v\n", err)
 		// Continue without commit info
 		commits = []CommitInfo{}
 	}
 
-	fmt.Printf("   ✅ Found %d commits in source branch ahead of target\n", len(commits))
-	fmt.Printf("   ✅ Git analysis completed successfully\n")
+	c.log.Printf("   ✅ Found %d commits in source branch ahead of target\n", len(commits))
+	c.log.Printf("   ✅ Git analysis completed successfully\n")
 
 	// Detect default branch using git command
-	fmt.Println("   🔍 Detecting default branch...")
+	c.log.Printf("   🔍 Detecting default branch...\n")
 	defaultBranch := c.detectDefaultBranchFast(tempDir)
-	fmt.Printf("   ✅ Default branch detected: %s\n", defaultBranch)
+	c.log.Printf("   ✅ Default branch detected: %s\n", defaultBranch)
+
+	c.log.Printf("   👥 Reading CODEOWNERS and file history...\n")
+	codeOwners, history := c.readOwnershipFast(tempDir, targetBranch)
 
 	return &GitResult{
 		Diff:          string(diffOutput),
 		Commits:       commits,
 		DefaultBranch: defaultBranch,
+		CodeOwners:    codeOwners,
+		History:       history,
 	}, nil
 }
 
@@ -0,0 +1,144 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// FileCommit is a commit of the target branch and the files it changed
+type FileCommit struct {
+	Author string
+	Email  string
+	Date   time.Time
+	Files  []string
+}
+
+// codeOwnersPaths are the locations of the CODEOWNERS file, first match wins
+var codeOwnersPaths = []string{".github/CODEOWNERS", ".gitlab/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// historyLimit is the number of target branch commits scanned for file authorship
+const historyLimit = 100
+
+// readOwnership returns the CODEOWNERS file and the recent history of the target commit
+func (c *Client) readOwnership(repo *git.Repository, target *object.Commit) (string, []FileCommit) {
+	codeOwners := ""
+	for _, path := range codeOwnersPaths {
+		file, err := target.File(path)
+		if err != nil {
+			continue
+		}
+		if content, err := file.Contents(); err == nil {
+			codeOwners = content
+			break
+		}
+	}
+
+	commits, err := repo.Log(&git.LogOptions{From: target.Hash})
+	if err != nil {
+		c.log.Printf("   ⚠️  Warning: Failed to read file history: %v\n", err)
+		return codeOwners, nil
+	}
+
+	var history []FileCommit
+	scanned := 0
+	err = commits.ForEach(func(commit *object.Commit) error {
+		if scanned >= historyLimit {
+			return storer.ErrStop
+		}
+		scanned++
+		// Merge commits repeat the changes of the merged commits
+		if commit.NumParents() > 1 {
+			return nil
+		}
+
+		tree, err := commit.Tree()
+		if err != nil {
+			return storer.ErrStop
+		}
+		var parentTree *object.Tree
+		if commit.NumParents() == 1 {
+			// The parent is missing at the boundary of the shallow clone
+			parent, err := commit.Parent(0)
+			if err != nil {
+				return storer.ErrStop
+			}
+			if parentTree, err = parent.Tree(); err != nil {
+				return storer.ErrStop
+			}
+		}
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return storer.ErrStop
+		}
+
+		entry := FileCommit{Author: commit.Author.Name, Email: commit.Author.Email, Date: commit.Author.When}
+		for _, change := range changes {
+			name := change.To.Name
+			if name == "" {
+				name = change.From.Name
+			}
+			entry.Files = append(entry.Files, name)
+		}
+		history = append(history, entry)
+		return nil
+	})
+	if err != nil {
+		c.log.Printf("   ⚠️  Warning: Failed to read file history: %v\n", err)
+	}
+	return codeOwners, history
+}
+
+// readOwnershipFast returns the CODEOWNERS file and the recent history of the target branch
+func (c *FastClient) readOwnershipFast(tempDir, targetBranch string) (string, []FileCommit) {
+	codeOwners := ""
+	for _, path := range codeOwnersPaths {
+		cmd := exec.Command("git", "show", fmt.Sprintf("%s:%s", targetBranch, path))
+		cmd.Dir = tempDir
+		if output, err := cmd.Output(); err == nil {
+			codeOwners = string(output)
+			break
+		}
+	}
+
+	// Records start with \x1e, fields are separated by \x1f and followed by the changed files
+	cmd := exec.Command("git", "log", targetBranch, "--no-merges", "--name-only",
+		fmt.Sprintf("--max-count=%d", historyLimit), "--format=%x1e%an%x1f%ae%x1f%aI")
+	cmd.Dir = tempDir
+	output, err := cmd.Output()
+	if err != nil {
+		c.log.Printf("   ⚠️  Warning: Failed to read file history: %v\n", err)
+		return codeOwners, nil
+	}
+	return codeOwners, parseFileHistory(string(output))
+}
+
+// parseFileHistory parses the output of git log --name-only with the readOwnershipFast format
+func parseFileHistory(output string) []FileCommit {
+	var history []FileCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 3 {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[2])
+		if err != nil {
+			continue
+		}
+
+		entry := FileCommit{Author: fields[0], Email: fields[1], Date: date}
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				entry.Files = append(entry.Files, line)
+			}
+		}
+		history = append(history, entry)
+	}
+	return history
+}
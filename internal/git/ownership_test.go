@@ -0,0 +1,27 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseFileHistory(t *testing.T) {
+	output := "\x1eAlice\x1falice@example.com\x1f2024-05-01T10:00:00+02:00\n\napi/users.go\ndocs/api.md\n" +
+		"\x1eBob\x1fbob@example.com\x1f2024-04-01T09:00:00Z\n\nREADME.md\n" +
+		"\x1emalformed\n"
+
+	want := []FileCommit{
+		{Author: "Alice", Email: "alice@example.com", Date: time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC), Files: []string{"api/users.go", "docs/api.md"}},
+		{Author: "Bob", Email: "bob@example.com", Date: time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), Files: []string{"README.md"}},
+	}
+	got := parseFileHistory(output)
+	if len(got) != len(want) {
+		t.Fatalf("parseFileHistory() returned %d commits, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Author != want[i].Author || got[i].Email != want[i].Email || !got[i].Date.Equal(want[i].Date) || !reflect.DeepEqual(got[i].Files, want[i].Files) {
+			t.Errorf("commit %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
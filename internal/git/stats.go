@@ -0,0 +1,122 @@
+package git
+
+import (
+	"strings"
+)
+
+// FileDiff represents the patch of a single file within a unified diff
+type FileDiff struct {
+	Path      string
+	OldPath   string
+	Additions int
+	Deletions int
+	Binary    bool
+	Patch     string
+}
+
+// SplitDiff splits a unified diff (git diff / go-git patch output) into per-file patches
+func SplitDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var current *FileDiff
+	var patch strings.Builder
+
+	flush := func() {
+		if current != nil {
+			current.Patch = patch.String()
+			files = append(files, *current)
+		}
+		patch.Reset()
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	inHunk := false
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(trimmed, "diff --git ") {
+			flush()
+			oldPath, newPath := parseDiffGitHeader(trimmed)
+			current = &FileDiff{Path: newPath, OldPath: oldPath}
+			inHunk = false
+			patch.WriteString(line)
+			continue
+		}
+
+		// Content before the first file header is ignored
+		if current == nil {
+			continue
+		}
+		patch.WriteString(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "@@"):
+			inHunk = true
+		case !inHunk && strings.HasPrefix(trimmed, "--- "):
+			if p := stripDiffPrefix(strings.TrimPrefix(trimmed, "--- ")); p != "" {
+				current.OldPath = p
+			}
+		case !inHunk && strings.HasPrefix(trimmed, "+++ "):
+			if p := stripDiffPrefix(strings.TrimPrefix(trimmed, "+++ ")); p != "" {
+				current.Path = p
+			}
+		case !inHunk && strings.HasPrefix(trimmed, "Binary files "):
+			current.Binary = true
+		case inHunk && strings.HasPrefix(trimmed, "+"):
+			current.Additions++
+		case inHunk && strings.HasPrefix(trimmed, "-"):
+			current.Deletions++
+		}
+	}
+	flush()
+
+	return files
+}
+
+// FilterDiff returns the diff without the patches of the excluded paths
+func FilterDiff(diff string, excluded map[string]bool) string {
+	if len(excluded) == 0 {
+		return diff
+	}
+
+	var builder strings.Builder
+	for _, file := range SplitDiff(diff) {
+		if excluded[file.Path] {
+			continue
+		}
+		builder.WriteString(file.Patch)
+	}
+	return builder.String()
+}
+
+// parseDiffGitHeader extracts old and new paths from a "diff --git a/x b/y" line
+func parseDiffGitHeader(line string) (string, string) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	// Paths are separated by " b/"; this works for the common case of paths without " b/"
+	if idx := strings.Index(rest, " b/"); idx >= 0 {
+		return strings.TrimPrefix(rest[:idx], "a/"), rest[idx+3:]
+	}
+	parts := strings.Fields(rest)
+	if len(parts) == 2 {
+		return stripDiffPrefix(parts[0]), stripDiffPrefix(parts[1])
+	}
+	return rest, rest
+}
+
+// stripDiffPrefix removes the a/ or b/ prefix from a diff path, returning "" for /dev/null
+func stripDiffPrefix(path string) string {
+	path = strings.TrimSpace(path)
+	// git may append a tab followed by a timestamp
+	if idx := strings.Index(path, "\t"); idx >= 0 {
+		path = path[:idx]
+	}
+	if path == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
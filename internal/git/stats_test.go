@@ -0,0 +1,81 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/cmd/main.go b/cmd/main.go
+index 1111111..2222222 100644
+--- a/cmd/main.go
++++ b/cmd/main.go
+@@ -1,4 +1,5 @@
+ package main
+-import "fmt"
++import (
++	"fmt"
++)
+diff --git a/docs/old.md b/docs/old.md
+deleted file mode 100644
+index 3333333..0000000
+--- a/docs/old.md
++++ /dev/null
+@@ -1,2 +0,0 @@
+-# Old
+-text
+diff --git a/logo.png b/logo.png
+new file mode 100644
+index 0000000..4444444
+Binary files /dev/null and b/logo.png differ
+`
+
+func TestSplitDiff(t *testing.T) {
+	files := SplitDiff(sampleDiff)
+
+	tests := []struct {
+		path      string
+		additions int
+		deletions int
+		binary    bool
+	}{
+		{path: "cmd/main.go", additions: 3, deletions: 1},
+		{path: "docs/old.md", additions: 0, deletions: 2},
+		{path: "logo.png", binary: true},
+	}
+
+	if len(files) != len(tests) {
+		t.Fatalf("SplitDiff returned %d files, want %d", len(files), len(tests))
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			file := files[i]
+			if file.Path != tt.path {
+				t.Errorf("Path = %q, want %q", file.Path, tt.path)
+			}
+			if file.Additions != tt.additions || file.Deletions != tt.deletions {
+				t.Errorf("counts = +%d -%d, want +%d -%d", file.Additions, file.Deletions, tt.additions, tt.deletions)
+			}
+			if file.Binary != tt.binary {
+				t.Errorf("Binary = %v, want %v", file.Binary, tt.binary)
+			}
+			if !strings.HasPrefix(file.Patch, "diff --git a/"+tt.path) {
+				t.Errorf("Patch does not start with its header: %q", file.Patch)
+			}
+		})
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	filtered := FilterDiff(sampleDiff, map[string]bool{"docs/old.md": true})
+
+	if strings.Contains(filtered, "docs/old.md") {
+		t.Errorf("FilterDiff kept excluded file: %s", filtered)
+	}
+	if !strings.Contains(filtered, "cmd/main.go") || !strings.Contains(filtered, "logo.png") {
+		t.Errorf("FilterDiff dropped included files: %s", filtered)
+	}
+	if FilterDiff(sampleDiff, nil) != sampleDiff {
+		t.Errorf("FilterDiff with no exclusions should return the diff unchanged")
+	}
+}
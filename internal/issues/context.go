@@ -0,0 +1,366 @@
+package issues
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+)
+
+// Defaults for the issue context sent to the AI
+const (
+	// DefaultMaxComments is the number of newest comments kept per issue
+	DefaultMaxComments = 20
+	// DefaultContextShare is the share of the model's context window used for issues
+	DefaultContextShare = 0.25
+)
+
+// commentsHeading starts the comments section of every formatted issue
+const commentsHeading = "\n\n**Comments:**\n"
+
+// commentSeparator starts every comment in the comments section
+const commentSeparator = "\n---\n**Comment "
+
+// botPattern matches the names of bots and automation accounts
+var botPattern = regexp.MustCompile(`(?i)(\[bot\]$|\bbot\b|automation|^(github-actions|dependabot|renovate|jenkins|codecov|sonarcloud|gitlab-bot)\b)`)
+
+// ContextOptions control which comments of an issue are sent to the AI
+type ContextOptions struct {
+	// MaxComments keeps the newest comments and summarises the older ones, 0 keeps all
+	MaxComments int
+	// KeepBots keeps comments by bots and automation accounts
+	KeepBots bool
+	// BotAuthors are additional author names treated as bots
+	BotAuthors []string
+}
+
+// Comment is the tracker independent view of a comment used for filtering
+type Comment struct {
+	Author  string
+	Body    string
+	Created string
+}
+
+// contextFilter holds the context options of a tracker
+type contextFilter struct {
+	options ContextOptions
+}
+
+// SetContextOptions sets which comments are sent to the AI
+func (f *contextFilter) SetContextOptions(opts ContextOptions) {
+	f.options = opts
+}
+
+// isBot reports whether a comment author is a bot or automation account
+func (o ContextOptions) isBot(author string) bool {
+	for _, name := range o.BotAuthors {
+		if strings.EqualFold(strings.TrimSpace(name), author) {
+			return true
+		}
+	}
+	return botPattern.MatchString(author)
+}
+
+// commentSelection is the result of filtering the comments of an issue
+type commentSelection struct {
+	// kept are the indexes of the kept comments, oldest first
+	kept []int
+	// bodies are the kept comments whose quoted text was removed
+	bodies map[int]string
+	// summary lists the older comments that were not kept
+	summary string
+	// notes describe what was dropped
+	notes []string
+}
+
+// selectComments orders the comments by creation time, drops bot comments and
+// duplicated quotes, then keeps the newest comments and summarises the older ones
+func selectComments(comments []Comment, opts ContextOptions) commentSelection {
+	selection := commentSelection{bodies: make(map[int]string)}
+	var bots []string
+	botComments, duplicates, deduplicated := 0, 0, 0
+	var candidates []int
+	var seen []string
+	for _, i := range chronological(comments) {
+		comment := comments[i]
+		if !opts.KeepBots && opts.isBot(comment.Author) {
+			botComments++
+			if !contains(bots, comment.Author) {
+				bots = append(bots, comment.Author)
+			}
+			continue
+		}
+
+		original := strings.TrimSpace(comment.Body)
+		body, stripped := stripQuotes(comment.Body, seen)
+		if original != "" && (strings.TrimSpace(body) == "" || contains(seen, strings.TrimSpace(body))) {
+			duplicates++
+			continue
+		}
+		if stripped {
+			deduplicated++
+			selection.bodies[i] = body
+		}
+		seen = append(seen, original)
+		candidates = append(candidates, i)
+	}
+
+	if botComments > 0 {
+		selection.notes = append(selection.notes, fmt.Sprintf("dropped %d bot comment(s) by %s", botComments, strings.Join(bots, ", ")))
+	}
+	if duplicates > 0 {
+		selection.notes = append(selection.notes, fmt.Sprintf("dropped %d duplicate comment(s)", duplicates))
+	}
+	if deduplicated > 0 {
+		selection.notes = append(selection.notes, fmt.Sprintf("removed quoted text from %d comment(s)", deduplicated))
+	}
+
+	selection.kept = candidates
+	if opts.MaxComments > 0 && len(candidates) > opts.MaxComments {
+		older := candidates[:len(candidates)-opts.MaxComments]
+		selection.kept = candidates[len(candidates)-opts.MaxComments:]
+
+		lines := make([]string, 0, len(older))
+		for _, i := range older {
+			body, ok := selection.bodies[i]
+			if !ok {
+				body = comments[i].Body
+			}
+			lines = append(lines, fmt.Sprintf("- %s (%s): %s", comments[i].Author, comments[i].Created, firstLine(body, 120)))
+		}
+		selection.summary = fmt.Sprintf("**Earlier Comments (%d, summarised):**\n%s", len(older), strings.Join(lines, "\n"))
+		selection.notes = append(selection.notes, fmt.Sprintf("summarised %d older comment(s), kept the newest %d", len(older), opts.MaxComments))
+	}
+	return selection
+}
+
+// createdLayouts are the timestamp formats of the trackers' comments
+var createdLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05-0700", "2006-01-02"}
+
+// chronological returns the indexes of the comments, oldest first. Trackers
+// return comments in different orders, e.g. ClickUp newest first. If a creation
+// time cannot be parsed the tracker's order is kept.
+func chronological(comments []Comment) []int {
+	order := make([]int, len(comments))
+	for i := range order {
+		order[i] = i
+	}
+	created := make([]time.Time, len(comments))
+	for i, comment := range comments {
+		t, ok := parseCreated(comment.Created)
+		if !ok {
+			return order
+		}
+		created[i] = t
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return created[order[a]].Before(created[order[b]])
+	})
+	return order
+}
+
+// parseCreated parses the creation time of a comment: a timestamp or Unix milliseconds (ClickUp)
+func parseCreated(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(millis), true
+	}
+	for _, layout := range createdLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// filterComments applies the context options to the comments of a tracker.
+// It returns the kept comments, a summary of the older ones and notes on what was dropped.
+func filterComments[T any](comments []T, opts ContextOptions, view func(T) Comment, setBody func(*T, string)) ([]T, string, []string) {
+	views := make([]Comment, len(comments))
+	for i, comment := range comments {
+		views[i] = view(comment)
+	}
+
+	selection := selectComments(views, opts)
+	kept := make([]T, 0, len(selection.kept))
+	for _, i := range selection.kept {
+		comment := comments[i]
+		if body, ok := selection.bodies[i]; ok {
+			setBody(&comment, body)
+		}
+		kept = append(kept, comment)
+	}
+	return kept, selection.summary, selection.notes
+}
+
+// withSummary adds the summary of older comments in front of the comments section
+func withSummary(description, summary string) string {
+	if summary == "" {
+		return description
+	}
+	if strings.Contains(description, commentsHeading) {
+		return strings.Replace(description, commentsHeading, "\n\n"+summary+commentsHeading, 1)
+	}
+	return description + "\n\n" + summary
+}
+
+// stripQuotes removes quoted blocks ("> ...") whose lines all appear in earlier comments
+func stripQuotes(body string, earlier []string) (string, bool) {
+	if len(earlier) == 0 || !strings.Contains(body, ">") {
+		return body, false
+	}
+
+	lines := strings.Split(body, "\n")
+	var kept, block []string
+	stripped := false
+	flush := func() {
+		if len(block) > 0 && quotedEarlier(block, earlier) {
+			stripped = true
+		} else {
+			kept = append(kept, block...)
+		}
+		block = nil
+	}
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), ">") {
+			block = append(block, line)
+			continue
+		}
+		flush()
+		kept = append(kept, line)
+	}
+	flush()
+	return strings.TrimSpace(strings.Join(kept, "\n")), stripped
+}
+
+// quotedEarlier reports whether every line of a quoted block appears in an earlier comment
+func quotedEarlier(block []string, earlier []string) bool {
+	for _, line := range block {
+		text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "> "))
+		if text == "" {
+			continue
+		}
+		found := false
+		for _, body := range earlier {
+			if strings.Contains(body, text) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// firstLine returns the first non-empty line of text, cut to max characters
+func firstLine(text string, max int) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > max {
+			return string(runes[:max]) + "…"
+		}
+		return line
+	}
+	return ""
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenBudget returns the number of tokens available to the issue context:
+// a share of the model's context window
+func TokenBudget(cfg *config.Config) int {
+	window := cfg.ModelContextTokens
+	if window == 0 {
+		window = ai.ContextWindow(cfg.Model)
+	}
+	share := cfg.IssueContextShare
+	if share == 0 {
+		share = DefaultContextShare
+	}
+	return int(float64(window) * share)
+}
+
+// Trim cuts the issues to fit budget tokens in total. Issues that fit their
+// share are left untouched so the rest of the budget goes to the larger ones,
+// which lose their oldest comments first.
+func Trim(fetched []Issue, budget int) []Issue {
+	total := 0
+	for _, issue := range fetched {
+		total += ai.EstimateTokens(issue.Description)
+	}
+	if budget <= 0 || total <= budget {
+		return fetched
+	}
+
+	trimmed := append([]Issue(nil), fetched...)
+	order := make([]int, len(trimmed))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return len(trimmed[order[a]].Description) < len(trimmed[order[b]].Description)
+	})
+
+	remaining := budget
+	for n, i := range order {
+		allowance := remaining / (len(order) - n)
+		if ai.EstimateTokens(trimmed[i].Description) > allowance {
+			description, note := trimDescription(trimmed[i].Description, allowance)
+			trimmed[i].Description = description
+			trimmed[i].Trimmed = append(append([]string(nil), trimmed[i].Trimmed...), note)
+		}
+		remaining -= ai.EstimateTokens(trimmed[i].Description)
+	}
+	return trimmed
+}
+
+// trimDescription drops the oldest comments of a formatted issue until it fits
+// maxTokens, then cuts the text if it is still too long
+func trimDescription(description string, maxTokens int) (string, string) {
+	head, section, found := strings.Cut(description, commentsHeading)
+	if found {
+		blocks := strings.Split(section, commentSeparator)
+		// blocks[0] is the text before the first comment, e.g. nothing
+		comments := blocks[1:]
+		for dropped := 1; dropped <= len(comments); dropped++ {
+			rest := comments[dropped:]
+			candidate := head
+			if len(rest) > 0 {
+				candidate += commentsHeading + fmt.Sprintf("\n_%d older comment(s) omitted to fit the token budget_\n", dropped) +
+					blocks[0] + commentSeparator + strings.Join(rest, commentSeparator)
+			}
+			if ai.EstimateTokens(candidate) <= maxTokens {
+				return candidate, fmt.Sprintf("dropped %d comment(s) to fit the token budget", dropped)
+			}
+		}
+		description = head
+	}
+
+	if ai.EstimateTokens(description) <= maxTokens {
+		return description, "dropped all comments to fit the token budget"
+	}
+	limit := maxTokens * 4
+	runes := []rune(description)
+	if limit < len(runes) {
+		description = string(runes[:limit])
+	}
+	return description + "\n\n_[truncated to fit the token budget]_", "truncated the issue to fit the token budget"
+}
@@ -0,0 +1,182 @@
+package issues
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectComments(t *testing.T) {
+	comments := []Comment{
+		{Author: "alice", Body: "The login form should validate the email.", Created: "2024-01-01"},
+		{Author: "github-actions[bot]", Body: "Build passed", Created: "2024-01-02"},
+		{Author: "bob", Body: "> The login form should validate the email.\n\nAgreed, I will add it.", Created: "2024-01-03"},
+		{Author: "Deploy Notifier", Body: "Deployed to staging", Created: "2024-01-04"},
+		{Author: "carol", Body: "> Agreed, I will add it.", Created: "2024-01-05"},
+		{Author: "dave", Body: "Done in the latest commit.", Created: "2024-01-06"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        ContextOptions
+		wantKept    []int
+		wantBodies  map[int]string
+		wantSummary string
+		wantNotes   []string
+	}{
+		{
+			name:       "bots and duplicated quotes are dropped",
+			opts:       ContextOptions{BotAuthors: []string{"deploy notifier"}},
+			wantKept:   []int{0, 2, 5},
+			wantBodies: map[int]string{2: "Agreed, I will add it."},
+			wantNotes: []string{
+				"dropped 2 bot comment(s) by github-actions[bot], Deploy Notifier",
+				"dropped 1 duplicate comment(s)",
+				"removed quoted text from 1 comment(s)",
+			},
+		},
+		{
+			name:        "older comments are summarised",
+			opts:        ContextOptions{MaxComments: 1, BotAuthors: []string{"Deploy Notifier"}},
+			wantKept:    []int{5},
+			wantBodies:  map[int]string{2: "Agreed, I will add it."},
+			wantSummary: "**Earlier Comments (2, summarised):**\n- alice (2024-01-01): The login form should validate the email.\n- bob (2024-01-03): Agreed, I will add it.",
+			wantNotes: []string{
+				"dropped 2 bot comment(s) by github-actions[bot], Deploy Notifier",
+				"dropped 1 duplicate comment(s)",
+				"removed quoted text from 1 comment(s)",
+				"summarised 2 older comment(s), kept the newest 1",
+			},
+		},
+		{
+			name:       "bots can be kept",
+			opts:       ContextOptions{KeepBots: true},
+			wantKept:   []int{0, 1, 2, 3, 5},
+			wantBodies: map[int]string{2: "Agreed, I will add it."},
+			wantNotes:  []string{"dropped 1 duplicate comment(s)", "removed quoted text from 1 comment(s)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectComments(comments, tt.opts)
+			if !reflect.DeepEqual(got.kept, tt.wantKept) {
+				t.Errorf("kept = %v, want %v", got.kept, tt.wantKept)
+			}
+			if !reflect.DeepEqual(got.bodies, tt.wantBodies) {
+				t.Errorf("bodies = %q, want %q", got.bodies, tt.wantBodies)
+			}
+			if got.summary != tt.wantSummary {
+				t.Errorf("summary = %q, want %q", got.summary, tt.wantSummary)
+			}
+			if !reflect.DeepEqual(got.notes, tt.wantNotes) {
+				t.Errorf("notes = %q, want %q", got.notes, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestSelectCommentsOrdersByCreation(t *testing.T) {
+	tests := []struct {
+		name        string
+		comments    []Comment
+		wantKept    []int
+		wantSummary string
+	}{
+		{
+			name: "newest first in Unix milliseconds",
+			comments: []Comment{
+				{Author: "carol", Body: "Third", Created: "1704412800000"},
+				{Author: "bob", Body: "Second", Created: "1704326400000"},
+				{Author: "alice", Body: "First", Created: "1704240000000"},
+			},
+			wantKept:    []int{1, 0},
+			wantSummary: "**Earlier Comments (1, summarised):**\n- alice (1704240000000): First",
+		},
+		{
+			name: "mixed order in Jira timestamps",
+			comments: []Comment{
+				{Author: "bob", Body: "Second", Created: "2024-01-02T10:00:00.000+0000"},
+				{Author: "carol", Body: "Third", Created: "2024-01-03T10:00:00.000+0000"},
+				{Author: "alice", Body: "First", Created: "2024-01-01T10:00:00.000+0000"},
+			},
+			wantKept:    []int{0, 1},
+			wantSummary: "**Earlier Comments (1, summarised):**\n- alice (2024-01-01T10:00:00.000+0000): First",
+		},
+		{
+			name: "unknown format keeps the tracker order",
+			comments: []Comment{
+				{Author: "alice", Body: "First", Created: "yesterday"},
+				{Author: "bob", Body: "Second", Created: "2024-01-02T10:00:00Z"},
+				{Author: "carol", Body: "Third", Created: "2024-01-01T10:00:00Z"},
+			},
+			wantKept:    []int{1, 2},
+			wantSummary: "**Earlier Comments (1, summarised):**\n- alice (yesterday): First",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectComments(tt.comments, ContextOptions{MaxComments: 2})
+			if !reflect.DeepEqual(got.kept, tt.wantKept) {
+				t.Errorf("kept = %v, want %v", got.kept, tt.wantKept)
+			}
+			if got.summary != tt.wantSummary {
+				t.Errorf("summary = %q, want %q", got.summary, tt.wantSummary)
+			}
+		})
+	}
+}
+
+func TestWithSummary(t *testing.T) {
+	description := "**Description:**\nText\n\n**Comments:**\n\n---\n**Comment 1** (by bob on 2024-01-03):\nHi\n"
+	want := "**Description:**\nText\n\n**Earlier Comments (1, summarised):**\n- alice\n\n**Comments:**\n\n---\n**Comment 1** (by bob on 2024-01-03):\nHi\n"
+	if got := withSummary(description, "**Earlier Comments (1, summarised):**\n- alice"); got != want {
+		t.Errorf("withSummary() = %q, want %q", got, want)
+	}
+}
+
+// formattedIssue formats an issue with the given number of comments like the trackers do
+func formattedIssue(id string, comments int) Issue {
+	var builder strings.Builder
+	builder.WriteString("**Description:**\n" + strings.Repeat("word ", 20))
+	if comments > 0 {
+		builder.WriteString("\n\n**Comments:**\n")
+		for i := 1; i <= comments; i++ {
+			builder.WriteString(fmt.Sprintf("\n---\n**Comment %d** (by alice on 2024-01-0%d):\n%s\n", i, i, strings.Repeat("text ", 40)))
+		}
+	}
+	return Issue{ID: id, Description: builder.String()}
+}
+
+func TestTrim(t *testing.T) {
+	small := formattedIssue("SMALL-1", 0)
+	large := formattedIssue("LARGE-1", 5)
+
+	if got := Trim([]Issue{small, large}, 10000); !reflect.DeepEqual(got, []Issue{small, large}) {
+		t.Error("Trim() changed issues that fit the budget")
+	}
+
+	got := Trim([]Issue{small, large}, 200)
+	if got[0].Description != small.Description || got[0].Trimmed != nil {
+		t.Errorf("Trim() changed the small issue: %+v", got[0])
+	}
+	if tokens := (len(got[1].Description) + 3) / 4; tokens+(len(small.Description)+3)/4 > 200 {
+		t.Errorf("Trim() left %d tokens in the large issue", tokens)
+	}
+	if !strings.Contains(got[1].Description, "**Comment 5**") || strings.Contains(got[1].Description, "**Comment 1**") {
+		t.Errorf("Trim() should drop the oldest comments first:\n%s", got[1].Description)
+	}
+	if !reflect.DeepEqual(got[1].Trimmed, []string{"dropped 3 comment(s) to fit the token budget"}) {
+		t.Errorf("Trimmed = %q", got[1].Trimmed)
+	}
+	if large.Trimmed != nil {
+		t.Error("Trim() modified its input")
+	}
+
+	got = Trim([]Issue{large}, 20)
+	if !strings.HasSuffix(got[0].Description, "_[truncated to fit the token budget]_") || strings.Contains(got[0].Description, "**Comments:**") {
+		t.Errorf("Trim() with a tiny budget = %q", got[0].Description)
+	}
+}
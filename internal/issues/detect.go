@@ -0,0 +1,100 @@
+package issues
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/erkineren/pullpoet/internal/git"
+)
+
+// DefaultDetectPattern matches Jira/Linear style keys like HIP-1234
+const DefaultDetectPattern = `\b([A-Z][A-Z0-9]+-\d+)\b`
+
+// standardPrefixes look like issue projects but name encodings, hashes and
+// standards, e.g. UTF-8, SHA-256 or ISO-8601. Keys with them are ignored
+// unless the project is configured explicitly.
+var standardPrefixes = map[string]bool{
+	"AES": true, "BASE": true, "CRC": true, "CVE": true, "CWE": true, "ECMA": true,
+	"GPT": true, "HTTP": true, "IEEE": true, "IPV": true, "ISO": true,
+	"PEP": true, "RFC": true, "RSA": true, "SHA": true, "TLS": true, "UCS": true,
+	"UTF": true, "WCAG": true,
+}
+
+// Detection is an issue key found in the branch name or a commit message
+type Detection struct {
+	Key string
+	// Source describes where the key was found, e.g. "branch" or "commit a1b2c3d"
+	Source string
+}
+
+// Detector extracts issue keys from branch names and commit messages
+type Detector struct {
+	patterns []*regexp.Regexp
+	projects map[string]bool
+}
+
+// NewDetector compiles the patterns; the first capture group is the key, or the
+// whole match if there is none. Keys are only kept if their project (the part
+// before the first "-") is in projects. Without projects any key is kept except
+// standard names like UTF-8 or SHA-256.
+func NewDetector(patterns, projects []string) (*Detector, error) {
+	if len(patterns) == 0 {
+		patterns = []string{DefaultDetectPattern}
+	}
+
+	detector := &Detector{projects: make(map[string]bool)}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issue detection pattern %q: %w", pattern, err)
+		}
+		detector.patterns = append(detector.patterns, re)
+	}
+	for _, project := range projects {
+		detector.projects[strings.ToUpper(project)] = true
+	}
+	return detector, nil
+}
+
+// Detect returns the unique keys found in the branch name and commit messages, in order
+func (d *Detector) Detect(branch string, commits []git.CommitInfo) []Detection {
+	var detections []Detection
+	seen := make(map[string]bool)
+
+	add := func(text, source string) {
+		for _, re := range d.patterns {
+			for _, match := range re.FindAllStringSubmatch(text, -1) {
+				key := match[0]
+				if len(match) > 1 && match[1] != "" {
+					key = match[1]
+				}
+				if seen[key] || !d.allowed(key) {
+					continue
+				}
+				seen[key] = true
+				detections = append(detections, Detection{Key: key, Source: source})
+			}
+		}
+	}
+
+	add(branch, "branch")
+	for _, commit := range commits {
+		hash := commit.ShortHash
+		if hash == "" && len(commit.Hash) >= 7 {
+			hash = commit.Hash[:7]
+		}
+		add(commit.Message, "commit "+hash)
+	}
+	return detections
+}
+
+// allowed reports whether the key belongs to an allowed project
+func (d *Detector) allowed(key string) bool {
+	project, _, _ := strings.Cut(key, "-")
+	project = strings.ToUpper(project)
+	if len(d.projects) == 0 {
+		return !standardPrefixes[project]
+	}
+	return d.projects[project]
+}
@@ -0,0 +1,297 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/cache"
+	"github.com/erkineren/pullpoet/internal/forge"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/ratelimit"
+)
+
+// Issue is an issue or task in a tracker independent format
+type Issue struct {
+	Tracker  string `json:"tracker"`
+	ID       string `json:"key"`
+	Title    string `json:"title"`
+	Status   string `json:"status"`
+	URL      string `json:"url"`
+	Comments int    `json:"comments"`
+	// Description is the formatted issue as it is sent to the AI
+	Description string `json:"description"`
+	// Trimmed describes the comments and text left out of the description
+	Trimmed []string `json:"trimmed,omitempty"`
+}
+
+// Tracker fetches issues from an issue tracker. Issues are fetched one at a
+// time: the Registry fetches a batch concurrently under a shared rate limit and
+// reports failures per reference, so trackers need not implement either.
+type Tracker interface {
+	Name() string
+	Fetch(id string) (Issue, error)
+}
+
+// Defaults for fetching issues
+const (
+	// DefaultConcurrency is the number of issues fetched at the same time
+	DefaultConcurrency = 4
+	// DefaultRequestsPerSecond is the request rate allowed per tracker host
+	DefaultRequestsPerSecond = 5
+)
+
+// FetchError is a failure to fetch a single issue
+type FetchError struct {
+	Ref Ref
+	Err error
+}
+
+// Error returns the reference with the failure
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Ref, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// Ref is an issue ID on a specific tracker
+type Ref struct {
+	Tracker string
+	ID      string
+}
+
+// String returns the reference in "tracker:id" form
+func (r Ref) String() string {
+	return r.Tracker + ":" + r.ID
+}
+
+// prefixPattern matches "tracker:" prefixes; URL schemes are handled separately
+var prefixPattern = regexp.MustCompile(`^([a-z]+):(.+)$`)
+
+// ParseRefs parses comma-separated references like "jira:HIP-12,clickup:abc123".
+// References without a tracker prefix use defaultTracker.
+func ParseRefs(value, defaultTracker string) ([]Ref, error) {
+	var refs []Ref
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		ref := Ref{Tracker: defaultTracker, ID: part}
+		if match := prefixPattern.FindStringSubmatch(part); match != nil && match[1] != "http" && match[1] != "https" {
+			ref = Ref{Tracker: match[1], ID: strings.TrimSpace(match[2])}
+		}
+		if ref.Tracker == "" {
+			return nil, fmt.Errorf("issue %q has no tracker prefix (e.g. jira:%s)", part, part)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// Registry holds the configured issue trackers
+type Registry struct {
+	trackers    map[string]Tracker
+	log         logger.Logger
+	concurrency int
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry(log logger.Logger) *Registry {
+	return &Registry{
+		trackers:    make(map[string]Tracker),
+		log:         logger.OrDefault(log),
+		concurrency: DefaultConcurrency,
+	}
+}
+
+// SetConcurrency sets the number of issues fetched at the same time
+func (r *Registry) SetConcurrency(n int) {
+	if n > 0 {
+		r.concurrency = n
+	}
+}
+
+// NewRegistryFromConfig registers every tracker that has credentials in cfg.
+// GitHub and GitLab issues are always available, public issues need no token.
+// All trackers share a rate limited transport that also caches responses if
+// cfg.IssueCacheTTL is set.
+func NewRegistryFromConfig(cfg *config.Config, log logger.Logger) *Registry {
+	registry := NewRegistry(log)
+	registry.SetConcurrency(cfg.IssueFetchConcurrency)
+	transport := newTransport(cfg)
+
+	// Jira Server/Data Center personal access tokens need no username
+	if cfg.JiraBaseURL != "" && cfg.JiraAPIToken != "" {
+		if client, err := NewJiraClient(cfg, registry.log); err == nil {
+			client.SetTransport(transport)
+			registry.Register(NewJira(client, registry.log))
+		}
+	}
+	if cfg.ClickUpPAT != "" {
+		client := NewClickUpClient(cfg, registry.log)
+		client.SetTransport(transport)
+		registry.Register(NewClickUp(client, registry.log))
+	}
+	if cfg.LinearAPIKey != "" {
+		linearTracker := NewLinear(cfg.LinearAPIKey, registry.log)
+		linearTracker.client.SetTransport(transport)
+		registry.Register(linearTracker)
+	}
+	forgeConfig := ForgeConfig{
+		RepoURL:      cfg.Repo,
+		GitHubToken:  cfg.GitHubToken,
+		GitHubAPIURL: cfg.GitHubAPIURL,
+		GitLabToken:  cfg.GitLabToken,
+		GitLabAPIURL: cfg.GitLabAPIURL,
+		Transport:    transport,
+	}
+	registry.Register(NewForge(forge.GitHub, forgeConfig, registry.log))
+	registry.Register(NewForge(forge.GitLab, forgeConfig, registry.log))
+	registry.SetContextOptions(ContextOptions{
+		MaxComments: cfg.IssueMaxComments,
+		KeepBots:    cfg.IssueKeepBots,
+		BotAuthors:  cfg.IssueBotAuthors,
+	})
+	return registry
+}
+
+// newTransport returns the HTTP transport shared by the trackers: rate limited
+// per host, with an on-disk response cache in front if enabled
+func newTransport(cfg *config.Config) http.RoundTripper {
+	perSecond := cfg.IssueRequestsPerSecond
+	if perSecond == 0 {
+		perSecond = DefaultRequestsPerSecond
+	}
+	var transport http.RoundTripper = &ratelimit.Transport{Limiter: ratelimit.New(perSecond)}
+	if cfg.IssueCacheTTL > 0 {
+		transport = &cache.Transport{Store: cache.New(cache.DefaultDir("issues"), cfg.IssueCacheTTL), Base: transport}
+	}
+	return transport
+}
+
+// SetContextOptions sets which comments the registered trackers send to the AI
+func (r *Registry) SetContextOptions(opts ContextOptions) {
+	for _, tracker := range r.trackers {
+		if filter, ok := tracker.(interface{ SetContextOptions(ContextOptions) }); ok {
+			filter.SetContextOptions(opts)
+		}
+	}
+}
+
+// Register adds a tracker, replacing any tracker with the same name
+func (r *Registry) Register(tracker Tracker) {
+	r.trackers[tracker.Name()] = tracker
+}
+
+// Get returns the tracker with the given name
+func (r *Registry) Get(name string) (Tracker, bool) {
+	tracker, ok := r.trackers[name]
+	return tracker, ok
+}
+
+// Names returns the names of the registered trackers in alphabetical order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.trackers))
+	for name := range r.trackers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fetch fetches the referenced issues concurrently. Issues are returned grouped
+// by tracker in order of first appearance. References that fail are returned as
+// FetchErrors instead of aborting; the error is only set for unknown trackers.
+func (r *Registry) Fetch(refs []Ref) ([]Issue, []*FetchError, error) {
+	var order []string
+	grouped := make(map[string][]Ref)
+	seen := make(map[Ref]bool)
+	for _, ref := range refs {
+		if _, ok := r.trackers[ref.Tracker]; !ok {
+			return nil, nil, fmt.Errorf("issue tracker %q is not configured (available: %s)", ref.Tracker, strings.Join(r.Names(), ", "))
+		}
+		if seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		if _, ok := grouped[ref.Tracker]; !ok {
+			order = append(order, ref.Tracker)
+		}
+		grouped[ref.Tracker] = append(grouped[ref.Tracker], ref)
+	}
+
+	var ordered []Ref
+	for _, name := range order {
+		r.log.Printf("📋 Fetching %d issue(s) from %s...\n", len(grouped[name]), name)
+		ordered = append(ordered, grouped[name]...)
+	}
+
+	results := make([]Issue, len(ordered))
+	errs := make([]error, len(ordered))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, r.concurrency)
+	for i, ref := range ordered {
+		wg.Add(1)
+		go func(i int, ref Ref) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i], errs[i] = r.trackers[ref.Tracker].Fetch(ref.ID)
+		}(i, ref)
+	}
+	wg.Wait()
+
+	var fetched []Issue
+	var failures []*FetchError
+	for i, ref := range ordered {
+		if errs[i] != nil {
+			failures = append(failures, &FetchError{Ref: ref, Err: errs[i]})
+			continue
+		}
+		fetched = append(fetched, results[i])
+	}
+	return fetched, failures, nil
+}
+
+// Combine joins the issue descriptions into a single context for the prompt
+func Combine(issues []Issue) string {
+	if len(issues) == 0 {
+		return ""
+	}
+	if len(issues) == 1 {
+		return issues[0].Description
+	}
+
+	var combined strings.Builder
+	combined.WriteString(fmt.Sprintf("**Multiple Issues (%d issues)**\n\n", len(issues)))
+	combined.WriteString(strings.Repeat("=", 80) + "\n\n")
+
+	for i, issue := range issues {
+		combined.WriteString(fmt.Sprintf("### Issue %d of %d\n\n", i+1, len(issues)))
+		combined.WriteString(issue.Description)
+		if i < len(issues)-1 {
+			combined.WriteString("\n\n" + strings.Repeat("-", 80) + "\n\n")
+		}
+	}
+
+	return combined.String()
+}
+
+// DefaultTracker returns the tracker used for references without a prefix:
+// the forge hosting the repository, GitHub if it cannot be determined
+func DefaultTracker(repoURL string) string {
+	host, _, err := forge.ParseRepoURL(repoURL)
+	if err != nil {
+		return forge.GitHub
+	}
+	return forge.DetectForge(host)
+}
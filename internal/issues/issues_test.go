@@ -0,0 +1,162 @@
+package issues
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+func TestParseRefs(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          string
+		defaultTracker string
+		want           []Ref
+		wantErr        bool
+	}{
+		{name: "empty", value: " , ", want: nil},
+		{
+			name:  "prefixed references",
+			value: "jira:HIP-12, clickup:abc123",
+			want:  []Ref{{Tracker: "jira", ID: "HIP-12"}, {Tracker: "clickup", ID: "abc123"}},
+		},
+		{
+			name:           "unprefixed references use the default tracker",
+			value:          "#12,acme/app#3,https://github.com/acme/app/issues/4",
+			defaultTracker: "github",
+			want: []Ref{
+				{Tracker: "github", ID: "#12"},
+				{Tracker: "github", ID: "acme/app#3"},
+				{Tracker: "github", ID: "https://github.com/acme/app/issues/4"},
+			},
+		},
+		{name: "prefixed URL", value: "gitlab:https://gitlab.com/g/p/-/issues/1", want: []Ref{{Tracker: "gitlab", ID: "https://gitlab.com/g/p/-/issues/1"}}},
+		{name: "missing prefix without default", value: "HIP-12", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRefs(tt.value, tt.defaultTracker)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRefs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRefs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeTracker returns an issue for every ID except the failing ones
+type fakeTracker struct {
+	name    string
+	failing map[string]bool
+}
+
+func (f *fakeTracker) Name() string { return f.name }
+
+func (f *fakeTracker) Fetch(id string) (Issue, error) {
+	if f.failing[id] {
+		return Issue{}, fmt.Errorf("not found")
+	}
+	return Issue{Tracker: f.name, ID: id, Description: fmt.Sprintf("%s issue %s", f.name, id)}, nil
+}
+
+func TestRegistryFetch(t *testing.T) {
+	registry := NewRegistry(logger.Discard)
+	registry.Register(&fakeTracker{name: "jira"})
+	registry.Register(&fakeTracker{name: "clickup", failing: map[string]bool{"x9": true}})
+	registry.SetConcurrency(2)
+
+	fetched, failures, err := registry.Fetch([]Ref{{"clickup", "a1"}, {"jira", "HIP-1"}, {"clickup", "x9"}, {"clickup", "b2"}, {"jira", "HIP-1"}})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+
+	// Grouped by tracker in order of first appearance, duplicates fetched once
+	var ids []string
+	for _, issue := range fetched {
+		ids = append(ids, issue.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a1", "b2", "HIP-1"}) {
+		t.Errorf("fetched = %v", ids)
+	}
+
+	// Failures are reported per ID instead of aborting
+	if len(failures) != 1 || failures[0].Ref != (Ref{"clickup", "x9"}) || failures[0].Error() != "clickup:x9: not found" {
+		t.Errorf("failures = %v", failures)
+	}
+
+	combined := Combine(fetched)
+	for _, want := range []string{"**Multiple Issues (3 issues)**", "### Issue 3 of 3\n\njira issue HIP-1"} {
+		if !strings.Contains(combined, want) {
+			t.Errorf("Combine() missing %q:\n%s", want, combined)
+		}
+	}
+
+	if _, _, err := registry.Fetch([]Ref{{"linear", "ENG-1"}}); err == nil || !strings.Contains(err.Error(), "available: clickup, jira") {
+		t.Errorf("Fetch() with unknown tracker error = %v", err)
+	}
+}
+
+func TestCombineSingleIssue(t *testing.T) {
+	if got := Combine([]Issue{{Description: "only"}}); got != "only" {
+		t.Errorf("Combine() = %q, want the description unchanged", got)
+	}
+}
+
+func TestDetector(t *testing.T) {
+	commits := []git.CommitInfo{
+		{ShortHash: "a1b2c3d", Message: "Add login form\n\nRefs: HIP-1250"},
+		{ShortHash: "e4f5a6b", Message: "Fix HIP-1234 typo, switch to UTF-8"},
+		{ShortHash: "c7d8e9f", Message: "Hash with SHA-256, format dates as ISO-8601 (OPS-7)"},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		projects []string
+		want     []Detection
+		wantErr  bool
+	}{
+		{
+			name: "default pattern",
+			want: []Detection{{Key: "HIP-1234", Source: "branch"}, {Key: "HIP-1250", Source: "commit a1b2c3d"}, {Key: "OPS-7", Source: "commit c7d8e9f"}},
+		},
+		{
+			name:     "project allow-list",
+			projects: []string{"hip"},
+			want:     []Detection{{Key: "HIP-1234", Source: "branch"}, {Key: "HIP-1250", Source: "commit a1b2c3d"}},
+		},
+		{
+			name:     "configured projects are kept even if they look like standards",
+			projects: []string{"SHA"},
+			want:     []Detection{{Key: "SHA-256", Source: "commit c7d8e9f"}},
+		},
+		{
+			name:     "custom pattern with capture group",
+			patterns: []string{`Refs: ([A-Z]+-\d+)`},
+			want:     []Detection{{Key: "HIP-1250", Source: "commit a1b2c3d"}},
+		},
+		{name: "invalid pattern", patterns: []string{"("}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := NewDetector(tt.patterns, tt.projects)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDetector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := detector.Detect("feature/HIP-1234-add-login", commits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/clickup"
+	"github.com/erkineren/pullpoet/internal/forge"
+	"github.com/erkineren/pullpoet/internal/jira"
+	"github.com/erkineren/pullpoet/internal/linear"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+// Jira fetches issues from Jira
+type Jira struct {
+	contextFilter
+	client *jira.Client
+	log    logger.Logger
+}
+
+// NewJira creates a Jira tracker
+func NewJira(client *jira.Client, log logger.Logger) *Jira {
+	return &Jira{client: client, log: logger.OrDefault(log)}
+}
+
+// NewJiraClient creates a Jira client from the Jira settings in cfg
+func NewJiraClient(cfg *config.Config, log logger.Logger) (*jira.Client, error) {
+	client := jira.NewClient(cfg.JiraBaseURL, cfg.JiraUsername, cfg.JiraAPIToken)
+	if err := client.SetDeployment(cfg.JiraDeployment); err != nil {
+		return nil, err
+	}
+	client.SetCustomFields(cfg.JiraCustomFields)
+	client.SetLogger(log)
+	return client, nil
+}
+
+// Name returns "jira"
+func (t *Jira) Name() string {
+	return "jira"
+}
+
+// Fetch fetches a Jira issue by key
+func (t *Jira) Fetch(key string) (Issue, error) {
+	issue, err := t.client.GetIssue(key)
+	if err != nil {
+		return Issue{}, fmt.Errorf("failed to fetch Jira issue %s: %w", key, err)
+	}
+	logFetched(t.log, issue.Key, issue.Summary, len(issue.Comments))
+
+	total := len(issue.Comments)
+	comments, summary, notes := filterComments(issue.Comments, t.options, func(c jira.Comment) Comment {
+		return Comment{Author: c.Author.DisplayName, Body: jira.ToMarkdown(c.Body), Created: c.Created}
+	}, func(c *jira.Comment, body string) {
+		c.Body = body
+	})
+	issue.Comments = comments
+
+	return Issue{
+		Tracker:     t.Name(),
+		ID:          issue.Key,
+		Title:       issue.Summary,
+		Status:      issue.Status,
+		URL:         issue.URL,
+		Comments:    total,
+		Description: withSummary(issue.FormatIssueDescription(), summary),
+		Trimmed:     notes,
+	}, nil
+}
+
+// ClickUp fetches tasks from ClickUp
+type ClickUp struct {
+	contextFilter
+	client *clickup.Client
+	log    logger.Logger
+}
+
+// NewClickUp creates a ClickUp tracker
+func NewClickUp(client *clickup.Client, log logger.Logger) *ClickUp {
+	return &ClickUp{client: client, log: logger.OrDefault(log)}
+}
+
+// NewClickUpClient creates a ClickUp client from the ClickUp settings in cfg
+func NewClickUpClient(cfg *config.Config, log logger.Logger) *clickup.Client {
+	client := clickup.NewClient(cfg.ClickUpPAT)
+	client.SetBaseURL(cfg.ClickUpBaseURL)
+	client.SetCustomFields(cfg.ClickUpCustomFields)
+	client.SetLogger(log)
+	return client
+}
+
+// Name returns "clickup"
+func (t *ClickUp) Name() string {
+	return "clickup"
+}
+
+// Fetch fetches a ClickUp task by ID
+func (t *ClickUp) Fetch(taskID string) (Issue, error) {
+	task, err := t.client.GetTask(taskID)
+	if err != nil {
+		return Issue{}, fmt.Errorf("failed to fetch ClickUp task %s: %w", taskID, err)
+	}
+
+	totalReplies := 0
+	for _, comment := range task.Comments {
+		totalReplies += len(comment.Replies)
+	}
+	if totalReplies > 0 {
+		t.log.Printf("   ✅ %s: %s (%d comments, %d replies)\n", task.ID, task.Name, len(task.Comments), totalReplies)
+	} else {
+		logFetched(t.log, task.ID, task.Name, len(task.Comments))
+	}
+
+	total := len(task.Comments)
+	comments, summary, notes := filterComments(task.Comments, t.options, func(c clickup.Comment) Comment {
+		return Comment{Author: c.User.Username, Body: c.Text(), Created: c.DateCreated}
+	}, func(c *clickup.Comment, body string) {
+		c.Comment = []clickup.CommentBlock{{Text: body}}
+	})
+	task.Comments = comments
+
+	return Issue{
+		Tracker:     t.Name(),
+		ID:          task.ID,
+		Title:       task.Name,
+		Status:      task.Status,
+		URL:         task.URL,
+		Comments:    total,
+		Description: withSummary(task.FormatTaskDescription(), summary),
+		Trimmed:     notes,
+	}, nil
+}
+
+// Linear fetches issues from Linear
+type Linear struct {
+	contextFilter
+	client *linear.Client
+	log    logger.Logger
+}
+
+// NewLinear creates a Linear tracker
+func NewLinear(apiKey string, log logger.Logger) *Linear {
+	client := linear.NewClient(apiKey)
+	client.SetLogger(log)
+	return &Linear{client: client, log: logger.OrDefault(log)}
+}
+
+// Name returns "linear"
+func (t *Linear) Name() string {
+	return "linear"
+}
+
+// Fetch fetches a Linear issue by identifier
+func (t *Linear) Fetch(issueID string) (Issue, error) {
+	issue, err := t.client.GetIssue(issueID)
+	if err != nil {
+		return Issue{}, fmt.Errorf("failed to fetch Linear issue %s: %w", issueID, err)
+	}
+	logFetched(t.log, issue.Identifier, issue.Title, len(issue.Comments))
+
+	total := len(issue.Comments)
+	comments, summary, notes := filterComments(issue.Comments, t.options, func(c linear.Comment) Comment {
+		return Comment{Author: c.Author, Body: c.Body, Created: c.CreatedAt}
+	}, func(c *linear.Comment, body string) {
+		c.Body = body
+	})
+	issue.Comments = comments
+
+	return Issue{
+		Tracker:     t.Name(),
+		ID:          issue.Identifier,
+		Title:       issue.Title,
+		Status:      issue.State,
+		URL:         issue.URL,
+		Comments:    total,
+		Description: withSummary(issue.FormatIssueDescription(), summary),
+		Trimmed:     notes,
+	}, nil
+}
+
+// ForgeConfig holds the tokens and API URLs for GitHub and GitLab issues
+type ForgeConfig struct {
+	// RepoURL resolves short references like "#123"
+	RepoURL      string
+	GitHubToken  string
+	GitHubAPIURL string
+	GitLabToken  string
+	GitLabAPIURL string
+	// Transport is the HTTP transport of the API clients, the default if nil
+	Transport http.RoundTripper
+}
+
+// Forge fetches GitHub or GitLab issues
+type Forge struct {
+	contextFilter
+	name   string
+	config ForgeConfig
+	log    logger.Logger
+}
+
+// NewForge creates a GitHub or GitLab issue tracker
+func NewForge(name string, config ForgeConfig, log logger.Logger) *Forge {
+	return &Forge{name: name, config: config, log: logger.OrDefault(log)}
+}
+
+// Name returns "github" or "gitlab"
+func (t *Forge) Name() string {
+	return t.name
+}
+
+// Fetch fetches an issue by "#123", "owner/repo#123" or issue URL
+func (t *Forge) Fetch(id string) (Issue, error) {
+	ref, err := forge.ParseIssueRef(id, t.config.RepoURL)
+	if err != nil {
+		return Issue{}, err
+	}
+
+	issue, err := t.fetcher(ref.Host, strings.Contains(id, "://")).GetIssue(ref.Repo, ref.Number)
+	if err != nil {
+		return Issue{}, fmt.Errorf("failed to fetch issue %s#%d: %w", ref.Repo, ref.Number, err)
+	}
+	key := fmt.Sprintf("%s#%d", issue.Repo, issue.Number)
+	logFetched(t.log, key, issue.Title, len(issue.Comments))
+	if len(issue.LinkedPRs) > 0 {
+		t.log.Printf("   🔗 %s: %d linked pull requests\n", key, len(issue.LinkedPRs))
+	}
+
+	total := len(issue.Comments)
+	comments, summary, notes := filterComments(issue.Comments, t.options, func(c forge.IssueComment) Comment {
+		return Comment{Author: c.Author, Body: c.Body, Created: c.CreatedAt}
+	}, func(c *forge.IssueComment, body string) {
+		c.Body = body
+	})
+	issue.Comments = comments
+
+	return Issue{
+		Tracker:     issue.Forge,
+		ID:          key,
+		Title:       issue.Title,
+		Status:      issue.State,
+		URL:         issue.URL,
+		Comments:    total,
+		Description: withSummary(issue.FormatIssueDescription(), summary),
+		Trimmed:     notes,
+	}, nil
+}
+
+// fetcher returns the API client for an issue host. Issue URLs use the forge
+// of their host, other references the forge of the tracker.
+func (t *Forge) fetcher(host string, isURL bool) forge.IssueFetcher {
+	forgeName := t.name
+	if isURL {
+		forgeName = forge.DetectForge(host)
+	}
+
+	// "gitlab:owner/repo#1" in a github.com repository means gitlab.com and vice versa
+	switch {
+	case forgeName == forge.GitLab && host == "github.com":
+		host = "gitlab.com"
+	case forgeName == forge.GitHub && host == "gitlab.com":
+		host = "github.com"
+	}
+
+	if forgeName == forge.GitLab {
+		apiURL := t.config.GitLabAPIURL
+		if apiURL == "" {
+			apiURL = forge.APIURL(forgeName, host)
+		}
+		client := forge.NewGitLabClient(apiURL, t.config.GitLabToken)
+		if t.config.Transport != nil {
+			client.SetTransport(t.config.Transport)
+		}
+		return client
+	}
+
+	apiURL := t.config.GitHubAPIURL
+	if apiURL == "" {
+		apiURL = forge.APIURL(forgeName, host)
+	}
+	client := forge.NewGitHubClient(apiURL, t.config.GitHubToken)
+	if t.config.Transport != nil {
+		client.SetTransport(t.config.Transport)
+	}
+	return client
+}
+
+// logFetched logs a fetched issue with its number of comments
+func logFetched(log logger.Logger, key, title string, comments int) {
+	if comments > 0 {
+		log.Printf("   ✅ %s: %s (%d comments)\n", key, title, comments)
+	} else {
+		log.Printf("   ✅ %s: %s\n", key, title)
+	}
+}
@@ -0,0 +1,383 @@
+package jira
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ToMarkdown renders a Jira rich text value as Markdown. Atlassian Document
+// Format (ADF) documents are converted, plain strings are returned unchanged.
+func ToMarkdown(data interface{}) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		return strings.TrimSpace(renderBlocks([]interface{}{v}, "\n\n"))
+	}
+	return ""
+}
+
+// inlineTypes are the ADF nodes that are rendered inside a paragraph
+var inlineTypes = map[string]bool{
+	"text": true, "hardBreak": true, "mention": true, "emoji": true, "inlineCard": true,
+	"date": true, "status": true, "placeholder": true, "mediaInline": true, "inlineExtension": true,
+}
+
+// panelTitles are the labels of the ADF panel types
+var panelTitles = map[string]string{
+	"info": "Info", "note": "Note", "tip": "Tip", "success": "Success", "warning": "Warning", "error": "Error",
+}
+
+// renderBlocks renders a list of nodes, grouping consecutive inline nodes into paragraphs
+func renderBlocks(nodes []interface{}, separator string) string {
+	var blocks []string
+	var inline []interface{}
+	flush := func() {
+		if text := strings.TrimSpace(renderInline(inline)); text != "" {
+			blocks = append(blocks, text)
+		}
+		inline = nil
+	}
+
+	for _, item := range nodes {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if isInline(node) {
+			inline = append(inline, node)
+			continue
+		}
+		flush()
+		if block := renderBlock(node); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	flush()
+	return strings.Join(blocks, separator)
+}
+
+// isInline reports whether a node is rendered inline; unknown leaf nodes count as inline
+func isInline(node map[string]interface{}) bool {
+	nodeType, _ := node["type"].(string)
+	if inlineTypes[nodeType] {
+		return true
+	}
+	_, hasContent := node["content"]
+	return !hasContent && !isKnownBlock(nodeType)
+}
+
+// isKnownBlock reports whether a node type is a block without content, such as a rule
+func isKnownBlock(nodeType string) bool {
+	switch nodeType {
+	case "rule", "media", "blockCard", "embedCard", "extension":
+		return true
+	}
+	return false
+}
+
+// renderBlock renders a single block node
+func renderBlock(node map[string]interface{}) string {
+	content := children(node)
+	switch node["type"] {
+	case "paragraph":
+		return strings.TrimSpace(renderInline(content))
+	case "heading":
+		level := intAttr(node, "level", 1)
+		if level < 1 || level > 6 {
+			level = 1
+		}
+		return strings.Repeat("#", level) + " " + strings.TrimSpace(renderInline(content))
+	case "bulletList":
+		return renderList(content, func(int) string { return "- " })
+	case "orderedList":
+		start := intAttr(node, "order", 1)
+		return renderList(content, func(i int) string { return strconv.Itoa(start+i) + ". " })
+	case "taskList":
+		return renderList(content, func(int) string { return "- " })
+	case "decisionList":
+		return renderList(content, func(int) string { return "- " })
+	case "taskItem":
+		box := "[ ] "
+		if stringAttr(node, "state") == "DONE" {
+			box = "[x] "
+		}
+		return box + renderBlocks(content, "\n")
+	case "decisionItem":
+		return "**Decision:** " + renderBlocks(content, "\n")
+	case "codeBlock":
+		var code strings.Builder
+		for _, child := range content {
+			if text, ok := child.(map[string]interface{})["text"].(string); ok {
+				code.WriteString(text)
+			}
+		}
+		return "```" + stringAttr(node, "language") + "\n" + strings.TrimRight(code.String(), "\n") + "\n```"
+	case "blockquote":
+		return quote(renderBlocks(content, "\n\n"))
+	case "panel":
+		title := panelTitles[stringAttr(node, "panelType")]
+		if title == "" {
+			title = "Note"
+		}
+		return quote("**" + title + ":**\n" + renderBlocks(content, "\n\n"))
+	case "rule":
+		return "---"
+	case "table":
+		return renderTable(content)
+	case "expand", "nestedExpand":
+		body := renderBlocks(content, "\n\n")
+		if title := stringAttr(node, "title"); title != "" {
+			return "**" + title + "**\n\n" + body
+		}
+		return body
+	case "mediaSingle", "mediaGroup":
+		return renderBlocks(content, "\n")
+	case "media":
+		if alt := stringAttr(node, "alt"); alt != "" {
+			return "[attachment: " + alt + "]"
+		}
+		return "[attachment]"
+	case "blockCard", "embedCard":
+		return stringAttr(node, "url")
+	case "extension":
+		return ""
+	}
+	// Unknown containers (layouts, bodied extensions, new node types) keep their content
+	return renderBlocks(content, "\n\n")
+}
+
+// renderList renders list items, indenting continuation lines under the marker
+func renderList(items []interface{}, marker func(int) string) string {
+	var lines []string
+	for i, item := range items {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if nodeType, _ := node["type"].(string); strings.HasSuffix(nodeType, "List") {
+			// Nested task lists are direct children of their parent list
+			for _, line := range strings.Split(renderBlock(node), "\n") {
+				lines = append(lines, "  "+line)
+			}
+			continue
+		}
+		var body string
+		if node["type"] == "listItem" {
+			body = renderBlocks(children(node), "\n")
+		} else {
+			body = renderBlock(node)
+		}
+		prefix := marker(i)
+		indent := strings.Repeat(" ", len(prefix))
+		for j, line := range strings.Split(body, "\n") {
+			switch {
+			case j == 0:
+				lines = append(lines, prefix+line)
+			case line == "":
+				lines = append(lines, "")
+			default:
+				lines = append(lines, indent+line)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderTable renders a table as a GitHub flavored Markdown table; the first row is the header
+func renderTable(rows []interface{}) string {
+	var table [][]string
+	columns := 0
+	for _, row := range rows {
+		rowNode, ok := row.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var cells []string
+		for _, cell := range children(rowNode) {
+			cellNode, ok := cell.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			text := renderBlocks(children(cellNode), "\n")
+			text = strings.ReplaceAll(strings.ReplaceAll(text, "|", "\\|"), "\n", "<br>")
+			cells = append(cells, text)
+			for span := intAttr(cellNode, "colspan", 1); span > 1; span-- {
+				cells = append(cells, "")
+			}
+		}
+		if len(cells) > columns {
+			columns = len(cells)
+		}
+		table = append(table, cells)
+	}
+	if len(table) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, cells := range table {
+		for len(cells) < columns {
+			cells = append(cells, "")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderInline renders inline nodes with their marks
+func renderInline(nodes []interface{}) string {
+	var builder strings.Builder
+	for _, item := range nodes {
+		node, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch node["type"] {
+		case "text":
+			text, _ := node["text"].(string)
+			builder.WriteString(applyMarks(text, node["marks"]))
+		case "hardBreak":
+			builder.WriteString("\n")
+		case "mention":
+			name := stringAttr(node, "text")
+			if name == "" {
+				name = stringAttr(node, "id")
+			}
+			builder.WriteString("@" + strings.TrimPrefix(name, "@"))
+		case "emoji":
+			if text := stringAttr(node, "text"); text != "" {
+				builder.WriteString(text)
+			} else {
+				builder.WriteString(stringAttr(node, "shortName"))
+			}
+		case "inlineCard":
+			builder.WriteString(stringAttr(node, "url"))
+		case "date":
+			builder.WriteString(formatTimestamp(stringAttr(node, "timestamp")))
+		case "status":
+			builder.WriteString("[" + stringAttr(node, "text") + "]")
+		case "placeholder":
+			// Placeholders are template hints, not content
+		case "mediaInline":
+			builder.WriteString("[attachment]")
+		default:
+			if text, ok := node["text"].(string); ok {
+				builder.WriteString(text)
+			} else if text := stringAttr(node, "text"); text != "" {
+				builder.WriteString(text)
+			} else {
+				builder.WriteString(renderInline(children(node)))
+			}
+		}
+	}
+	return builder.String()
+}
+
+// applyMarks wraps text in the Markdown for its marks. Whitespace is kept outside
+// the markers, "** bold**" is not valid Markdown.
+func applyMarks(text string, marks interface{}) string {
+	markList, _ := marks.([]interface{})
+	if len(markList) == 0 || strings.TrimSpace(text) == "" {
+		return text
+	}
+
+	trimmed := strings.TrimSpace(text)
+	leading := text[:strings.Index(text, trimmed)]
+	trailing := text[len(leading)+len(trimmed):]
+
+	var href string
+	code, strong, em, strike := false, false, false, false
+	for _, item := range markList {
+		mark, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch mark["type"] {
+		case "code":
+			code = true
+		case "strong":
+			strong = true
+		case "em":
+			em = true
+		case "strike":
+			strike = true
+		case "link":
+			href = stringAttr(mark, "href")
+		}
+	}
+
+	if code {
+		trimmed = "`" + trimmed + "`"
+	}
+	if em {
+		trimmed = "*" + trimmed + "*"
+	}
+	if strong {
+		trimmed = "**" + trimmed + "**"
+	}
+	if strike {
+		trimmed = "~~" + trimmed + "~~"
+	}
+	if href != "" {
+		trimmed = "[" + trimmed + "](" + href + ")"
+	}
+	return leading + trimmed + trailing
+}
+
+// quote prefixes every line with "> "
+func quote(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatTimestamp formats an ADF date (milliseconds since epoch) as YYYY-MM-DD
+func formatTimestamp(value string) string {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return value
+	}
+	return time.UnixMilli(millis).UTC().Format("2006-01-02")
+}
+
+// children returns the content of a node
+func children(node map[string]interface{}) []interface{} {
+	content, _ := node["content"].([]interface{})
+	return content
+}
+
+// stringAttr returns a string attribute of a node or mark
+func stringAttr(node map[string]interface{}, name string) string {
+	attrs, _ := node["attrs"].(map[string]interface{})
+	switch v := attrs[name].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// intAttr returns an integer attribute of a node, or def if it is not set
+func intAttr(node map[string]interface{}, name string, def int) int {
+	attrs, _ := node["attrs"].(map[string]interface{})
+	if v, ok := attrs[name].(float64); ok {
+		return int(v)
+	}
+	return def
+}
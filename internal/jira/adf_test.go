@@ -0,0 +1,87 @@
+package jira
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// runGolden renders every sample with the extension in testdata/<dir> and
+// compares the result with the .md file next to it
+func runGolden(t *testing.T, dir, ext string, render func(data []byte) (string, error)) {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join("testdata", dir, "*"+ext))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no samples found in testdata/%s: %v", dir, err)
+	}
+
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ext)
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := render(data)
+			if err != nil {
+				t.Fatalf("invalid sample: %v", err)
+			}
+			got += "\n"
+
+			golden := strings.TrimSuffix(file, ext) + ".md"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("mismatch for %s\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+			}
+		})
+	}
+}
+
+func TestToMarkdownGolden(t *testing.T) {
+	runGolden(t, "adf", ".json", func(data []byte) (string, error) {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return "", err
+		}
+		return ToMarkdown(doc), nil
+	})
+}
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"nil", nil, ""},
+		{"plain string", "Already *markdown*", "Already *markdown*"},
+		{"empty doc", map[string]interface{}{"type": "doc", "content": []interface{}{}}, ""},
+		{"marks keep whitespace outside", map[string]interface{}{"type": "doc", "content": []interface{}{
+			map[string]interface{}{"type": "paragraph", "content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "a"},
+				map[string]interface{}{"type": "text", "text": " bold ", "marks": []interface{}{map[string]interface{}{"type": "strong"}}},
+				map[string]interface{}{"type": "text", "text": "b"},
+			}},
+		}}, "a **bold** b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToMarkdown(tt.data); got != tt.want {
+				t.Errorf("ToMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,10 +4,17 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/erkineren/pullpoet/internal/logger"
 )
 
 // Client handles Jira API operations
@@ -16,8 +23,23 @@ type Client struct {
 	username string
 	apiToken string
 	client   *http.Client
+	log      logger.Logger
+	// customFields are the custom field IDs included in the issue, e.g. "customfield_10042"
+	customFields []string
+	// deployment is DeploymentCloud or DeploymentServer, detected on first use if empty.
+	// Issues are fetched concurrently, mu guards the detection.
+	deployment string
+	mu         sync.Mutex
 }
 
+// Deployment types
+const (
+	// DeploymentCloud is Jira Cloud: REST API v3, ADF rich text
+	DeploymentCloud = "cloud"
+	// DeploymentServer is Jira Server or Data Center: REST API v2, wiki markup
+	DeploymentServer = "server"
+)
+
 // IssueResponse represents the response from Jira API for a single issue
 type IssueResponse struct {
 	ID     string `json:"id"`
@@ -40,9 +62,57 @@ type IssueResponse struct {
 			DisplayName  string `json:"displayName"`
 			EmailAddress string `json:"emailAddress"`
 		} `json:"reporter"`
+		Labels     []string `json:"labels"`
+		Components []struct {
+			Name string `json:"name"`
+		} `json:"components"`
+		FixVersions []struct {
+			Name string `json:"name"`
+		} `json:"fixVersions"`
+		Parent     *LinkedIssue  `json:"parent"`
+		Subtasks   []LinkedIssue `json:"subtasks"`
+		IssueLinks []struct {
+			Type struct {
+				Inward  string `json:"inward"`
+				Outward string `json:"outward"`
+			} `json:"type"`
+			InwardIssue  *LinkedIssue `json:"inwardIssue"`
+			OutwardIssue *LinkedIssue `json:"outwardIssue"`
+		} `json:"issuelinks"`
+	} `json:"fields"`
+	// RenderedFields holds HTML renderings of the fields (expand=renderedFields)
+	RenderedFields map[string]interface{} `json:"renderedFields"`
+	// Names maps field IDs to display names (expand=names)
+	Names map[string]string `json:"names"`
+}
+
+// LinkedIssue is a parent, subtask or linked issue as embedded in an issue response
+type LinkedIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary string `json:"summary"`
+		Status  struct {
+			Name string `json:"name"`
+		} `json:"status"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
 	} `json:"fields"`
 }
 
+// IssueLink is a link to another issue, e.g. "blocks HIP-2"
+type IssueLink struct {
+	Relation string
+	Issue    LinkedIssue
+}
+
+// CustomField is a custom field value rendered as text
+type CustomField struct {
+	ID    string
+	Name  string
+	Value string
+}
+
 // CommentsResponse represents the response from Jira API for issue comments
 type CommentsResponse struct {
 	Comments []Comment `json:"comments"`
@@ -63,19 +133,27 @@ type Comment struct {
 
 // Issue represents a simplified issue structure for our use
 type Issue struct {
-	ID          string
-	Key         string
-	Summary     string
-	Description string
-	Status      string
-	IssueType   string
-	Creator     string
-	Reporter    string
-	URL         string
-	Comments    []Comment
+	ID           string
+	Key          string
+	Summary      string
+	Description  string
+	Status       string
+	IssueType    string
+	Creator      string
+	Reporter     string
+	URL          string
+	Comments     []Comment
+	Labels       []string
+	Components   []string
+	FixVersions  []string
+	Parent       *LinkedIssue
+	Subtasks     []LinkedIssue
+	Links        []IssueLink
+	CustomFields []CustomField
 }
 
-// NewClient creates a new Jira API client
+// NewClient creates a new Jira API client. Without a username the token is
+// sent as a bearer token, as used by Jira Server/Data Center personal access tokens.
 func NewClient(baseURL, username, apiToken string) *Client {
 	// Remove trailing slash from baseURL if present
 	baseURL = strings.TrimSuffix(baseURL, "/")
@@ -87,23 +165,115 @@ func NewClient(baseURL, username, apiToken string) *Client {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		log: logger.Stdout,
+	}
+}
+
+// SetTransport sets the HTTP transport, e.g. for caching or rate limiting
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.client.Transport = rt
+}
+
+// SetLogger sets where progress messages are written
+func (c *Client) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// SetDeployment sets the deployment type, "cloud" or "server" ("datacenter" is an alias).
+// An empty value detects it on first use.
+func (c *Client) SetDeployment(deployment string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch strings.ToLower(deployment) {
+	case "", "auto":
+		c.deployment = ""
+	case DeploymentCloud:
+		c.deployment = DeploymentCloud
+	case DeploymentServer, "datacenter", "data-center":
+		c.deployment = DeploymentServer
+	default:
+		return fmt.Errorf("unknown Jira deployment %q (use cloud or server)", deployment)
+	}
+	return nil
+}
+
+// Deployment returns the deployment type, detecting it if it is not set:
+// *.atlassian.net is Cloud, other hosts are asked via /rest/api/2/serverInfo
+func (c *Client) Deployment() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.deployment != "" {
+		return c.deployment, nil
+	}
+	if parsed, err := neturl.Parse(c.baseURL); err == nil && strings.HasSuffix(parsed.Hostname(), ".atlassian.net") {
+		c.deployment = DeploymentCloud
+		return c.deployment, nil
+	}
+
+	var info struct {
+		DeploymentType string `json:"deploymentType"`
+	}
+	if err := c.send("GET", c.baseURL+"/rest/api/2/serverInfo", nil, &info); err != nil {
+		return "", fmt.Errorf("failed to detect Jira deployment type (set it with --jira-deployment): %w", err)
+	}
+	if strings.EqualFold(info.DeploymentType, "Cloud") {
+		c.deployment = DeploymentCloud
+	} else {
+		c.deployment = DeploymentServer
+	}
+	return c.deployment, nil
+}
+
+// isServer reports whether the detected deployment is Jira Server or Data Center
+func (c *Client) isServer() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deployment == DeploymentServer
+}
+
+// apiURL returns the REST API URL for a path, v3 on Cloud and v2 on Server
+func (c *Client) apiURL(format string, args ...interface{}) (string, error) {
+	deployment, err := c.Deployment()
+	if err != nil {
+		return "", err
 	}
+	version := "3"
+	if deployment == DeploymentServer {
+		version = "2"
+	}
+	return fmt.Sprintf("%s/rest/api/%s/", c.baseURL, version) + fmt.Sprintf(format, args...), nil
+}
+
+// authorize sets the authentication headers: Basic with a username, Bearer without
+func (c *Client) authorize(req *http.Request) {
+	if c.username == "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	} else {
+		auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.apiToken)))
+		req.Header.Set("Authorization", fmt.Sprintf("Basic %s", auth))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+}
+
+// SetCustomFields sets the custom field IDs included in fetched issues
+func (c *Client) SetCustomFields(ids []string) {
+	c.customFields = ids
 }
 
 // GetIssue fetches an issue by key from Jira
 func (c *Client) GetIssue(issueKey string) (*Issue, error) {
-	url := fmt.Sprintf("%s/rest/api/3/issue/%s", c.baseURL, issueKey)
+	url, err := c.apiURL("issue/%s?expand=renderedFields,names", issueKey)
+	if err != nil {
+		return nil, err
+	}
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set Basic Auth header
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.apiToken)))
-	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", auth))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -116,8 +286,12 @@ func (c *Client) GetIssue(issueKey string) (*Issue, error) {
 		return nil, fmt.Errorf("Jira API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
 	var issueResp IssueResponse
-	if err := json.NewDecoder(resp.Body).Decode(&issueResp); err != nil {
+	if err := json.Unmarshal(body, &issueResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
@@ -126,19 +300,74 @@ func (c *Client) GetIssue(issueKey string) (*Issue, error) {
 		ID:          issueResp.ID,
 		Key:         issueResp.Key,
 		Summary:     issueResp.Fields.Summary,
-		Description: extractText(issueResp.Fields.Description),
+		Description: c.toMarkdown(issueResp.Fields.Description),
 		Status:      issueResp.Fields.Status.Name,
 		IssueType:   issueResp.Fields.IssueType.Name,
 		Creator:     issueResp.Fields.Creator.DisplayName,
 		Reporter:    issueResp.Fields.Reporter.DisplayName,
 		URL:         fmt.Sprintf("%s/browse/%s", c.baseURL, issueResp.Key),
+		Labels:      issueResp.Fields.Labels,
+		Parent:      issueResp.Fields.Parent,
+		Subtasks:    issueResp.Fields.Subtasks,
+	}
+	for _, component := range issueResp.Fields.Components {
+		issue.Components = append(issue.Components, component.Name)
+	}
+	for _, version := range issueResp.Fields.FixVersions {
+		issue.FixVersions = append(issue.FixVersions, version.Name)
+	}
+	for _, link := range issueResp.Fields.IssueLinks {
+		if link.OutwardIssue != nil {
+			issue.Links = append(issue.Links, IssueLink{Relation: link.Type.Outward, Issue: *link.OutwardIssue})
+		}
+		if link.InwardIssue != nil {
+			issue.Links = append(issue.Links, IssueLink{Relation: link.Type.Inward, Issue: *link.InwardIssue})
+		}
+	}
+
+	// Custom fields and the Epic Link field are not known in advance, decode the fields again as a map
+	var raw struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if len(c.customFields) > 0 || issue.Parent == nil {
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("failed to decode custom fields: %w", err)
+		}
+	}
+
+	// Jira Server/Data Center has no parent for epics, the epic is in the Epic Link custom field
+	if issue.Parent == nil {
+		if epicKey := epicLink(raw.Fields, issueResp.Names); epicKey != "" {
+			issue.Parent = c.linkedIssue(epicKey)
+		}
+	}
+
+	if len(c.customFields) > 0 {
+		for _, id := range c.customFields {
+			value := fieldText(raw.Fields[id])
+			if c.isServer() {
+				value = WikiToMarkdown(value)
+			}
+			if value == "" {
+				// Wiki markup and other fields without an ADF value are easier to read rendered
+				value = htmlToText(issueResp.RenderedFields[id])
+			}
+			if value == "" {
+				continue
+			}
+			name := issueResp.Names[id]
+			if name == "" {
+				name = id
+			}
+			issue.CustomFields = append(issue.CustomFields, CustomField{ID: id, Name: name, Value: value})
+		}
 	}
 
 	// Fetch comments for the issue
 	comments, err := c.GetIssueComments(issueKey)
 	if err != nil {
 		// Log the error but don't fail the entire request
-		fmt.Printf("Warning: Failed to fetch comments for issue %s: %v\n", issueKey, err)
+		c.log.Printf("Warning: Failed to fetch comments for issue %s: %v\n", issueKey, err)
 	} else {
 		issue.Comments = comments
 	}
@@ -146,20 +375,50 @@ func (c *Client) GetIssue(issueKey string) (*Issue, error) {
 	return issue, nil
 }
 
+// epicLink returns the issue key in the Epic Link field, found by its name as the field ID differs per instance
+func epicLink(fields map[string]interface{}, names map[string]string) string {
+	for id, name := range names {
+		if !strings.EqualFold(name, "Epic Link") {
+			continue
+		}
+		if key, ok := fields[id].(string); ok && key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
+// linkedIssue fetches the summary, status and type of a related issue. If that
+// fails, only the key is returned.
+func (c *Client) linkedIssue(key string) *LinkedIssue {
+	linked := &LinkedIssue{Key: key}
+	url, err := c.apiURL("issue/%s?fields=summary,status,issuetype", key)
+	if err == nil {
+		err = c.send("GET", url, nil, linked)
+	}
+	if err != nil {
+		c.log.Printf("Warning: Failed to fetch epic %s: %v\n", key, err)
+		linked = &LinkedIssue{Key: key}
+	}
+	if linked.Fields.IssueType.Name == "" {
+		linked.Fields.IssueType.Name = "Epic"
+	}
+	return linked
+}
+
 // GetIssueComments fetches comments for an issue by key from Jira
 func (c *Client) GetIssueComments(issueKey string) ([]Comment, error) {
-	url := fmt.Sprintf("%s/rest/api/3/issue/%s/comment", c.baseURL, issueKey)
+	url, err := c.apiURL("issue/%s/comment", issueKey)
+	if err != nil {
+		return nil, err
+	}
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set Basic Auth header
-	auth := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", c.username, c.apiToken)))
-	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", auth))
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
@@ -177,53 +436,63 @@ func (c *Client) GetIssueComments(issueKey string) ([]Comment, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	for i := range commentResp.Comments {
+		commentResp.Comments[i].Body = c.toMarkdown(commentResp.Comments[i].Body)
+	}
 	return commentResp.Comments, nil
 }
 
-// extractText extracts plain text from Jira's Atlassian Document Format (ADF)
-// This is a simplified version that handles the most common cases
-func extractText(data interface{}) string {
-	if data == nil {
-		return ""
-	}
-
-	// If it's a string, return it directly
-	if str, ok := data.(string); ok {
-		return str
+// toMarkdown renders a rich text field: ADF on Cloud, wiki markup on Server
+func (c *Client) toMarkdown(value interface{}) string {
+	if text, ok := value.(string); ok && c.isServer() {
+		return WikiToMarkdown(text)
 	}
+	return ToMarkdown(value)
+}
 
-	// If it's a map (ADF format), extract text recursively
-	if adf, ok := data.(map[string]interface{}); ok {
-		var builder strings.Builder
-		extractTextFromADF(adf, &builder)
-		return builder.String()
+// fieldText renders a custom field value: text, ADF documents, options, users and lists
+func fieldText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if text := fieldText(item); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		if v["type"] == "doc" {
+			return ToMarkdown(v)
+		}
+		for _, key := range []string{"value", "name", "displayName", "key"} {
+			if text, ok := v[key].(string); ok {
+				return text
+			}
+		}
 	}
-
 	return ""
 }
 
-// extractTextFromADF recursively extracts text from Atlassian Document Format
-func extractTextFromADF(node map[string]interface{}, builder *strings.Builder) {
-	// Check for text content
-	if text, ok := node["text"].(string); ok {
-		builder.WriteString(text)
-	}
-
-	// Process content array (paragraphs, lists, etc.)
-	if content, ok := node["content"].([]interface{}); ok {
-		for _, item := range content {
-			if contentNode, ok := item.(map[string]interface{}); ok {
-				// Add newlines between block elements
-				nodeType, _ := contentNode["type"].(string)
-				if nodeType == "paragraph" || nodeType == "heading" {
-					extractTextFromADF(contentNode, builder)
-					builder.WriteString("\n")
-				} else {
-					extractTextFromADF(contentNode, builder)
-				}
-			}
-		}
+// htmlTagPattern matches HTML tags in rendered fields
+var htmlTagPattern = regexp.MustCompile(`<[^>]+>`)
+
+// htmlToText strips the tags from a rendered HTML field
+func htmlToText(value interface{}) string {
+	rendered, ok := value.(string)
+	if !ok {
+		return ""
 	}
+	rendered = strings.NewReplacer("<br/>", "\n", "<br />", "\n", "</p>", "\n", "</li>", "\n").Replace(rendered)
+	return strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(rendered, "")))
 }
 
 // FormatIssueDescription formats the issue information into a structured description
@@ -242,6 +511,41 @@ func (i *Issue) FormatIssueDescription() string {
 **Description:**
 %s`, i.Summary, i.Key, i.Key, i.IssueType, i.Status, i.Creator, i.Reporter, i.URL, i.Description))
 
+	var details []string
+	if i.Parent != nil {
+		label := strings.TrimSpace("Parent " + i.Parent.Fields.IssueType.Name)
+		details = append(details, fmt.Sprintf("**%s:** %s", label, formatLinkedIssue(*i.Parent)))
+	}
+	if len(i.Labels) > 0 {
+		details = append(details, fmt.Sprintf("**Labels:** %s", strings.Join(i.Labels, ", ")))
+	}
+	if len(i.Components) > 0 {
+		details = append(details, fmt.Sprintf("**Components:** %s", strings.Join(i.Components, ", ")))
+	}
+	if len(i.FixVersions) > 0 {
+		details = append(details, fmt.Sprintf("**Fix Versions:** %s", strings.Join(i.FixVersions, ", ")))
+	}
+	if len(details) > 0 {
+		builder.WriteString("\n\n" + strings.Join(details, "\n"))
+	}
+
+	for _, field := range i.CustomFields {
+		builder.WriteString(fmt.Sprintf("\n\n**%s:**\n%s", field.Name, field.Value))
+	}
+
+	if len(i.Subtasks) > 0 {
+		builder.WriteString("\n\n**Subtasks:**")
+		for _, subtask := range i.Subtasks {
+			builder.WriteString(fmt.Sprintf("\n- %s", formatLinkedIssue(subtask)))
+		}
+	}
+	if len(i.Links) > 0 {
+		builder.WriteString("\n\n**Linked Issues:**")
+		for _, link := range i.Links {
+			builder.WriteString(fmt.Sprintf("\n- %s %s", link.Relation, formatLinkedIssue(link.Issue)))
+		}
+	}
+
 	// Add comments if available
 	if len(i.Comments) > 0 {
 		builder.WriteString("\n\n**Comments:**\n")
@@ -249,8 +553,8 @@ func (i *Issue) FormatIssueDescription() string {
 			builder.WriteString(fmt.Sprintf("\n---\n"))
 			builder.WriteString(fmt.Sprintf("**Comment %d** (by %s on %s):\n", idx+1, comment.Author.DisplayName, comment.Created))
 
-			// Extract text from comment body (ADF format)
-			commentText := extractText(comment.Body)
+			// Render comment body (ADF format) as Markdown
+			commentText := ToMarkdown(comment.Body)
 			builder.WriteString(commentText)
 			builder.WriteString("\n")
 		}
@@ -258,3 +562,12 @@ func (i *Issue) FormatIssueDescription() string {
 
 	return builder.String()
 }
+
+// formatLinkedIssue formats a related issue as "KEY: Summary (Status)"
+func formatLinkedIssue(issue LinkedIssue) string {
+	text := fmt.Sprintf("%s: %s", issue.Key, issue.Fields.Summary)
+	if issue.Fields.Status.Name != "" {
+		text += fmt.Sprintf(" (%s)", issue.Fields.Status.Name)
+	}
+	return text
+}
@@ -0,0 +1,254 @@
+package jira
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+const richIssueResponse = `{
+	"id": "10001", "key": "HIP-12",
+	"fields": {
+		"summary": "Add login form",
+		"description": {"type": "doc", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "Users sign in with email."}]}]},
+		"status": {"name": "In Progress"}, "issuetype": {"name": "Story"},
+		"creator": {"displayName": "Ada"}, "reporter": {"displayName": "Bob"},
+		"labels": ["auth", "frontend"],
+		"components": [{"name": "Web"}],
+		"fixVersions": [{"name": "2.4.0"}],
+		"parent": {"key": "HIP-1", "fields": {"summary": "Accounts", "status": {"name": "In Progress"}, "issuetype": {"name": "Epic"}}},
+		"subtasks": [{"key": "HIP-13", "fields": {"summary": "Validate email", "status": {"name": "Done"}}}],
+		"issuelinks": [
+			{"type": {"inward": "is blocked by", "outward": "blocks"}, "outwardIssue": {"key": "HIP-20", "fields": {"summary": "Profile page", "status": {"name": "To Do"}}}},
+			{"type": {"inward": "relates to", "outward": "relates to"}, "inwardIssue": {"key": "OPS-3", "fields": {"summary": "Rate limit auth"}}}
+		],
+		"customfield_10042": {"type": "doc", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "Wrong passwords show an error"}]}]},
+		"customfield_10050": {"value": "High"},
+		"customfield_10060": null,
+		"customfield_10070": 5
+	},
+	"renderedFields": {"customfield_10060": "<p>Legacy &amp; wiki<br/>markup</p>"},
+	"names": {"customfield_10042": "Acceptance Criteria", "customfield_10050": "Risk", "customfield_10060": "Notes"}
+}`
+
+func TestGetIssueRichFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/3/issue/HIP-12":
+			if r.URL.Query().Get("expand") != "renderedFields,names" {
+				t.Errorf("expand = %q", r.URL.Query().Get("expand"))
+			}
+			io.WriteString(w, richIssueResponse)
+		case "/rest/api/3/issue/HIP-12/comment":
+			io.WriteString(w, `{"comments": [], "total": 0}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "user", "token")
+	client.SetLogger(logger.Discard)
+	client.SetDeployment(DeploymentCloud)
+	client.SetCustomFields([]string{"customfield_10042", "customfield_10050", "customfield_10060", "customfield_10070", "customfield_99999"})
+
+	issue, err := client.GetIssue("HIP-12")
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+
+	want := []CustomField{
+		{ID: "customfield_10042", Name: "Acceptance Criteria", Value: "Wrong passwords show an error"},
+		{ID: "customfield_10050", Name: "Risk", Value: "High"},
+		{ID: "customfield_10060", Name: "Notes", Value: "Legacy & wiki\nmarkup"},
+		{ID: "customfield_10070", Name: "customfield_10070", Value: "5"},
+	}
+	if len(issue.CustomFields) != len(want) {
+		t.Fatalf("custom fields = %+v", issue.CustomFields)
+	}
+	for i := range want {
+		if issue.CustomFields[i] != want[i] {
+			t.Errorf("custom field %d = %+v, want %+v", i, issue.CustomFields[i], want[i])
+		}
+	}
+
+	description := issue.FormatIssueDescription()
+	for _, want := range []string{
+		"**Parent Epic:** HIP-1: Accounts (In Progress)",
+		"**Labels:** auth, frontend",
+		"**Components:** Web",
+		"**Fix Versions:** 2.4.0",
+		"**Acceptance Criteria:**\nWrong passwords show an error",
+		"**Subtasks:**\n- HIP-13: Validate email (Done)",
+		"**Linked Issues:**\n- blocks HIP-20: Profile page (To Do)\n- relates to OPS-3: Rate limit auth",
+	} {
+		if !strings.Contains(description, want) {
+			t.Errorf("description missing %q:\n%s", want, description)
+		}
+	}
+}
+
+func TestGetIssueServer(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer server-pat" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/jira/rest/api/2/serverInfo":
+			io.WriteString(w, `{"deploymentType": "Server", "version": "9.12.0"}`)
+		case "/jira/rest/api/2/issue/OPS-7":
+			io.WriteString(w, `{"id": "1", "key": "OPS-7", "fields": {"summary": "Rotate keys", "description": "h3. Steps\n# Generate *new* keys\n# Revoke {{old}} keys", "status": {"name": "Open"}}}`)
+		case "/jira/rest/api/2/issue/OPS-7/comment":
+			io.WriteString(w, `{"comments": [{"id": "9", "body": "See [runbook|https://wiki.example.com/keys]", "author": {"displayName": "Ada"}}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/jira/", "", "server-pat")
+	client.SetLogger(logger.Discard)
+
+	issue, err := client.GetIssue("OPS-7")
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if deployment, _ := client.Deployment(); deployment != DeploymentServer {
+		t.Errorf("Deployment() = %q, want server", deployment)
+	}
+	if want := "### Steps\n1. Generate **new** keys\n1. Revoke `old` keys"; issue.Description != want {
+		t.Errorf("Description = %q, want %q", issue.Description, want)
+	}
+	if len(issue.Comments) != 1 || issue.Comments[0].Body != "See [runbook](https://wiki.example.com/keys)" {
+		t.Errorf("Comments = %+v", issue.Comments)
+	}
+	if len(paths) != 3 || paths[0] != "/jira/rest/api/2/serverInfo" {
+		t.Errorf("requests = %v, want server info to be fetched once", paths)
+	}
+}
+
+func TestGetIssueServerEpicLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		epic     string
+		wantLine string
+	}{
+		{
+			name:     "epic fetched",
+			epic:     `{"key": "OPS-1", "fields": {"summary": "Key rotation", "status": {"name": "Open"}, "issuetype": {"name": "Epic"}}}`,
+			wantLine: "**Parent Epic:** OPS-1: Key rotation (Open)",
+		},
+		{
+			name:     "epic not accessible",
+			wantLine: "**Parent Epic:** OPS-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/rest/api/2/issue/OPS-7":
+					io.WriteString(w, `{"id": "1", "key": "OPS-7",
+						"fields": {"summary": "Rotate keys", "status": {"name": "Open"}, "customfield_10100": "OPS-1", "customfield_10101": "Rotation"},
+						"names": {"customfield_10100": "Epic Link", "customfield_10101": "Epic Name"}}`)
+				case "/rest/api/2/issue/OPS-1":
+					if tt.epic == "" || r.URL.Query().Get("fields") != "summary,status,issuetype" {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					io.WriteString(w, tt.epic)
+				case "/rest/api/2/issue/OPS-7/comment":
+					io.WriteString(w, `{"comments": []}`)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(server.URL, "", "server-pat")
+			client.SetLogger(logger.Discard)
+			client.SetDeployment(DeploymentServer)
+
+			issue, err := client.GetIssue("OPS-7")
+			if err != nil {
+				t.Fatalf("GetIssue() error = %v", err)
+			}
+			if issue.Parent == nil || issue.Parent.Key != "OPS-1" {
+				t.Fatalf("Parent = %+v, want the epic OPS-1", issue.Parent)
+			}
+			if description := issue.FormatIssueDescription(); !strings.Contains(description, tt.wantLine) {
+				t.Errorf("description missing %q:\n%s", tt.wantLine, description)
+			}
+		})
+	}
+}
+
+func TestGetIssueConcurrentDetection(t *testing.T) {
+	var serverInfo int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rest/api/2/serverInfo":
+			atomic.AddInt32(&serverInfo, 1)
+			io.WriteString(w, `{"deploymentType": "Server"}`)
+		case strings.HasSuffix(r.URL.Path, "/comment"):
+			io.WriteString(w, `{"comments": []}`)
+		default:
+			io.WriteString(w, `{"id": "1", "key": "OPS-1", "fields": {"summary": "Issue", "status": {"name": "Open"}}}`)
+		}
+	}))
+	defer server.Close()
+
+	// Issues are fetched concurrently, the deployment is detected once
+	client := NewClient(server.URL, "", "token")
+	client.SetLogger(logger.Discard)
+	var wg sync.WaitGroup
+	for _, key := range []string{"OPS-1", "OPS-2", "OPS-3"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			if _, err := client.GetIssue(key); err != nil {
+				t.Errorf("GetIssue(%s) error = %v", key, err)
+			}
+		}(key)
+	}
+	wg.Wait()
+	if serverInfo != 1 {
+		t.Errorf("server info requests = %d, want 1", serverInfo)
+	}
+}
+
+func TestSetDeployment(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"cloud", DeploymentCloud, false},
+		{"Server", DeploymentServer, false},
+		{"datacenter", DeploymentServer, false},
+		{"", "", false},
+		{"onprem", "", true},
+	}
+	for _, tt := range tests {
+		client := NewClient("https://jira.example.com", "", "token")
+		err := client.SetDeployment(tt.value)
+		if (err != nil) != tt.wantErr || client.deployment != tt.want {
+			t.Errorf("SetDeployment(%q) = %q, %v; want %q, error %v", tt.value, client.deployment, err, tt.want, tt.wantErr)
+		}
+	}
+
+	client := NewClient("https://acme.atlassian.net", "user", "token")
+	if deployment, err := client.Deployment(); err != nil || deployment != DeploymentCloud {
+		t.Errorf("Deployment() for atlassian.net = %q, %v; want cloud without a request", deployment, err)
+	}
+}
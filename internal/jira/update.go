@@ -0,0 +1,199 @@
+package jira
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Transition is a workflow transition available on an issue
+type Transition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   struct {
+		Name string `json:"name"`
+	} `json:"to"`
+}
+
+// AddComment posts a comment to an issue. The body is an ADF document on Cloud
+// and a wiki markup string on Server.
+func (c *Client) AddComment(issueKey string, body interface{}) error {
+	url, err := c.apiURL("issue/%s/comment", issueKey)
+	if err != nil {
+		return err
+	}
+	return c.send("POST", url, map[string]interface{}{"body": body}, nil)
+}
+
+// UpdateComment replaces the body of a comment, in the same format as AddComment
+func (c *Client) UpdateComment(issueKey, commentID string, body interface{}) error {
+	url, err := c.apiURL("issue/%s/comment/%s", issueKey, commentID)
+	if err != nil {
+		return err
+	}
+	return c.send("PUT", url, map[string]interface{}{"body": body}, nil)
+}
+
+// FindPullRequestComment returns the ID of the pull request comment linking url, or "" if there is none
+func (c *Client) FindPullRequestComment(issueKey, url string) (string, error) {
+	comments, err := c.GetIssueComments(issueKey)
+	if err != nil {
+		return "", err
+	}
+	for _, comment := range comments {
+		body, _ := comment.Body.(string)
+		if strings.Contains(body, "Pull request:") && strings.Contains(body, url) {
+			return comment.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// SetPullRequestComment updates the comment linking the pull request, or adds one if
+// there is none, so reruns do not add duplicates. It reports whether a comment was updated.
+func (c *Client) SetPullRequestComment(issueKey, title, url, summary string) (bool, error) {
+	deployment, err := c.Deployment()
+	if err != nil {
+		return false, err
+	}
+	var body interface{} = PullRequestComment(title, url, summary)
+	if deployment == DeploymentServer {
+		body = PullRequestWikiComment(title, url, summary)
+	}
+
+	commentID, err := c.FindPullRequestComment(issueKey, url)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch comments: %w", err)
+	}
+	if commentID == "" {
+		return false, c.AddComment(issueKey, body)
+	}
+	return true, c.UpdateComment(issueKey, commentID, body)
+}
+
+// GetTransitions returns the transitions available on an issue
+func (c *Client) GetTransitions(issueKey string) ([]Transition, error) {
+	url, err := c.apiURL("issue/%s/transitions", issueKey)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Transitions []Transition `json:"transitions"`
+	}
+	if err := c.send("GET", url, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Transitions, nil
+}
+
+// TransitionIssue moves an issue through the transition with the given name.
+// The name is matched case-insensitively against the transition and its target status.
+// An issue whose current status already is the target is left alone and false is returned.
+func (c *Client) TransitionIssue(issueKey, name, status string) (bool, error) {
+	if strings.EqualFold(status, name) {
+		return false, nil
+	}
+	transitions, err := c.GetTransitions(issueKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch transitions: %w", err)
+	}
+
+	var available []string
+	for _, transition := range transitions {
+		if strings.EqualFold(transition.Name, name) || strings.EqualFold(transition.To.Name, name) {
+			if strings.EqualFold(transition.To.Name, status) {
+				return false, nil
+			}
+			url, err := c.apiURL("issue/%s/transitions", issueKey)
+			if err != nil {
+				return false, err
+			}
+			return true, c.send("POST", url, map[string]interface{}{"transition": map[string]string{"id": transition.ID}}, nil)
+		}
+		available = append(available, transition.Name)
+	}
+	return false, fmt.Errorf("transition %q is not available for %s (available: %s)", name, issueKey, strings.Join(available, ", "))
+}
+
+// send performs an authenticated request, encoding payload and decoding the response into out
+func (c *Client) send(method, url string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.authorize(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Jira API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// PullRequestComment builds an ADF comment linking a pull request, with a short summary
+func PullRequestComment(title, url, summary string) map[string]interface{} {
+	titleNode := map[string]interface{}{"type": "text", "text": title}
+	if url != "" {
+		titleNode["marks"] = []interface{}{
+			map[string]interface{}{"type": "link", "attrs": map[string]string{"href": url}},
+		}
+	}
+
+	content := []interface{}{
+		map[string]interface{}{
+			"type": "paragraph",
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "Pull request: ", "marks": []interface{}{map[string]string{"type": "strong"}}},
+				titleNode,
+			},
+		},
+	}
+	if summary != "" {
+		content = append(content, map[string]interface{}{
+			"type":    "paragraph",
+			"content": []interface{}{map[string]interface{}{"type": "text", "text": summary}},
+		})
+	}
+
+	return map[string]interface{}{
+		"type":    "doc",
+		"version": 1,
+		"content": content,
+	}
+}
+
+// PullRequestWikiComment builds a wiki markup comment linking a pull request, with a short summary
+func PullRequestWikiComment(title, url, summary string) string {
+	comment := "*Pull request:* " + title
+	if url != "" {
+		comment = fmt.Sprintf("*Pull request:* [%s|%s]", strings.NewReplacer("|", "-", "[", "(", "]", ")").Replace(title), url)
+	}
+	if summary != "" {
+		comment += "\n\n" + summary
+	}
+	return comment
+}
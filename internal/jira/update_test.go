@@ -0,0 +1,184 @@
+package jira
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordedRequest is a request received by the fake Jira server
+type recordedRequest struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+func newFakeJira(t *testing.T) (*Client, *[]recordedRequest) {
+	t.Helper()
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorded := recordedRequest{method: r.Method, path: r.URL.Path}
+		json.NewDecoder(r.Body).Decode(&recorded.body)
+		requests = append(requests, recorded)
+
+		if r.Method == "GET" && r.URL.Path == "/rest/api/3/issue/HIP-2/comment" {
+			io.WriteString(w, `{"comments": [
+				{"id": "10000", "body": {"type": "doc", "version": 1, "content": [{"type": "paragraph", "content": [{"type": "text", "text": "Looks good"}]}]}},
+				{"id": "10001", "body": {"type": "doc", "version": 1, "content": [{"type": "paragraph", "content": [
+					{"type": "text", "text": "Pull request: ", "marks": [{"type": "strong"}]},
+					{"type": "text", "text": "Add login", "marks": [{"type": "link", "attrs": {"href": "https://github.com/acme/app/pull/7"}}]}
+				]}]}}
+			]}`)
+			return
+		}
+		if r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/comment") {
+			io.WriteString(w, `{"comments": []}`)
+			return
+		}
+		if r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/transitions") {
+			io.WriteString(w, `{"transitions": [
+				{"id": "11", "name": "Start progress", "to": {"name": "In Progress"}},
+				{"id": "21", "name": "Request review", "to": {"name": "In Review"}}
+			]}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, "user", "token")
+	client.SetDeployment(DeploymentCloud)
+	return client, &requests
+}
+
+func TestAddComment(t *testing.T) {
+	client, requests := newFakeJira(t)
+
+	body := PullRequestComment("Add login", "https://github.com/acme/app/pull/7", "Adds the login form.")
+	if err := client.AddComment("HIP-1", body); err != nil {
+		t.Fatalf("AddComment() error = %v", err)
+	}
+
+	got := (*requests)[0]
+	if got.method != "POST" || got.path != "/rest/api/3/issue/HIP-1/comment" {
+		t.Fatalf("request = %s %s", got.method, got.path)
+	}
+	encoded, _ := json.Marshal(got.body)
+	for _, want := range []string{`"type":"doc"`, `"href":"https://github.com/acme/app/pull/7"`, `"text":"Adds the login form."`} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("comment body missing %s: %s", want, encoded)
+		}
+	}
+}
+
+func TestTransitionIssue(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		status  string
+		wantID  string
+		wantErr string
+	}{
+		{name: "by target status", target: "in review", status: "To Do", wantID: "21"},
+		{name: "by transition name", target: "Start progress", status: "To Do", wantID: "11"},
+		{name: "unknown transition", target: "Done", status: "To Do", wantErr: "available: Start progress, Request review"},
+		{name: "already in the target status", target: "In Review", status: "In Review"},
+		{name: "already in the status of the transition", target: "Request review", status: "in review"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newFakeJira(t)
+			moved, err := client.TransitionIssue("HIP-1", tt.target, tt.status)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("TransitionIssue() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TransitionIssue() error = %v", err)
+			}
+			if moved != (tt.wantID != "") {
+				t.Errorf("TransitionIssue() = %v, want %v", moved, tt.wantID != "")
+			}
+			if tt.wantID == "" {
+				for _, request := range *requests {
+					if request.method == "POST" {
+						t.Errorf("unexpected transition request: %s %v", request.path, request.body)
+					}
+				}
+				return
+			}
+
+			last := (*requests)[len(*requests)-1]
+			transition, _ := last.body["transition"].(map[string]interface{})
+			if last.method != "POST" || transition["id"] != tt.wantID {
+				t.Errorf("request = %s %v, want transition %s", last.method, last.body, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestSetPullRequestComment(t *testing.T) {
+	tests := []struct {
+		name        string
+		deployment  string
+		issueKey    string
+		url         string
+		wantUpdated bool
+		wantRequest string
+		wantBody    string
+	}{
+		{
+			name:        "new comment on Server",
+			deployment:  DeploymentServer,
+			issueKey:    "OPS-7",
+			url:         "https://git.example.com/pr/3",
+			wantRequest: "POST /rest/api/2/issue/OPS-7/comment",
+			wantBody:    "*Pull request:* [Rotate keys (ops)|https://git.example.com/pr/3]\n\nRotates the keys.",
+		},
+		{
+			name:        "existing comment is updated",
+			deployment:  DeploymentCloud,
+			issueKey:    "HIP-2",
+			url:         "https://github.com/acme/app/pull/7",
+			wantUpdated: true,
+			wantRequest: "PUT /rest/api/3/issue/HIP-2/comment/10001",
+		},
+		{
+			name:        "comment of another pull request is kept",
+			deployment:  DeploymentCloud,
+			issueKey:    "HIP-2",
+			url:         "https://github.com/acme/app/pull/8",
+			wantRequest: "POST /rest/api/3/issue/HIP-2/comment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, requests := newFakeJira(t)
+			client.SetDeployment(tt.deployment)
+
+			updated, err := client.SetPullRequestComment(tt.issueKey, "Rotate keys [ops]", tt.url, "Rotates the keys.")
+			if err != nil {
+				t.Fatalf("SetPullRequestComment() error = %v", err)
+			}
+			if updated != tt.wantUpdated {
+				t.Errorf("updated = %v, want %v", updated, tt.wantUpdated)
+			}
+			if len(*requests) != 2 {
+				t.Fatalf("requests = %v, want a lookup and a write", *requests)
+			}
+			got := (*requests)[1]
+			if got.method+" "+got.path != tt.wantRequest {
+				t.Errorf("request = %s %s, want %s", got.method, got.path, tt.wantRequest)
+			}
+			if tt.wantBody != "" && got.body["body"] != tt.wantBody {
+				t.Errorf("body = %v, want %q", got.body["body"], tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package jira
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// wikiBlockMacro matches block macros so they can be put on lines of their own
+	wikiBlockMacro = regexp.MustCompile(`\{(?:code|noformat|quote|panel|info|note|tip|warning)(?::[^}]*)?\}`)
+	wikiHeading    = regexp.MustCompile(`^h([1-6])\.\s+(.*)$`)
+	wikiQuoteLine  = regexp.MustCompile(`^bq\.\s+(.*)$`)
+	wikiListItem   = regexp.MustCompile(`^([*#]+|-)\s+(.*)$`)
+	wikiRule       = regexp.MustCompile(`^-{4,}\s*$`)
+
+	wikiMonospace = regexp.MustCompile(`\{\{(.+?)\}\}`)
+	wikiLink      = regexp.MustCompile(`\[([^\]|]+)\|([^\]]+)\]`)
+	wikiMention   = regexp.MustCompile(`\[~([^\]]+)\]`)
+	wikiURL       = regexp.MustCompile(`\[((?:https?|mailto):[^\]]+)\]`)
+	wikiImage     = regexp.MustCompile(`!([^!\s|]+\.[A-Za-z0-9]+)(?:\|[^!]*)?!`)
+	wikiColor     = regexp.MustCompile(`\{color(?::[^}]*)?\}`)
+	wikiStrong    = emphasisPattern(`\*`)
+	wikiEmphasis  = emphasisPattern(`_`)
+	wikiStrike    = emphasisPattern(`-`)
+	wikiUnderline = emphasisPattern(`\+`)
+)
+
+// emphasisPattern matches text wrapped in a wiki markup delimiter, e.g. *bold*
+func emphasisPattern(delimiter string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|[\s(\[>])` + delimiter + `([^\s` + delimiter + `](?:[^` + delimiter + `]*[^\s` + delimiter + `])?)` + delimiter + `($|[\s).,:;!?\]])`)
+}
+
+// wikiPanelTitles are the labels of the admonition macros
+var wikiPanelTitles = map[string]string{"info": "Info", "note": "Note", "tip": "Tip", "warning": "Warning"}
+
+// WikiToMarkdown converts Jira wiki markup, as used by Jira Server and Data Center, to Markdown
+func WikiToMarkdown(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = wikiBlockMacro.ReplaceAllStringFunc(text, func(macro string) string {
+		return "\n" + macro + "\n"
+	})
+
+	var out, code, quoted []string
+	var codeMacro, codeFence, quoteMacro, quoteTitle string
+	inTable := false
+	// emit appends a converted line to the open quote or panel, or to the output
+	emit := func(line string) {
+		if quoteMacro != "" {
+			quoted = append(quoted, line)
+		} else {
+			out = append(out, line)
+		}
+	}
+	flushCode := func() {
+		emit(codeFence + "\n" + strings.Trim(strings.Join(code, "\n"), "\n") + "\n```")
+		code, codeMacro = nil, ""
+	}
+	flushQuote := func() {
+		body := strings.Trim(collapseBlankLines(strings.Join(quoted, "\n")), "\n")
+		if quoteTitle != "" {
+			body = "**" + quoteTitle + ":**\n" + body
+		}
+		out = append(out, "", quote(body), "")
+		quoted, quoteMacro = nil, ""
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		// Code blocks are copied verbatim until the closing macro
+		if codeMacro != "" {
+			if trimmed == "{"+codeMacro+"}" {
+				flushCode()
+			} else {
+				code = append(code, line)
+			}
+			continue
+		}
+
+		if name, params, ok := parseMacro(trimmed); ok {
+			switch {
+			case name == "code" || name == "noformat":
+				codeMacro = name
+				codeFence = "```" + codeLanguage(name, params)
+			case quoteMacro == name && params == "":
+				flushQuote()
+			case quoteMacro == "":
+				quoteMacro = name
+				quoteTitle = macroTitle(name, params)
+			}
+			continue
+		}
+
+		if !strings.HasPrefix(trimmed, "|") {
+			inTable = false
+		}
+
+		var converted string
+		switch {
+		case trimmed == "":
+			converted = ""
+		case wikiRule.MatchString(trimmed):
+			converted = "---"
+		case wikiHeading.MatchString(trimmed):
+			match := wikiHeading.FindStringSubmatch(trimmed)
+			converted = strings.Repeat("#", int(match[1][0]-'0')) + " " + wikiInline(match[2])
+		case wikiQuoteLine.MatchString(trimmed):
+			converted = "> " + wikiInline(wikiQuoteLine.FindStringSubmatch(trimmed)[1])
+		case wikiListItem.MatchString(trimmed):
+			match := wikiListItem.FindStringSubmatch(trimmed)
+			marker := "- "
+			if strings.HasSuffix(match[1], "#") {
+				marker = "1. "
+			}
+			// Three spaces nest under both "- " and "1. " items
+			indent := strings.Repeat("   ", len(match[1])-1)
+			converted = indent + marker + strings.ReplaceAll(wikiInline(match[2]), "\n", "\n"+indent+"   ")
+		case strings.HasPrefix(trimmed, "|"):
+			row, columns := wikiTableRow(trimmed)
+			converted = row
+			if !inTable {
+				// Markdown tables need a header row; the first row is used as one
+				converted += "\n|" + strings.Repeat(" --- |", columns)
+			}
+			inTable = true
+		default:
+			converted = wikiInline(trimmed)
+		}
+		emit(converted)
+	}
+	if codeMacro != "" {
+		flushCode()
+	}
+	if quoteMacro != "" {
+		flushQuote()
+	}
+
+	return strings.TrimSpace(collapseBlankLines(strings.Join(out, "\n")))
+}
+
+// parseMacro parses a line that consists of a single block macro like {code:java}
+func parseMacro(line string) (name, params string, ok bool) {
+	if !wikiBlockMacro.MatchString(line) || wikiBlockMacro.FindString(line) != line {
+		return "", "", false
+	}
+	inner := strings.TrimSuffix(strings.TrimPrefix(line, "{"), "}")
+	name, params, _ = strings.Cut(inner, ":")
+	return name, params, true
+}
+
+// codeLanguage returns the language of a {code} macro: {code:java} or {code:language=java}
+func codeLanguage(name, params string) string {
+	if name != "code" {
+		return ""
+	}
+	for _, param := range strings.Split(params, "|") {
+		key, value, hasValue := strings.Cut(param, "=")
+		if !hasValue {
+			return strings.TrimSpace(key)
+		}
+		if key == "language" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// macroTitle returns the heading shown for a quoted macro: the title parameter or the admonition type
+func macroTitle(name, params string) string {
+	for _, param := range strings.Split(params, "|") {
+		if key, value, ok := strings.Cut(param, "="); ok && key == "title" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return wikiPanelTitles[name]
+}
+
+// wikiTableRow converts a table row and returns it with its number of cells.
+// "||" separated header cells are treated like normal cells.
+func wikiTableRow(line string) (string, int) {
+	line = strings.ReplaceAll(line, "||", "|")
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+
+	// Links contain "|" as well, convert them before splitting the cells
+	line = wikiInline(line)
+	cells := strings.Split(line, "|")
+	for i, cell := range cells {
+		cells[i] = strings.ReplaceAll(strings.TrimSpace(cell), "\n", "<br>")
+	}
+	return "| " + strings.Join(cells, " | ") + " |", len(cells)
+}
+
+// wikiInline converts inline wiki markup: emphasis, monospace, links, mentions and images
+func wikiInline(text string) string {
+	// Monospace text and link targets are protected from emphasis conversion
+	var protected []string
+	protect := func(value string) string {
+		protected = append(protected, value)
+		return fmt.Sprintf("\x00%d\x00", len(protected)-1)
+	}
+
+	text = wikiMonospace.ReplaceAllStringFunc(text, func(match string) string {
+		return protect("`" + wikiMonospace.FindStringSubmatch(match)[1] + "`")
+	})
+	text = wikiMention.ReplaceAllStringFunc(text, func(match string) string {
+		return protect("@" + wikiMention.FindStringSubmatch(match)[1])
+	})
+	text = wikiLink.ReplaceAllStringFunc(text, func(match string) string {
+		parts := wikiLink.FindStringSubmatch(match)
+		return "[" + parts[1] + "](" + protect(strings.TrimSpace(parts[2])) + ")"
+	})
+	text = wikiURL.ReplaceAllStringFunc(text, func(match string) string {
+		return protect(wikiURL.FindStringSubmatch(match)[1])
+	})
+	text = wikiImage.ReplaceAllStringFunc(text, func(match string) string {
+		return protect("[attachment: " + wikiImage.FindStringSubmatch(match)[1] + "]")
+	})
+	text = wikiColor.ReplaceAllString(text, "")
+	text = strings.ReplaceAll(text, `\\`, "\n")
+
+	// Adjacent matches share a boundary character, so replace until nothing changes
+	for _, rule := range []struct {
+		pattern *regexp.Regexp
+		marker  string
+	}{
+		{wikiStrong, "\x01"},
+		{wikiEmphasis, "\x02"},
+		{wikiStrike, "~~"},
+		{wikiUnderline, ""},
+	} {
+		for {
+			replaced := rule.pattern.ReplaceAllString(text, "${1}"+rule.marker+"${2}"+rule.marker+"${3}")
+			if replaced == text {
+				break
+			}
+			text = replaced
+		}
+	}
+	text = strings.NewReplacer("\x01", "**", "\x02", "*").Replace(text)
+
+	for i, value := range protected {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), value, 1)
+	}
+	return text
+}
+
+// collapseBlankLines reduces runs of blank lines to a single blank line
+func collapseBlankLines(text string) string {
+	for strings.Contains(text, "\n\n\n") {
+		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
+	}
+	return text
+}
@@ -0,0 +1,9 @@
+package jira
+
+import "testing"
+
+func TestWikiToMarkdownGolden(t *testing.T) {
+	runGolden(t, "wiki", ".txt", func(data []byte) (string, error) {
+		return WikiToMarkdown(string(data)), nil
+	})
+}
@@ -0,0 +1,274 @@
+package linear
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+// issueQuery fetches an issue with its labels, parent, sub-issues and comments
+const issueQuery = `query Issue($id: String!) {
+  issue(id: $id) {
+    id
+    identifier
+    title
+    description
+    url
+    priorityLabel
+    state { name }
+    creator { name }
+    assignee { name }
+    labels { nodes { name } }
+    parent { identifier title state { name } }
+    children { nodes { identifier title state { name } } }
+    comments { nodes { body createdAt user { name } } }
+  }
+}`
+
+// Client handles Linear GraphQL API operations
+type Client struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+	log     logger.Logger
+}
+
+// graphQLRequest is the body of a GraphQL request
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type namedNode struct {
+	Name string `json:"name"`
+}
+
+type issueRefNode struct {
+	Identifier string    `json:"identifier"`
+	Title      string    `json:"title"`
+	State      namedNode `json:"state"`
+}
+
+// IssueResponse represents the response from the Linear API for a single issue
+type IssueResponse struct {
+	Data struct {
+		Issue *struct {
+			ID            string     `json:"id"`
+			Identifier    string     `json:"identifier"`
+			Title         string     `json:"title"`
+			Description   string     `json:"description"`
+			URL           string     `json:"url"`
+			PriorityLabel string     `json:"priorityLabel"`
+			State         namedNode  `json:"state"`
+			Creator       *namedNode `json:"creator"`
+			Assignee      *namedNode `json:"assignee"`
+			Labels        struct {
+				Nodes []namedNode `json:"nodes"`
+			} `json:"labels"`
+			Parent   *issueRefNode `json:"parent"`
+			Children struct {
+				Nodes []issueRefNode `json:"nodes"`
+			} `json:"children"`
+			Comments struct {
+				Nodes []struct {
+					Body      string     `json:"body"`
+					CreatedAt string     `json:"createdAt"`
+					User      *namedNode `json:"user"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"issue"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// IssueRef is a parent or sub-issue of an issue
+type IssueRef struct {
+	Identifier string
+	Title      string
+	State      string
+}
+
+// Comment represents a single comment on a Linear issue
+type Comment struct {
+	Body      string
+	Author    string
+	CreatedAt string
+}
+
+// Issue represents a simplified issue structure for our use
+type Issue struct {
+	ID          string
+	Identifier  string
+	Title       string
+	Description string
+	State       string
+	Priority    string
+	Creator     string
+	Assignee    string
+	Labels      []string
+	URL         string
+	Parent      *IssueRef
+	SubIssues   []IssueRef
+	Comments    []Comment
+}
+
+// NewClient creates a new Linear API client
+func NewClient(apiKey string) *Client {
+	return &Client{
+		baseURL: "https://api.linear.app/graphql",
+		apiKey:  apiKey,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		log: logger.Stdout,
+	}
+}
+
+// SetTransport sets the HTTP transport, e.g. for caching or rate limiting
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.client.Transport = rt
+}
+
+// SetLogger sets where progress messages are written
+func (c *Client) SetLogger(l logger.Logger) {
+	c.log = logger.OrDefault(l)
+}
+
+// GetIssue fetches an issue by identifier (e.g. ENG-123) or ID from Linear
+func (c *Client) GetIssue(issueID string) (*Issue, error) {
+	jsonData, err := json.Marshal(graphQLRequest{
+		Query:     issueQuery,
+		Variables: map[string]interface{}{"id": issueID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.baseURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Personal API keys are sent without a Bearer prefix
+	req.Header.Set("Authorization", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Linear API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var issueResp IssueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&issueResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if len(issueResp.Errors) > 0 {
+		messages := make([]string, 0, len(issueResp.Errors))
+		for _, e := range issueResp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return nil, fmt.Errorf("Linear API error: %s", strings.Join(messages, "; "))
+	}
+
+	data := issueResp.Data.Issue
+	if data == nil {
+		return nil, fmt.Errorf("Linear issue %s not found", issueID)
+	}
+
+	// Convert to our simplified Issue structure
+	issue := &Issue{
+		ID:          data.ID,
+		Identifier:  data.Identifier,
+		Title:       data.Title,
+		Description: data.Description,
+		State:       data.State.Name,
+		Priority:    data.PriorityLabel,
+		URL:         data.URL,
+	}
+	if data.Creator != nil {
+		issue.Creator = data.Creator.Name
+	}
+	if data.Assignee != nil {
+		issue.Assignee = data.Assignee.Name
+	}
+	for _, label := range data.Labels.Nodes {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	if data.Parent != nil {
+		issue.Parent = &IssueRef{Identifier: data.Parent.Identifier, Title: data.Parent.Title, State: data.Parent.State.Name}
+	}
+	for _, child := range data.Children.Nodes {
+		issue.SubIssues = append(issue.SubIssues, IssueRef{Identifier: child.Identifier, Title: child.Title, State: child.State.Name})
+	}
+	for _, node := range data.Comments.Nodes {
+		comment := Comment{Body: node.Body, CreatedAt: node.CreatedAt}
+		if node.User != nil {
+			comment.Author = node.User.Name
+		}
+		issue.Comments = append(issue.Comments, comment)
+	}
+
+	// Linear returns the newest comments first, show the conversation in order
+	sort.SliceStable(issue.Comments, func(i, j int) bool {
+		return issue.Comments[i].CreatedAt < issue.Comments[j].CreatedAt
+	})
+
+	return issue, nil
+}
+
+// FormatIssueDescription formats the issue information into a structured description
+func (i *Issue) FormatIssueDescription() string {
+	var builder strings.Builder
+
+	builder.WriteString(fmt.Sprintf(`**Linear Issue: %s - %s**
+
+**Issue ID:** %s
+**State:** %s
+**Priority:** %s
+**Labels:** %s
+**Creator:** %s
+**Assignee:** %s
+**Issue URL:** %s
+`, i.Title, i.Identifier, i.Identifier, i.State, i.Priority, strings.Join(i.Labels, ", "), i.Creator, i.Assignee, i.URL))
+
+	if i.Parent != nil {
+		builder.WriteString(fmt.Sprintf("**Parent Issue:** %s - %s (%s)\n", i.Parent.Identifier, i.Parent.Title, i.Parent.State))
+	}
+	if len(i.SubIssues) > 0 {
+		builder.WriteString("**Sub-issues:**\n")
+		for _, sub := range i.SubIssues {
+			builder.WriteString(fmt.Sprintf("- %s - %s (%s)\n", sub.Identifier, sub.Title, sub.State))
+		}
+	}
+
+	builder.WriteString(fmt.Sprintf("\n**Description:**\n%s", i.Description))
+
+	// Add comments if available
+	if len(i.Comments) > 0 {
+		builder.WriteString("\n\n**Comments:**\n")
+		for idx, comment := range i.Comments {
+			builder.WriteString("\n---\n")
+			builder.WriteString(fmt.Sprintf("**Comment %d** (by %s on %s):\n", idx+1, comment.Author, comment.CreatedAt))
+			builder.WriteString(comment.Body)
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,94 @@
+package linear
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+func newTestClient(t *testing.T, response string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "lin_api_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Variables["id"] != "ENG-42" {
+			t.Errorf("unexpected request: %+v (%v)", req, err)
+		}
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient("lin_api_test")
+	client.baseURL = server.URL
+	client.SetLogger(logger.Discard)
+	return client
+}
+
+func TestGetIssue(t *testing.T) {
+	client := newTestClient(t, `{"data": {"issue": {
+		"id": "uuid-42", "identifier": "ENG-42", "title": "Add SSO login",
+		"description": "Support **SAML** providers.", "url": "https://linear.app/acme/issue/ENG-42",
+		"priorityLabel": "High", "state": {"name": "In Progress"},
+		"creator": {"name": "Ada"}, "assignee": null,
+		"labels": {"nodes": [{"name": "auth"}, {"name": "backend"}]},
+		"parent": {"identifier": "ENG-40", "title": "Enterprise auth", "state": {"name": "Todo"}},
+		"children": {"nodes": [{"identifier": "ENG-43", "title": "Okta", "state": {"name": "Done"}}]},
+		"comments": {"nodes": [
+			{"body": "Second", "createdAt": "2024-05-02T10:00:00Z", "user": {"name": "Bob"}},
+			{"body": "First", "createdAt": "2024-05-01T10:00:00Z", "user": {"name": "Ada"}}
+		]}
+	}}}`)
+
+	issue, err := client.GetIssue("ENG-42")
+	if err != nil {
+		t.Fatalf("GetIssue() error = %v", err)
+	}
+	if issue.Title != "Add SSO login" || issue.State != "In Progress" || issue.Assignee != "" {
+		t.Errorf("issue = %+v", issue)
+	}
+	if len(issue.Comments) != 2 || issue.Comments[0].Body != "First" {
+		t.Errorf("comments = %+v, want oldest first", issue.Comments)
+	}
+
+	formatted := issue.FormatIssueDescription()
+	for _, want := range []string{
+		"**Linear Issue: Add SSO login - ENG-42**",
+		"**Labels:** auth, backend",
+		"**Parent Issue:** ENG-40 - Enterprise auth (Todo)",
+		"- ENG-43 - Okta (Done)",
+		"Support **SAML** providers.",
+		"**Comment 2** (by Bob on 2024-05-02T10:00:00Z):\nSecond",
+	} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("formatted description missing %q:\n%s", want, formatted)
+		}
+	}
+}
+
+func TestGetIssueErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{name: "GraphQL error", response: `{"errors": [{"message": "Entity not found"}]}`, want: "Entity not found"},
+		{name: "missing issue", response: `{"data": {"issue": null}}`, want: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestClient(t, tt.response).GetIssue("ENG-42")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("GetIssue() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
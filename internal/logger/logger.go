@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Logger receives the progress messages emitted by the internal packages
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// writerLogger writes progress messages to an io.Writer
+type writerLogger struct {
+	w io.Writer
+}
+
+// Printf writes a formatted message to the underlying writer
+func (l *writerLogger) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(l.w, format, args...)
+}
+
+// New creates a logger that writes to w
+func New(w io.Writer) Logger {
+	return &writerLogger{w: w}
+}
+
+// Stdout is the default logger, it preserves the historical console output
+var Stdout = New(os.Stdout)
+
+// Discard drops every message
+var Discard = New(io.Discard)
+
+// Func adapts a plain function to the Logger interface
+type Func func(format string, args ...interface{})
+
+// Printf calls f
+func (f Func) Printf(format string, args ...interface{}) {
+	f(format, args...)
+}
+
+// OrDefault returns l, or Stdout when l is nil
+func OrDefault(l Logger) Logger {
+	if l == nil {
+		return Stdout
+	}
+	return l
+}
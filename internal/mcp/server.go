@@ -0,0 +1,241 @@
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/redact"
+)
+
+// ProtocolVersion is the MCP revision implemented by the server
+const ProtocolVersion = "2024-11-05"
+
+// maxMessageSize limits the size of a single JSON-RPC message
+const maxMessageSize = 10 << 20
+
+// JSON-RPC 2.0 error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Config holds the settings shared by all tool calls
+type Config struct {
+	// Settings is the resolved pullpoet configuration (provider, language, trackers...)
+	Settings *config.Config
+	// NewAIClient creates the AI client for a tool call
+	NewAIClient func() (ai.Client, error)
+	// Redactor replaces secrets before prompts are sent; nil disables redaction
+	Redactor      *redact.Redactor
+	FailOnSecrets bool
+	// Fast uses native git commands instead of go-git for branch diffs
+	Fast    bool
+	Version string
+	// Log receives progress messages; it must not write to the protocol stream
+	Log logger.Logger
+}
+
+// Server answers MCP requests over newline-delimited JSON-RPC
+type Server struct {
+	config Config
+	log    logger.Logger
+	tools  []tool
+}
+
+// New creates an MCP server exposing the pullpoet tools
+func New(cfg Config) *Server {
+	if cfg.Settings == nil {
+		cfg.Settings = &config.Config{}
+	}
+	s := &Server{
+		config: cfg,
+		log:    logger.OrDefault(cfg.Log),
+	}
+	s.tools = s.registerTools()
+	return s
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Serve reads requests from in and writes responses to out until in is closed
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	encoder := json.NewEncoder(out)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		resp := s.handle(line)
+		if resp == nil {
+			continue
+		}
+		if err := encoder.Encode(resp); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle processes a single message; notifications return nil
+func (s *Server) handle(message []byte) *response {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return errorResponse(json.RawMessage("null"), codeParseError, "parse error: "+err.Error())
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return errorResponse(idOrNull(req.ID), codeInvalidRequest, "invalid request")
+	}
+
+	// Notifications have no id and never get a response
+	if len(req.ID) == 0 {
+		return nil
+	}
+
+	var result interface{}
+	var rpcErr *rpcError
+	switch req.Method {
+	case "initialize":
+		result = s.initialize()
+	case "ping":
+		result = struct{}{}
+	case "tools/list":
+		result = s.listTools()
+	case "tools/call":
+		result, rpcErr = s.callTool(req.Params)
+	default:
+		rpcErr = &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr.Code, rpcErr.Message)
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// initialize answers the MCP handshake
+func (s *Server) initialize() interface{} {
+	version := s.config.Version
+	if version == "" {
+		version = "dev"
+	}
+	return map[string]interface{}{
+		"protocolVersion": ProtocolVersion,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    "pullpoet",
+			"version": version,
+		},
+	}
+}
+
+// listTools describes the available tools and their input schemas
+func (s *Server) listTools() interface{} {
+	tools := make([]map[string]interface{}, 0, len(s.tools))
+	for _, t := range s.tools {
+		tools = append(tools, map[string]interface{}{
+			"name":        t.name,
+			"description": t.description,
+			"inputSchema": t.inputSchema,
+		})
+	}
+	return map[string]interface{}{"tools": tools}
+}
+
+// callTool runs a tool; tool failures are reported in the result so the caller can see them
+func (s *Server) callTool(params json.RawMessage) (interface{}, *rpcError) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+
+	var selected *tool
+	for i := range s.tools {
+		if s.tools[i].name == call.Name {
+			selected = &s.tools[i]
+			break
+		}
+	}
+	if selected == nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + call.Name}
+	}
+
+	arguments := call.Arguments
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage("{}")
+	}
+
+	s.log.Printf("🔧 Running tool %s\n", call.Name)
+	value, err := selected.handler(arguments)
+	if err != nil {
+		s.log.Printf("❌ Tool %s failed: %v\n", call.Name, err)
+		return toolError(err), nil
+	}
+
+	text, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return toolError(fmt.Errorf("failed to encode result: %w", err)), nil
+	}
+	return map[string]interface{}{
+		"content": []map[string]string{
+			{"type": "text", "text": string(text)},
+		},
+		"structuredContent": value,
+		"isError":           false,
+	}, nil
+}
+
+// toolError wraps a tool failure in a tool result
+func toolError(err error) interface{} {
+	return map[string]interface{}{
+		"content": []map[string]string{
+			{"type": "text", "text": err.Error()},
+		},
+		"isError": true,
+	}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
@@ -0,0 +1,209 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+// fakeAIClient returns a canned response and records the prompt
+type fakeAIClient struct {
+	response string
+	prompt   string
+}
+
+func (c *fakeAIClient) Complete(request ai.Request) (string, error) {
+	c.prompt = request.Text()
+	return c.response, nil
+}
+
+func (c *fakeAIClient) GetProviderInfo() (string, string) {
+	return "fake", "fake-model"
+}
+
+func newTestServer(client *fakeAIClient) *Server {
+	return New(Config{
+		Settings:    &config.Config{Provider: "fake", Model: "fake-model", Language: "en"},
+		NewAIClient: func() (ai.Client, error) { return client, nil },
+		Version:     "1.2.3",
+		Log:         logger.Discard,
+	})
+}
+
+// roundTrip sends newline-delimited messages and decodes every response
+func roundTrip(t *testing.T, s *Server, messages ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(strings.Join(messages, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var responses []map[string]interface{}
+	decoder := json.NewDecoder(&out)
+	for decoder.More() {
+		var resp map[string]interface{}
+		if err := decoder.Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServeProtocol(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		wantError float64
+		check     func(t *testing.T, result map[string]interface{})
+	}{
+		{
+			name:    "initialize",
+			message: `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`,
+			check: func(t *testing.T, result map[string]interface{}) {
+				if result["protocolVersion"] != ProtocolVersion {
+					t.Errorf("protocolVersion = %v, want %s", result["protocolVersion"], ProtocolVersion)
+				}
+				serverInfo := result["serverInfo"].(map[string]interface{})
+				if serverInfo["name"] != "pullpoet" || serverInfo["version"] != "1.2.3" {
+					t.Errorf("serverInfo = %v", serverInfo)
+				}
+			},
+		},
+		{
+			name:    "tools/list",
+			message: `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+			check: func(t *testing.T, result map[string]interface{}) {
+				var names []string
+				for _, item := range result["tools"].([]interface{}) {
+					names = append(names, item.(map[string]interface{})["name"].(string))
+				}
+				want := "describe_branch,describe_staged,fetch_issue,generate_commit_message"
+				if got := strings.Join(names, ","); got != want {
+					t.Errorf("tools = %s, want %s", got, want)
+				}
+			},
+		},
+		{
+			name:    "ping",
+			message: `{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		},
+		{
+			name:      "unknown method",
+			message:   `{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+			wantError: codeMethodNotFound,
+		},
+		{
+			name:      "unknown tool",
+			message:   `{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"deploy"}}`,
+			wantError: codeInvalidParams,
+		},
+		{
+			name:      "parse error",
+			message:   `{"jsonrpc":`,
+			wantError: codeParseError,
+		},
+		{
+			name:    "tool failure is reported in the result",
+			message: `{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"fetch_issue","arguments":{"key":"PROJ-1"}}}`,
+			check: func(t *testing.T, result map[string]interface{}) {
+				if result["isError"] != true {
+					t.Errorf("isError = %v, want true", result["isError"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := roundTrip(t, newTestServer(&fakeAIClient{}), tt.message)
+			if len(responses) != 1 {
+				t.Fatalf("got %d responses, want 1", len(responses))
+			}
+			resp := responses[0]
+
+			if tt.wantError != 0 {
+				rpcErr, ok := resp["error"].(map[string]interface{})
+				if !ok || rpcErr["code"] != tt.wantError {
+					t.Fatalf("response = %v, want error code %v", resp, tt.wantError)
+				}
+				return
+			}
+			result, ok := resp["result"].(map[string]interface{})
+			if !ok {
+				t.Fatalf("response = %v, want result", resp)
+			}
+			if tt.check != nil {
+				tt.check(t, result)
+			}
+		})
+	}
+}
+
+func TestServeIgnoresNotifications(t *testing.T) {
+	responses := roundTrip(t, newTestServer(&fakeAIClient{}),
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":"a","method":"ping"}`,
+	)
+	if len(responses) != 1 || responses[0]["id"] != "a" {
+		t.Errorf("responses = %v, want only the ping response", responses)
+	}
+}
+
+func TestGenerateCommitMessage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "login.go"), []byte("package demo\n\nfunc Login() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	client := &fakeAIClient{response: `{"title": "Add login function", "body": ""}`}
+	responses := roundTrip(t, newTestServer(client),
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"generate_commit_message","arguments":{}}}`)
+	if len(responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(responses))
+	}
+
+	result := responses[0]["result"].(map[string]interface{})
+	if result["isError"] != false {
+		t.Fatalf("tool failed: %v", result["content"])
+	}
+	structured := result["structuredContent"].(map[string]interface{})
+	if structured["message"] != "Add login function" {
+		t.Errorf("message = %v, want %q", structured["message"], "Add login function")
+	}
+	files := structured["changed_files"].([]interface{})
+	if len(files) != 1 || files[0].(map[string]interface{})["path"] != "login.go" {
+		t.Errorf("changed_files = %v, want login.go", files)
+	}
+	if !strings.Contains(client.prompt, "func Login()") {
+		t.Error("prompt does not contain the staged diff")
+	}
+}
@@ -0,0 +1,348 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/issues"
+	"github.com/erkineren/pullpoet/internal/output"
+	"github.com/erkineren/pullpoet/internal/pr"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+)
+
+// tool is a callable MCP tool
+type tool struct {
+	name        string
+	description string
+	inputSchema map[string]interface{}
+	handler     func(arguments json.RawMessage) (interface{}, error)
+}
+
+// CommitMessage is the structured result of generate_commit_message
+type CommitMessage struct {
+	Subject      string               `json:"subject"`
+	Body         string               `json:"body"`
+	Message      string               `json:"message"`
+	ChangedFiles []output.ChangedFile `json:"changed_files"`
+	Usage        ai.Usage             `json:"usage"`
+	Redactions   []redact.Finding     `json:"redactions,omitempty"`
+}
+
+var jiraKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-\d+$`)
+
+// registerTools returns the tools exposed by the server
+func (s *Server) registerTools() []tool {
+	descriptionProperty := map[string]interface{}{
+		"type":        "string",
+		"description": "Optional task description or issue context to include in the prompt",
+	}
+
+	return []tool{
+		{
+			name:        "describe_branch",
+			description: "Generate a pull request title and description for the changes between two branches. Missing values are detected from the git repository in the server's working directory.",
+			inputSchema: objectSchema(map[string]interface{}{
+				"repo":        map[string]interface{}{"type": "string", "description": "Repository URL or path (default: origin of the current repository)"},
+				"source":      map[string]interface{}{"type": "string", "description": "Source branch (default: current branch)"},
+				"target":      map[string]interface{}{"type": "string", "description": "Target branch (default: default branch)"},
+				"description": descriptionProperty,
+			}),
+			handler: s.describeBranch,
+		},
+		{
+			name:        "describe_staged",
+			description: "Generate a title and description for the staged changes of the current repository.",
+			inputSchema: objectSchema(map[string]interface{}{
+				"description": descriptionProperty,
+			}),
+			handler: s.describeStaged,
+		},
+		{
+			name:        "fetch_issue",
+			description: "Fetch a Jira, Linear or GitHub/GitLab issue or ClickUp task with its comments, formatted as pullpoet sends it to the AI.",
+			inputSchema: objectSchema(map[string]interface{}{
+				"key":     map[string]interface{}{"type": "string", "description": "Issue key (e.g. PROJ-123, #42, owner/repo#42, an issue URL, or a prefixed reference like clickup:abc123)"},
+				"tracker": map[string]interface{}{"type": "string", "enum": []string{"jira", "clickup", "linear", "github", "gitlab"}, "description": "Tracker to query (default: detected from the key)"},
+			}, "key"),
+			handler: s.fetchIssue,
+		},
+		{
+			name:        "generate_commit_message",
+			description: "Generate a commit message (subject and body) for the staged changes of the current repository.",
+			inputSchema: objectSchema(map[string]interface{}{}),
+			handler:     s.generateCommitMessage,
+		},
+	}
+}
+
+// objectSchema builds a JSON schema for an object with the given properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// describeBranch runs the regular branch pipeline
+func (s *Server) describeBranch(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Repo        string `json:"repo"`
+		Source      string `json:"source"`
+		Target      string `json:"target"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	settings := s.config.Settings
+	repo := firstNonEmpty(args.Repo, settings.Repo)
+	source := firstNonEmpty(args.Source, settings.Source)
+	target := firstNonEmpty(args.Target, settings.Target)
+
+	if repo == "" || source == "" || target == "" {
+		gitClient := git.NewClient()
+		gitClient.SetLogger(s.log)
+		gitInfo, err := gitClient.GetGitInfoFromCurrentDir()
+		if err != nil {
+			return nil, fmt.Errorf("auto-detection failed: %w", err)
+		}
+		if !gitInfo.IsGitRepo {
+			return nil, fmt.Errorf("not in a git repository - please provide repo, source and target")
+		}
+		repo = firstNonEmpty(repo, gitInfo.RepoURL)
+		source = firstNonEmpty(source, gitInfo.CurrentBranch)
+		target = firstNonEmpty(target, gitInfo.DefaultBranch)
+	}
+
+	startedAt := time.Now()
+	var gitResult *git.GitResult
+	var err error
+	if s.config.Fast {
+		fastClient := git.NewFastClient()
+		fastClient.SetLogger(s.log)
+		gitResult, err = fastClient.GetDiffWithCommits(repo, source, target)
+	} else {
+		gitClient := git.NewClient()
+		gitClient.SetLogger(s.log)
+		gitResult, err = gitClient.GetDiffWithCommits(repo, source, target)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze git changes: %w", err)
+	}
+	gitDuration := time.Since(startedAt)
+
+	doc, err := s.describe(gitResult, firstNonEmpty(args.Description, settings.Description), repo, true)
+	if err != nil {
+		return nil, err
+	}
+	doc.Repository = pr.SanitizeRepoURL(repo)
+	doc.Source = source
+	doc.Target = target
+	doc.Timings.Git = gitDuration.Milliseconds()
+	doc.Timings.Total = time.Since(startedAt).Milliseconds()
+	return doc, nil
+}
+
+// describeStaged describes the staged changes like `pullpoet preview`
+func (s *Server) describeStaged(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	startedAt := time.Now()
+	gitResult, err := s.stagedChanges()
+	if err != nil {
+		return nil, err
+	}
+	gitDuration := time.Since(startedAt)
+
+	doc, err := s.describe(gitResult, firstNonEmpty(args.Description, s.config.Settings.Description), s.config.Settings.Repo, false)
+	if err != nil {
+		return nil, err
+	}
+	doc.Timings.Git = gitDuration.Milliseconds()
+	doc.Timings.Total = time.Since(startedAt).Milliseconds()
+	return doc, nil
+}
+
+// generateCommitMessage writes a commit message for the staged changes
+func (s *Server) generateCommitMessage(arguments json.RawMessage) (interface{}, error) {
+	gitResult, err := s.stagedChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	generator, err := s.newGenerator()
+	if err != nil {
+		return nil, err
+	}
+	result, err := generator.GenerateCommitMessage(gitResult, s.config.Settings.Language)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+
+	var doc output.Document
+	doc.SetGitResult(gitResult)
+
+	message := result.Title
+	if strings.TrimSpace(result.Body) != "" {
+		message += "\n\n" + result.Body
+	}
+	return &CommitMessage{
+		Subject:      result.Title,
+		Body:         result.Body,
+		Message:      message,
+		ChangedFiles: doc.ChangedFiles,
+		Usage:        result.Usage,
+		Redactions:   result.Redactions,
+	}, nil
+}
+
+// fetchIssue fetches a single issue from any configured tracker
+func (s *Server) fetchIssue(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Key     string `json:"key"`
+		Tracker string `json:"tracker"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	key := strings.TrimSpace(args.Key)
+	if key == "" {
+		return nil, fmt.Errorf("key is required")
+	}
+
+	registry := issues.NewRegistryFromConfig(s.config.Settings, s.log)
+	ref := issues.Ref{Tracker: strings.ToLower(args.Tracker), ID: key}
+	if ref.Tracker == "" {
+		refs, err := issues.ParseRefs(key, detectTracker(registry, key, s.config.Settings.Repo))
+		if err != nil {
+			return nil, fmt.Errorf("no issue tracker configured for %s (set Jira, ClickUp or Linear credentials, or pass tracker)", key)
+		}
+		if len(refs) != 1 {
+			return nil, fmt.Errorf("fetch_issue accepts a single key")
+		}
+		ref = refs[0]
+	}
+
+	fetched, failures, err := registry.Fetch([]issues.Ref{ref})
+	if err != nil {
+		return nil, err
+	}
+	if len(failures) > 0 {
+		return nil, failures[0].Err
+	}
+	return &fetched[0], nil
+}
+
+// detectTracker guesses the tracker of an unprefixed key from its format
+func detectTracker(registry *issues.Registry, key, repoURL string) string {
+	_, hasJira := registry.Get("jira")
+	_, hasLinear := registry.Get("linear")
+	_, hasClickUp := registry.Get("clickup")
+
+	switch {
+	case strings.Contains(key, "#") || strings.Contains(key, "://"):
+		return issues.DefaultTracker(repoURL)
+	case jiraKeyPattern.MatchString(key) && hasJira:
+		return "jira"
+	case jiraKeyPattern.MatchString(key) && hasLinear:
+		return "linear"
+	case hasClickUp:
+		return "clickup"
+	case hasJira:
+		return "jira"
+	case hasLinear:
+		return "linear"
+	}
+	return ""
+}
+
+// stagedChanges returns the staged diff of the current repository
+func (s *Server) stagedChanges() (*git.GitResult, error) {
+	gitClient := git.NewClient()
+	gitClient.SetLogger(s.log)
+	stagedDiff, err := gitClient.GetStagedDiff()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get staged changes: %w", err)
+	}
+	if stagedDiff == "" {
+		return nil, fmt.Errorf("no staged changes found, run 'git add' to stage your changes first")
+	}
+	return &git.GitResult{
+		Diff:          stagedDiff,
+		Commits:       []git.CommitInfo{},
+		DefaultBranch: s.config.Settings.Target,
+	}, nil
+}
+
+// describe generates a description and wraps it with the file stats of the diff
+func (s *Server) describe(gitResult *git.GitResult, issueContext, repoURL string, addSignature bool) (*output.Document, error) {
+	generator, err := s.newGenerator()
+	if err != nil {
+		return nil, err
+	}
+
+	startedAt := time.Now()
+	result, err := generator.Generate(gitResult, issueContext, repoURL, s.config.Settings.Language, addSignature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PR description: %w", err)
+	}
+
+	doc := &output.Document{
+		Provider: s.config.Settings.Provider,
+		Model:    s.config.Settings.Model,
+	}
+	if !s.config.Settings.ReviewersDisabled {
+		result.Reviewers = reviewers.Suggest(gitResult, reviewers.OptionsFromConfig(s.config.Settings))
+	}
+	doc.SetResult(result)
+	doc.SetGitResult(gitResult)
+	doc.Timings.Generation = time.Since(startedAt).Milliseconds()
+	return doc, nil
+}
+
+// newGenerator creates a PR generator with a fresh AI client
+func (s *Server) newGenerator() (*pr.Generator, error) {
+	if s.config.NewAIClient == nil {
+		return nil, fmt.Errorf("no AI provider configured")
+	}
+	aiClient, err := s.config.NewAIClient()
+	if err != nil {
+		return nil, err
+	}
+	generator := pr.NewGenerator(aiClient, s.config.Settings.SystemPrompt)
+	generator.SetLogger(s.log)
+	generator.SetRedactor(s.config.Redactor)
+	generator.SetFailOnSecrets(s.config.FailOnSecrets)
+	generator.SetLabels(s.config.Settings.Labels)
+	policy, err := pr.NewPolicy(s.config.Settings.Policy, nil)
+	if err != nil {
+		return nil, err
+	}
+	generator.SetPolicy(policy)
+	return generator, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
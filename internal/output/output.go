@@ -0,0 +1,170 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/pr"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+)
+
+// Format selects how the generated result is written to stdout
+type Format string
+
+// Supported output formats
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatJSON     Format = "json"
+)
+
+// ParseFormat validates a --format value, defaulting to text when empty
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s (supported: text, markdown, json)", value)
+	}
+}
+
+// MachineReadable reports whether stdout is reserved for the result document
+func (f Format) MachineReadable() bool {
+	return f == FormatJSON || f == FormatMarkdown
+}
+
+// Commit is a commit included in the analyzed range
+type Commit struct {
+	Hash      string    `json:"hash"`
+	ShortHash string    `json:"short_hash"`
+	Message   string    `json:"message"`
+	Author    string    `json:"author"`
+	Email     string    `json:"email"`
+	Date      time.Time `json:"date"`
+}
+
+// ChangedFile summarizes the changes of a single file in the diff
+type ChangedFile struct {
+	Path      string `json:"path"`
+	OldPath   string `json:"old_path,omitempty"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+	Binary    bool   `json:"binary,omitempty"`
+}
+
+// Timings holds the duration of each stage in milliseconds
+type Timings struct {
+	Issues     int64 `json:"issues_ms"`
+	Git        int64 `json:"git_ms"`
+	Generation int64 `json:"generation_ms"`
+	Total      int64 `json:"total_ms"`
+}
+
+// Document is the machine-readable result of a pullpoet run
+type Document struct {
+	Title           string               `json:"title"`
+	Body            string               `json:"body"`
+	Labels          []string             `json:"labels,omitempty"`
+	ChangeType      string               `json:"change_type,omitempty"`
+	Risk            *pr.Risk             `json:"risk,omitempty"`
+	BreakingChanges []string             `json:"breaking_changes,omitempty"`
+	MigrationNotes  string               `json:"migration_notes,omitempty"`
+	TestPlan        []string             `json:"test_plan,omitempty"`
+	Reviewers       []reviewers.Reviewer `json:"reviewers,omitempty"`
+	Provider        string               `json:"provider"`
+	Model           string               `json:"model"`
+	Repository      string               `json:"repository,omitempty"`
+	Source          string               `json:"source,omitempty"`
+	Target          string               `json:"target,omitempty"`
+	Commits         []Commit             `json:"commits"`
+	ChangedFiles    []ChangedFile        `json:"changed_files"`
+	Usage           ai.Usage             `json:"usage"`
+	Redactions      []redact.Finding     `json:"redactions,omitempty"`
+	Timings         Timings              `json:"timings"`
+
+	// markdown is the body with the structured fields rendered, written by the markdown format
+	markdown string
+}
+
+// SetResult fills the title, body and structured fields from the generated result
+func (d *Document) SetResult(result *pr.Result) {
+	d.Title = result.Title
+	d.Body = result.Body
+	d.markdown = result.Markdown()
+	d.Labels = result.Labels
+	d.ChangeType = result.ChangeType
+	d.Risk = result.Risk
+	d.BreakingChanges = result.BreakingChanges
+	d.MigrationNotes = result.MigrationNotes
+	d.TestPlan = result.TestPlan
+	d.Reviewers = result.Reviewers
+	d.Usage = result.Usage
+	d.Redactions = result.Redactions
+}
+
+// SetGitResult fills the commit list and changed files from the analyzed changes
+func (d *Document) SetGitResult(gitResult *git.GitResult) {
+	d.Commits = []Commit{}
+	d.ChangedFiles = []ChangedFile{}
+	if gitResult == nil {
+		return
+	}
+
+	for _, commit := range gitResult.Commits {
+		d.Commits = append(d.Commits, Commit{
+			Hash:      commit.Hash,
+			ShortHash: commit.ShortHash,
+			Message:   commit.Message,
+			Author:    commit.Author,
+			Email:     commit.Email,
+			Date:      commit.Date,
+		})
+	}
+
+	for _, file := range git.SplitDiff(gitResult.Diff) {
+		changed := ChangedFile{
+			Path:      file.Path,
+			Additions: file.Additions,
+			Deletions: file.Deletions,
+			Binary:    file.Binary,
+		}
+		if file.OldPath != file.Path {
+			changed.OldPath = file.OldPath
+		}
+		d.ChangedFiles = append(d.ChangedFiles, changed)
+	}
+}
+
+// Write renders the document to w in the given format
+func Write(w io.Writer, format Format, doc *Document) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode JSON output: %w", err)
+		}
+		return nil
+	case FormatMarkdown, FormatText:
+		body := doc.markdown
+		if body == "" {
+			body = doc.Body
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n\n%s\n", doc.Title, body); err != nil {
+			return fmt.Errorf("failed to write markdown output: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
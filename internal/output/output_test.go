@@ -0,0 +1,90 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/pr"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{input: "", want: FormatText},
+		{input: "text", want: FormatText},
+		{input: "JSON", want: FormatJSON},
+		{input: "md", want: FormatMarkdown},
+		{input: "markdown", want: FormatMarkdown},
+		{input: "yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	doc := &Document{Title: "Add feature", Body: "Details", Provider: "Ollama", Model: "llama3.2"}
+	doc.SetGitResult(&git.GitResult{
+		Diff:    "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1,2 @@\n package main\n+// comment\n",
+		Commits: []git.CommitInfo{{Hash: "abc123", ShortHash: "abc", Message: "Add comment"}},
+	})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, doc); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	var decoded Document
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded.Title != doc.Title || decoded.Body != doc.Body {
+		t.Errorf("title/body = %q/%q, want %q/%q", decoded.Title, decoded.Body, doc.Title, doc.Body)
+	}
+	if len(decoded.Commits) != 1 || decoded.Commits[0].Hash != "abc123" {
+		t.Errorf("commits = %+v, want one commit abc123", decoded.Commits)
+	}
+	if len(decoded.ChangedFiles) != 1 || decoded.ChangedFiles[0].Path != "main.go" || decoded.ChangedFiles[0].Additions != 1 {
+		t.Errorf("changed_files = %+v, want main.go with one addition", decoded.ChangedFiles)
+	}
+}
+
+func TestWriteStructuredFields(t *testing.T) {
+	var doc Document
+	doc.SetResult(&pr.Result{Title: "Fix login", Body: "## Overview\nText", ChangeType: "fix"})
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, &doc); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	var decoded Document
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if decoded.Body != "## Overview\nText" || decoded.ChangeType != "fix" {
+		t.Errorf("body/change_type = %q/%q, want the body without the rendered fields", decoded.Body, decoded.ChangeType)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, FormatMarkdown, &doc); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := "# Fix login\n\n## Overview\nText\n\n## 🏷️ Change Summary\n- **Type**: fix\n"
+	if buf.String() != want {
+		t.Errorf("markdown = %q, want %q", buf.String(), want)
+	}
+}
@@ -5,65 +5,185 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"pullpoet/internal/ai"
-	"pullpoet/internal/git"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/redact"
 )
 
 //go:embed prompt.md
 var promptTemplate string
 
+//go:embed commit_prompt.md
+var commitPromptTemplate string
+
+// DefaultRepairAttempts is how often a response that does not match the schema is sent back to the model
+const DefaultRepairAttempts = 2
+
+// defaultTitleLength is the length titles are truncated to when no policy limits it
+const defaultTitleLength = 80
+
 // Generator handles PR description generation
 type Generator struct {
-	aiClient     ai.Client
-	customPrompt string
-}
-
-// Result represents the generated PR description
-type Result struct {
-	Title string
-	Body  string
+	aiClient      ai.Client
+	customPrompt  string
+	log           logger.Logger
+	redactor      *redact.Redactor
+	failOnSecrets bool
+	findings      []redact.Finding
+	// repairAttempts is the number of times an invalid response is sent back for repair
+	repairAttempts int
+	// labels are the labels the AI may suggest
+	labels []string
+	// policy constrains the generated title and body, nil if none is configured
+	policy *Policy
 }
 
 // NewGenerator creates a new PR generator
 func NewGenerator(aiClient ai.Client, customPrompt string) *Generator {
 	return &Generator{
-		aiClient:     aiClient,
-		customPrompt: customPrompt,
+		aiClient:       aiClient,
+		customPrompt:   customPrompt,
+		log:            logger.Stdout,
+		redactor:       redact.NewRedactor(),
+		repairAttempts: DefaultRepairAttempts,
 	}
 }
 
+// SetRepairAttempts sets how often an invalid response is sent back to the model; 0 disables repairs
+func (g *Generator) SetRepairAttempts(attempts int) {
+	if attempts >= 0 {
+		g.repairAttempts = attempts
+	}
+}
+
+// SetLabels sets the labels the AI may suggest; no labels are suggested if empty
+func (g *Generator) SetLabels(labels []string) {
+	g.labels = nonEmpty(labels)
+}
+
+// SetPolicy sets the policy the generated PR title and body must satisfy; nil disables it
+func (g *Generator) SetPolicy(policy *Policy) {
+	g.policy = policy
+}
+
+// SetRedactor sets the redactor applied to diffs, commits and issue context; nil disables redaction
+func (g *Generator) SetRedactor(r *redact.Redactor) {
+	g.redactor = r
+}
+
+// SetFailOnSecrets makes generation fail instead of sending redacted content when secrets are found
+func (g *Generator) SetFailOnSecrets(fail bool) {
+	g.failOnSecrets = fail
+}
+
+// SetLogger sets where progress messages are written
+func (g *Generator) SetLogger(l logger.Logger) {
+	g.log = logger.OrDefault(l)
+}
+
 // Generate creates a PR description based on the git diff and optional description
 func (g *Generator) Generate(gitResult *git.GitResult, issueContext, repoURL, language string, addSignature bool) (*Result, error) {
-	fmt.Println("   📝 Building unified AI prompt...")
+	g.log.Printf("   📝 Building unified AI prompt...\n")
 
-	prompt, err := g.buildUnifiedPrompt(gitResult, issueContext, repoURL, language)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	// The signature is added after generation, the body limit leaves room for it
+	policy := g.policy
+	if addSignature {
+		policy = policy.reserveBody(utf8.RuneCountInString(g.addPullpoetSignature("")))
 	}
 
-	fmt.Printf("   ✅ Unified prompt built (%d characters)\n", len(prompt))
-
-	response, err := g.aiClient.GenerateDescription(prompt)
+	g.findings = nil
+	request, err := g.buildRequest(gitResult, issueContext, repoURL, language, policy)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get AI response: %w", err)
+		return nil, fmt.Errorf("failed to build prompt: %w", err)
+	}
+	if err := g.checkFindings(); err != nil {
+		return nil, err
 	}
 
-	fmt.Println("   🔍 Parsing AI response...")
-	result, err := g.parseResponse(response)
+	g.log.Printf("   ✅ Unified prompt built (%d characters)\n", len(request.Text()))
+
+	result, err := g.complete(request, policy)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Println("   ✅ Response parsed successfully")
+	result.Redactions = g.findings
 
 	// Add pullpoet signature to the end of the PR body only if requested
 	if addSignature {
-		result.Body = g.addPullpoetSignature(result.Body)
+		result.signature = g.addPullpoetSignature("")
+		result.Body += result.signature
+	}
+
+	// The final body must satisfy the policy as well, e.g. the signature must not contain a forbidden word
+	if g.policy != nil {
+		if violations := g.policy.Check(result); len(violations) > 0 {
+			return nil, &PolicyError{Violations: violations}
+		}
 	}
 
 	return result, nil
 }
 
+// GenerateCommitMessage creates a commit subject (Title) and body from staged changes
+func (g *Generator) GenerateCommitMessage(gitResult *git.GitResult, language string) (*Result, error) {
+	g.log.Printf("   📝 Building commit message prompt...\n")
+
+	g.findings = nil
+	request := ai.Request{
+		System: g.systemInstructions(commitPromptTemplate, language),
+		User: []string{
+			g.buildDiffSection(gitResult),
+			"**Analyze the above diff and write the commit message following the JSON format specified in the instructions.**",
+		},
+		Schema: commitSchema,
+	}
+	if err := g.checkFindings(); err != nil {
+		return nil, err
+	}
+
+	result, err := g.complete(request, nil)
+	if err != nil {
+		return nil, err
+	}
+	result.Redactions = g.findings
+	return result, nil
+}
+
+// redact replaces secrets in text and records what was replaced
+func (g *Generator) redact(text string) string {
+	if g.redactor == nil || text == "" {
+		return text
+	}
+	redacted, findings := g.redactor.Redact(text)
+	g.findings = append(g.findings, findings...)
+	return redacted
+}
+
+// checkFindings reports redacted secrets and fails when secrets are not allowed
+func (g *Generator) checkFindings() error {
+	if len(g.findings) == 0 {
+		return nil
+	}
+
+	summary := make([]string, 0, len(g.findings))
+	for _, finding := range g.findings {
+		summary = append(summary, finding.String())
+	}
+	if g.failOnSecrets {
+		return fmt.Errorf("%w: %s", redact.ErrSecretsFound, strings.Join(summary, "; "))
+	}
+
+	g.log.Printf("   🔒 Redacted %d secret(s) before sending to AI:\n", len(g.findings))
+	for _, line := range summary {
+		g.log.Printf("      - %s\n", line)
+	}
+	return nil
+}
+
 // loadPromptTemplate loads the unified prompt template from the embedded content or custom file
 func (g *Generator) loadPromptTemplate() (string, error) {
 	// If custom prompt is provided, load it from file
@@ -79,48 +199,56 @@ func (g *Generator) loadPromptTemplate() (string, error) {
 	return promptTemplate, nil
 }
 
-// buildUnifiedPrompt constructs the prompt using the unified template
-func (g *Generator) buildUnifiedPrompt(gitResult *git.GitResult, issueContext, repoURL, language string) (string, error) {
+// buildRequest constructs the AI request: the unified template as system
+// instructions and the issue context, commits, diff and repository as user content
+func (g *Generator) buildRequest(gitResult *git.GitResult, issueContext, repoURL, language string, policy *Policy) (ai.Request, error) {
 	// Load the base prompt template
 	baseTemplate, err := g.loadPromptTemplate()
 	if err != nil {
-		return "", err
+		return ai.Request{}, err
 	}
 
-	var promptBuilder strings.Builder
-
-	// Add language instruction if not English
-	if language != "" && language != "en" {
-		languageInstruction := g.getLanguageInstruction(language)
-		promptBuilder.WriteString(languageInstruction)
-		promptBuilder.WriteString("\n\n")
+	request := ai.Request{
+		System: g.systemInstructions(baseTemplate, language),
+		Schema: resultSchema(g.labels),
+	}
+	if policy != nil {
+		request.System += "\n\n" + policy.Instructions()
 	}
-
-	// Add the base template
-	promptBuilder.WriteString(baseTemplate)
-	promptBuilder.WriteString("\n\n")
 
 	// Add context section
-	contextSection := g.buildContextSection(gitResult, issueContext)
-	promptBuilder.WriteString(contextSection)
+	if contextSection := g.buildContextSection(gitResult, issueContext); contextSection != "" {
+		request.User = append(request.User, contextSection)
+	}
 
 	// Add git diff section
-	diffSection := g.buildDiffSection(gitResult)
-	promptBuilder.WriteString(diffSection)
+	request.User = append(request.User, g.buildDiffSection(gitResult))
 
 	// Add repository information if available
 	if repoURL != "" {
 		repoInfo := extractRepoInfo(repoURL)
 		if repoInfo != "" {
-			promptBuilder.WriteString(fmt.Sprintf("**Repository**: %s\n", repoInfo))
-			promptBuilder.WriteString(fmt.Sprintf("**Default Branch**: %s\n\n", gitResult.DefaultBranch))
+			request.User = append(request.User, fmt.Sprintf("**Repository**: %s\n**Default Branch**: %s\n", repoInfo, gitResult.DefaultBranch))
 		}
 	}
 
+	// Labels are also part of the schema, but not every provider enforces it
+	if len(g.labels) > 0 {
+		request.User = append(request.User, fmt.Sprintf("**Allowed labels**: %s\n", strings.Join(g.labels, ", ")))
+	}
+
 	// Add final instruction
-	promptBuilder.WriteString("**Analyze the above information and create a professional PR description following the JSON format specified above.**")
+	request.User = append(request.User, "**Analyze the above information and create a professional PR description following the JSON format specified in the instructions.**")
 
-	return promptBuilder.String(), nil
+	return request, nil
+}
+
+// systemInstructions returns the template with the language instruction for non-English output
+func (g *Generator) systemInstructions(template, language string) string {
+	if language != "" && language != "en" {
+		return g.getLanguageInstruction(language) + "\n\n" + template
+	}
+	return template
 }
 
 // getLanguageInstruction returns the appropriate language instruction for the prompt
@@ -226,7 +354,7 @@ func (g *Generator) buildContextSection(gitResult *git.GitResult, issueContext s
 	if issueContext != "" {
 		contextBuilder.WriteString("## 📋 Issue/Task Context\n\n")
 		contextBuilder.WriteString("```\n")
-		contextBuilder.WriteString(issueContext)
+		contextBuilder.WriteString(g.redact(issueContext))
 		contextBuilder.WriteString("\n```\n\n")
 	}
 
@@ -235,7 +363,7 @@ func (g *Generator) buildContextSection(gitResult *git.GitResult, issueContext s
 		contextBuilder.WriteString("## 📝 Commit History\n\n")
 
 		for _, commit := range gitResult.Commits {
-			contextBuilder.WriteString(fmt.Sprintf("- **%s**: %s\n", commit.ShortHash, commit.Message))
+			contextBuilder.WriteString(fmt.Sprintf("- **%s**: %s\n", commit.ShortHash, g.redact(commit.Message)))
 			contextBuilder.WriteString(fmt.Sprintf("  *By %s on %s*\n", commit.Author, commit.Date.Format("2006-01-02 15:04")))
 		}
 		contextBuilder.WriteString("\n")
@@ -250,12 +378,17 @@ func (g *Generator) buildDiffSection(gitResult *git.GitResult) string {
 
 	diffBuilder.WriteString("## 🔍 Git Diff to Analyze\n\n")
 	diffBuilder.WriteString("```diff\n")
-	diffBuilder.WriteString(gitResult.Diff)
+	diffBuilder.WriteString(g.redact(gitResult.Diff))
 	diffBuilder.WriteString("\n```\n\n")
 
 	return diffBuilder.String()
 }
 
+// SanitizeRepoURL returns the repository URL in HTTPS form without embedded credentials
+func SanitizeRepoURL(repoURL string) string {
+	return extractRepoInfo(repoURL)
+}
+
 // extractRepoInfo extracts repository URL information from a git repository URL
 // and removes sensitive information like PAT tokens
 func extractRepoInfo(repoURL string) string {
@@ -298,161 +431,96 @@ func (g *Generator) addPullpoetSignature(body string) string {
 	return body + signature
 }
 
-// parseResponse extracts the title and body from the AI response using multiple parsing strategies
-func (g *Generator) parseResponse(response string) (*Result, error) {
-	fmt.Printf("   📊 AI response length: %d characters\n", len(response))
-
-	response = strings.TrimSpace(response)
-
-	// Clean up markdown headers that might be before JSON blocks
-	// Some AI models return: # ```json instead of ```json
-	response = strings.ReplaceAll(response, "# ```json", "```json")
-	response = strings.ReplaceAll(response, "## ```json", "```json")
-	response = strings.ReplaceAll(response, "### ```json", "```json")
-
-	// Try to parse as JSON first (multiple methods)
-	var jsonResult struct {
-		Title string `json:"title"`
-		Body  string `json:"body"`
-	}
-
-	// Method 1: Look for JSON block with ```json markers
-	if jsonStart := strings.Index(response, "```json"); jsonStart >= 0 {
-		jsonStart += len("```json")
-		if jsonEnd := strings.Index(response[jsonStart:], "```"); jsonEnd >= 0 {
-			jsonStr := strings.TrimSpace(response[jsonStart : jsonStart+jsonEnd])
-			err := json.Unmarshal([]byte(jsonStr), &jsonResult)
-			if err == nil {
-				fmt.Println("   ✅ Successfully parsed JSON from ```json block")
-				return &Result{
-					Title: cleanTitle(jsonResult.Title),
-					Body:  jsonResult.Body,
-				}, nil
-			}
-			fmt.Printf("   ⚠️  Found ```json block but JSON parsing failed: %v\n", err)
-		}
+// complete sends the request and parses the response. Responses that do not
+// match the schema or violate the policy are sent back to the model with the
+// problems to be repaired.
+func (g *Generator) complete(request ai.Request, policy *Policy) (*Result, error) {
+	// A policy title limit is enforced by repairs instead of truncation
+	titleLength := defaultTitleLength
+	if policy != nil && policy.titleMaxLength > 0 {
+		titleLength = 0
 	}
 
-	// Method 2: Look for any JSON object
-	jsonStart := strings.Index(response, "{")
-	jsonEnd := strings.LastIndex(response, "}")
-
-	if jsonStart >= 0 && jsonEnd > jsonStart {
-		jsonStr := response[jsonStart : jsonEnd+1]
-		if err := json.Unmarshal([]byte(jsonStr), &jsonResult); err == nil {
-			fmt.Println("   ✅ Successfully parsed JSON object")
-			return &Result{
-				Title: cleanTitle(jsonResult.Title),
-				Body:  jsonResult.Body,
-			}, nil
-		} else {
-			fmt.Printf("   ⚠️  Found JSON-like structure but parsing failed: %v\n", err)
-			previewLen := 100
-			if len(jsonStr) < previewLen {
-				previewLen = len(jsonStr)
-			}
-			fmt.Printf("   📄 Attempted to parse: %s...\n", jsonStr[:previewLen])
+	var usage ai.Usage
+	attempt := request
+	for repairs := 0; ; repairs++ {
+		response, err := g.aiClient.Complete(attempt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AI response: %w", err)
 		}
-	}
-
-	// Method 3: Try to extract from markdown structure
-	if strings.Contains(response, "# ") {
-		lines := strings.Split(response, "\n")
-		var title, body string
-		var bodyLines []string
-		titleFound := false
-
-		for i, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "# ") && !titleFound {
-				title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
-				titleFound = true
-				// Collect everything after the title as body
-				if i+1 < len(lines) {
-					bodyLines = lines[i+1:]
-				}
-				break
+		usage = usage.Add(ai.LastUsage(g.aiClient))
+
+		g.log.Printf("   🔍 Parsing AI response...\n")
+		result, err := g.parseResponse(request.Schema, response, titleLength)
+		if err == nil {
+			g.log.Printf("   ✅ Response parsed successfully\n")
+			result.Usage = usage
+			if policy == nil {
+				return result, nil
+			}
+			violations := policy.Check(result)
+			if len(violations) == 0 {
+				return result, nil
+			}
+			if repairs >= g.repairAttempts {
+				return nil, &PolicyError{Violations: violations}
 			}
-		}
 
-		if titleFound {
-			body = strings.TrimSpace(strings.Join(bodyLines, "\n"))
-			fmt.Println("   ✅ Successfully parsed markdown structure")
-			return &Result{
-				Title: cleanTitle(title),
-				Body:  body,
-			}, nil
+			g.log.Printf("   🔧 Response violates the policy (%d problem(s)), asking the model to fix it (%d/%d)...\n", len(violations), repairs+1, g.repairAttempts)
+			attempt.User = append(append([]string(nil), request.User...), policyInstruction(response, violations))
+			continue
+		}
+		if repairs >= g.repairAttempts {
+			return nil, fmt.Errorf("AI response does not match the expected format after %d repair attempt(s): %w", repairs, err)
 		}
-	}
 
-	// Method 4: Legacy TITLE:/BODY: format
-	if strings.HasPrefix(response, "TITLE:") {
-		lines := strings.Split(response, "\n")
-		var title, body string
+		g.log.Printf("   🔧 Invalid response (%v), asking the model to repair it (%d/%d)...\n", err, repairs+1, g.repairAttempts)
+		attempt.User = append(append([]string(nil), request.User...), repairInstruction(request.Schema, response, err))
+	}
+}
 
-		bodyStart := false
-		var bodyLines []string
+// repairInstruction asks the model to fix its previous response
+func repairInstruction(schema *ai.Schema, response string, problem error) string {
+	schemaJSON, _ := json.MarshalIndent(schema, "", "  ")
+	return fmt.Sprintf("Your previous response was:\n\n```\n%s\n```\n\nIt does not match the expected format: %v\n\nRespond again with only a JSON object that matches this schema:\n\n```json\n%s\n```", strings.TrimSpace(response), problem, schemaJSON)
+}
 
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "TITLE:") {
-				title = strings.TrimSpace(strings.TrimPrefix(line, "TITLE:"))
-			} else if strings.HasPrefix(line, "BODY:") {
-				bodyStart = true
-			} else if bodyStart {
-				bodyLines = append(bodyLines, line)
-			}
-		}
+// policyInstruction asks the model to fix the policy violations of its previous response
+func policyInstruction(response string, violations []string) string {
+	return fmt.Sprintf("Your previous response was:\n\n```\n%s\n```\n\nIt violates the policy for pull request descriptions:\n- %s\n\nRespond again with the same JSON format and fix every violation.", strings.TrimSpace(response), strings.Join(violations, "\n- "))
+}
 
-		body = strings.TrimSpace(strings.Join(bodyLines, "\n"))
+// parseResponse validates the response against the schema and extracts the result.
+// Titles are truncated to titleLength characters unless it is 0.
+func (g *Generator) parseResponse(schema *ai.Schema, response string, titleLength int) (*Result, error) {
+	g.log.Printf("   📊 AI response length: %d characters\n", len(response))
 
-		if title != "" {
-			fmt.Println("   ✅ Successfully parsed TITLE:/BODY: format")
-			return &Result{
-				Title: cleanTitle(title),
-				Body:  body,
-			}, nil
-		}
+	document, err := ai.Validate(schema, response)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fallback: extract from unstructured response
-	lines := strings.Split(strings.TrimSpace(response), "\n")
-	if len(lines) == 0 {
-		return nil, fmt.Errorf("empty response from AI")
+	var parsed resultDocument
+	if err := json.Unmarshal(document, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
 	}
-
-	// Find the first meaningful line as title
-	title := ""
-	bodyStartIndex := 0
-
-	for i, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && title == "" {
-			title = line
-			bodyStartIndex = i + 1
-			break
-		}
+	result := &Result{
+		Title:           cleanTitle(parsed.Title, titleLength),
+		Body:            parsed.Body,
+		Labels:          allowed(parsed.Labels, g.labels),
+		ChangeType:      parsed.ChangeType,
+		BreakingChanges: nonEmpty(parsed.BreakingChanges),
+		MigrationNotes:  strings.TrimSpace(parsed.MigrationNotes),
+		TestPlan:        nonEmpty(parsed.TestPlan),
 	}
-
-	// Clean up title
-	title = cleanTitle(title)
-
-	// Get body from remaining lines
-	var body string
-	if bodyStartIndex < len(lines) {
-		bodyLines := lines[bodyStartIndex:]
-		body = strings.TrimSpace(strings.Join(bodyLines, "\n"))
+	if parsed.Risk != nil && parsed.Risk.Level != "" {
+		result.Risk = parsed.Risk
 	}
-
-	fmt.Println("   ⚠️  Used fallback parsing method")
-	return &Result{
-		Title: title,
-		Body:  body,
-	}, nil
+	return result, nil
 }
 
-// cleanTitle removes common prefixes and limits length
-func cleanTitle(title string) string {
+// cleanTitle removes common prefixes and limits the length to maxLength if it is not 0
+func cleanTitle(title string, maxLength int) string {
 	// Remove common prefixes
 	prefixes := []string{"Title:", "PR Title:", "Pull Request Title:", "**Title:**", "📋 **Title:**"}
 	for _, prefix := range prefixes {
@@ -467,8 +535,8 @@ func cleanTitle(title string) string {
 	title = strings.TrimSpace(title)
 
 	// Limit title length
-	if len(title) > 80 {
-		title = title[:77] + "..."
+	if maxLength > 0 && len(title) > maxLength {
+		title = title[:maxLength-3] + "..."
 	}
 
 	return title
@@ -0,0 +1,187 @@
+package pr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/erkineren/pullpoet/config"
+)
+
+// conventionalCommitPattern is the title prefix used for title_prefix: conventional
+const conventionalCommitPattern = `(feat|fix|docs|style|refactor|perf|test|build|ci|chore|revert)(\([^)]+\))?!?: `
+
+// issueKeyPattern matches issue keys like HIP-123 or #123 when no issues were fetched
+var issueKeyPattern = regexp.MustCompile(`\b[A-Z][A-Z0-9]+-\d+\b|#\d+\b`)
+
+// Policy constrains the generated title and body
+type Policy struct {
+	titleMaxLength   int
+	titlePrefix      *regexp.Regexp
+	requireIssueKey  bool
+	issueKeys        []string
+	forbiddenWords   []*regexp.Regexp
+	words            []string
+	requiredSections []string
+	bodyMaxLength    int
+}
+
+// PolicyError lists the policy violations left after all repair attempts
+type PolicyError struct {
+	Violations []string
+}
+
+// Error returns the violations, one per line
+func (e *PolicyError) Error() string {
+	return "the generated description violates the policy:\n  - " + strings.Join(e.Violations, "\n  - ")
+}
+
+// NewPolicy compiles the policy of the config file. issueKeys are the keys of the
+// fetched issues; without them any issue key satisfies require_issue_key.
+// It returns nil if no rule is configured.
+func NewPolicy(settings *config.PolicyConfig, issueKeys []string) (*Policy, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	policy := &Policy{
+		titleMaxLength:   settings.TitleMaxLength,
+		requireIssueKey:  settings.RequireIssueKey,
+		issueKeys:        titleKeys(issueKeys),
+		requiredSections: nonEmpty(settings.RequiredSections),
+		bodyMaxLength:    settings.BodyMaxLength,
+	}
+
+	if prefix := settings.TitlePrefix; prefix != "" {
+		if strings.EqualFold(prefix, "conventional") {
+			prefix = conventionalCommitPattern
+		}
+		compiled, err := regexp.Compile(`^(?:` + prefix + `)`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy title_prefix %q: %w", settings.TitlePrefix, err)
+		}
+		policy.titlePrefix = compiled
+	}
+	for _, word := range nonEmpty(settings.ForbiddenWords) {
+		policy.words = append(policy.words, word)
+		policy.forbiddenWords = append(policy.forbiddenWords, regexp.MustCompile(`(?i)(^|\W)`+regexp.QuoteMeta(word)+`($|\W)`))
+	}
+
+	if policy.titleMaxLength == 0 && policy.titlePrefix == nil && !policy.requireIssueKey &&
+		len(policy.words) == 0 && len(policy.requiredSections) == 0 && policy.bodyMaxLength == 0 {
+		return nil, nil
+	}
+	return policy, nil
+}
+
+// reserveBody returns the policy with the body limit lowered by n characters
+// that are appended to the generated body later
+func (p *Policy) reserveBody(n int) *Policy {
+	if p == nil || p.bodyMaxLength == 0 {
+		return p
+	}
+	reserved := *p
+	reserved.bodyMaxLength = max(p.bodyMaxLength-n, 1)
+	return &reserved
+}
+
+// Instructions describe the policy for the system prompt
+func (p *Policy) Instructions() string {
+	var rules []string
+	if p.titleMaxLength > 0 {
+		rules = append(rules, fmt.Sprintf("The title must be at most %d characters.", p.titleMaxLength))
+	}
+	if p.titlePrefix != nil {
+		rules = append(rules, fmt.Sprintf("The title must start with text matching the regular expression `%s`.", strings.TrimSuffix(strings.TrimPrefix(p.titlePrefix.String(), "^(?:"), ")")))
+	}
+	if p.requireIssueKey {
+		if len(p.issueKeys) > 0 {
+			rules = append(rules, fmt.Sprintf("The title must contain the issue key %s.", strings.Join(p.issueKeys, " or ")))
+		} else {
+			rules = append(rules, "The title must contain an issue key, e.g. HIP-123 or #123.")
+		}
+	}
+	if len(p.words) > 0 {
+		rules = append(rules, fmt.Sprintf("Never use these words in the title or body: %s.", strings.Join(p.words, ", ")))
+	}
+	if len(p.requiredSections) > 0 {
+		rules = append(rules, fmt.Sprintf("The body must contain Markdown headings for these sections: %s.", strings.Join(p.requiredSections, ", ")))
+	}
+	if p.bodyMaxLength > 0 {
+		rules = append(rules, fmt.Sprintf("The body must be at most %d characters.", p.bodyMaxLength))
+	}
+	return "## 📏 Policy\n\n- " + strings.Join(rules, "\n- ")
+}
+
+// Check returns the violations of a result
+func (p *Policy) Check(result *Result) []string {
+	var violations []string
+	title, body := result.Title, result.Body
+
+	if length := utf8.RuneCountInString(title); p.titleMaxLength > 0 && length > p.titleMaxLength {
+		violations = append(violations, fmt.Sprintf("title is %d characters long, the maximum is %d", length, p.titleMaxLength))
+	}
+	if p.titlePrefix != nil && !p.titlePrefix.MatchString(title) {
+		violations = append(violations, fmt.Sprintf("title %q does not start with the required prefix %s", title, strings.TrimSuffix(strings.TrimPrefix(p.titlePrefix.String(), "^(?:"), ")")))
+	}
+	if p.requireIssueKey && !p.hasIssueKey(title) {
+		if len(p.issueKeys) > 0 {
+			violations = append(violations, fmt.Sprintf("title does not contain the issue key %s", strings.Join(p.issueKeys, " or ")))
+		} else {
+			violations = append(violations, "title does not contain an issue key")
+		}
+	}
+	for i, pattern := range p.forbiddenWords {
+		if pattern.MatchString(title) {
+			violations = append(violations, fmt.Sprintf("title contains the forbidden word %q", p.words[i]))
+		}
+		if pattern.MatchString(body) {
+			violations = append(violations, fmt.Sprintf("body contains the forbidden word %q", p.words[i]))
+		}
+	}
+	for _, section := range p.requiredSections {
+		if !hasSection(body, section) {
+			violations = append(violations, fmt.Sprintf("body has no %q section", section))
+		}
+	}
+	if length := utf8.RuneCountInString(body); p.bodyMaxLength > 0 && length > p.bodyMaxLength {
+		violations = append(violations, fmt.Sprintf("body is %d characters long, the maximum is %d", length, p.bodyMaxLength))
+	}
+	return violations
+}
+
+// hasIssueKey reports whether the title contains one of the issue keys, or any key if none are known
+func (p *Policy) hasIssueKey(title string) bool {
+	if len(p.issueKeys) == 0 {
+		return issueKeyPattern.MatchString(title)
+	}
+	for _, key := range p.issueKeys {
+		if strings.Contains(strings.ToLower(title), strings.ToLower(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// titleKeys returns the issue keys as they appear in titles: "owner/repo#123" is referenced as "#123"
+func titleKeys(issueKeys []string) []string {
+	var keys []string
+	for _, key := range nonEmpty(issueKeys) {
+		if i := strings.LastIndex(key, "#"); i > 0 {
+			key = key[i:]
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// hasSection reports whether the body has a Markdown heading containing the section name
+func hasSection(body, section string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") && strings.Contains(strings.ToLower(line), strings.ToLower(section)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package pr
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  config.PolicyConfig
+		issueKeys []string
+		title     string
+		body      string
+		want      []string
+	}{
+		{
+			name:     "title length in characters",
+			settings: config.PolicyConfig{TitleMaxLength: 10},
+			title:    "Äöü änderung",
+			want:     []string{"title is 12 characters long, the maximum is 10"},
+		},
+		{
+			name:     "conventional commits prefix",
+			settings: config.PolicyConfig{TitlePrefix: "conventional"},
+			title:    "feat(api)!: add login",
+		},
+		{
+			name:     "missing custom prefix",
+			settings: config.PolicyConfig{TitlePrefix: `\[[A-Z]+-\d+\] `},
+			title:    "Add login HIP-1",
+			want:     []string{`title "Add login HIP-1" does not start with the required prefix \[[A-Z]+-\d+\] `},
+		},
+		{
+			name:      "fetched issue key",
+			settings:  config.PolicyConfig{RequireIssueKey: true},
+			issueKeys: []string{"HIP-12"},
+			title:     "Add login (HIP-13)",
+			want:      []string{"title does not contain the issue key HIP-12"},
+		},
+		{
+			name:      "forge issue key",
+			settings:  config.PolicyConfig{RequireIssueKey: true},
+			issueKeys: []string{"acme/app#42"},
+			title:     "Add login (#42)",
+		},
+		{
+			name:     "any issue key without fetched issues",
+			settings: config.PolicyConfig{RequireIssueKey: true},
+			title:    "Add login",
+			want:     []string{"title does not contain an issue key"},
+		},
+		{
+			name:     "forbidden words as whole words",
+			settings: config.PolicyConfig{ForbiddenWords: []string{"wip", "hack"}},
+			title:    "WIP: add login",
+			body:     "No shortcuts, hackathon code removed",
+			want:     []string{`title contains the forbidden word "wip"`},
+		},
+		{
+			name:     "required sections and body length",
+			settings: config.PolicyConfig{RequiredSections: []string{"Summary", "Testing"}, BodyMaxLength: 20},
+			title:    "Add login",
+			body:     "## 📋 Summary\nAdds the login page",
+			want:     []string{`body has no "Testing" section`, "body is 32 characters long, the maximum is 20"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewPolicy(&tt.settings, tt.issueKeys)
+			if err != nil {
+				t.Fatalf("NewPolicy() error = %v", err)
+			}
+			got := policy.Check(&Result{Title: tt.title, Body: tt.body})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	if policy, err := NewPolicy(&config.PolicyConfig{ForbiddenWords: []string{" "}}, nil); policy != nil || err != nil {
+		t.Errorf("NewPolicy() = %v, %v, want no policy without rules", policy, err)
+	}
+	if _, err := NewPolicy(&config.PolicyConfig{TitlePrefix: "feat("}, nil); err == nil {
+		t.Error("NewPolicy() accepted an invalid title_prefix")
+	}
+}
+
+func TestGenerateEnforcesPolicy(t *testing.T) {
+	gitResult := &git.GitResult{Diff: "diff --git a/main.go b/main.go\n+package main\n"}
+	policy, err := NewPolicy(&config.PolicyConfig{TitlePrefix: "conventional", TitleMaxLength: 20}, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+
+	t.Run("repaired", func(t *testing.T) {
+		client := &scriptedClient{responses: []string{
+			`{"title": "Add the main package of the application", "body": "Adds main"}`,
+			`{"title": "feat: add main", "body": "Adds main"}`,
+		}}
+		generator := NewGenerator(client, "")
+		generator.SetLogger(logger.Discard)
+		generator.SetPolicy(policy)
+
+		result, err := generator.Generate(gitResult, "", "", "en", false)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if result.Title != "feat: add main" {
+			t.Errorf("Title = %q, want the repaired title", result.Title)
+		}
+		if len(client.requests) != 2 {
+			t.Fatalf("AI calls = %d, want 2", len(client.requests))
+		}
+		if !strings.Contains(client.requests[0].System, "The title must be at most 20 characters.") {
+			t.Error("system prompt does not describe the policy")
+		}
+		repair := client.requests[1].User[len(client.requests[1].User)-1]
+		if !strings.Contains(repair, "title is 39 characters long, the maximum is 20") {
+			t.Errorf("repair instruction does not list the violations:\n%s", repair)
+		}
+	})
+
+	t.Run("still violated", func(t *testing.T) {
+		client := &scriptedClient{responses: []string{`{"title": "Add main", "body": "Adds main"}`}}
+		generator := NewGenerator(client, "")
+		generator.SetLogger(logger.Discard)
+		generator.SetPolicy(policy)
+
+		_, err := generator.Generate(gitResult, "", "", "en", false)
+		var policyErr *PolicyError
+		if !errors.As(err, &policyErr) {
+			t.Fatalf("Generate() error = %v, want a PolicyError", err)
+		}
+		if len(client.requests) != DefaultRepairAttempts+1 {
+			t.Errorf("AI calls = %d, want %d", len(client.requests), DefaultRepairAttempts+1)
+		}
+		if len(policyErr.Violations) != 1 || !strings.Contains(policyErr.Violations[0], "required prefix") {
+			t.Errorf("Violations = %q", policyErr.Violations)
+		}
+	})
+	t.Run("body limit includes the signature", func(t *testing.T) {
+		client := &scriptedClient{responses: []string{
+			`{"title": "Add main", "body": "Adds the main package"}`,
+			`{"title": "Add main", "body": "Adds main"}`,
+		}}
+		generator := NewGenerator(client, "")
+		generator.SetLogger(logger.Discard)
+		limit := utf8.RuneCountInString(generator.addPullpoetSignature("")) + 10
+		policy, err := NewPolicy(&config.PolicyConfig{BodyMaxLength: limit}, nil)
+		if err != nil {
+			t.Fatalf("NewPolicy() error = %v", err)
+		}
+		generator.SetPolicy(policy)
+
+		result, err := generator.Generate(gitResult, "", "", "en", true)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if length := utf8.RuneCountInString(result.Body); length > limit {
+			t.Errorf("body is %d characters long, the maximum is %d", length, limit)
+		}
+		if len(client.requests) != 2 {
+			t.Errorf("AI calls = %d, want 2", len(client.requests))
+		}
+		if !strings.Contains(client.requests[0].System, "The body must be at most 10 characters.") {
+			t.Error("system prompt does not leave room for the signature")
+		}
+	})
+
+	t.Run("body limit does not count the structured fields", func(t *testing.T) {
+		client := &scriptedClient{responses: []string{
+			`{"title": "Add main", "body": "Adds the main package", "change_type": "feature", "test_plan": ["Run go test"]}`,
+		}}
+		generator := NewGenerator(client, "")
+		generator.SetLogger(logger.Discard)
+		policy, err := NewPolicy(&config.PolicyConfig{BodyMaxLength: utf8.RuneCountInString("Adds the main package")}, nil)
+		if err != nil {
+			t.Fatalf("NewPolicy() error = %v", err)
+		}
+		generator.SetPolicy(policy)
+
+		result, err := generator.Generate(gitResult, "", "", "en", false)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if len(client.requests) != 1 {
+			t.Errorf("AI calls = %d, want 1", len(client.requests))
+		}
+		if !strings.Contains(result.Markdown(), "## 🧪 Test Plan") {
+			t.Errorf("Markdown() = %q, want the rendered test plan", result.Markdown())
+		}
+	})
+
+	t.Run("structured fields do not satisfy required sections", func(t *testing.T) {
+		client := &scriptedClient{responses: []string{
+			`{"title": "Add main", "body": "## Summary\nAdds main", "test_plan": ["Run go test"]}`,
+			`{"title": "Add main", "body": "## Summary\nAdds main\n\n## Test Plan\nRun go test", "test_plan": ["Run go test"]}`,
+		}}
+		generator := NewGenerator(client, "")
+		generator.SetLogger(logger.Discard)
+		policy, err := NewPolicy(&config.PolicyConfig{RequiredSections: []string{"Summary", "Test Plan"}}, nil)
+		if err != nil {
+			t.Fatalf("NewPolicy() error = %v", err)
+		}
+		generator.SetPolicy(policy)
+
+		if _, err := generator.Generate(gitResult, "", "", "en", false); err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if len(client.requests) != 2 {
+			t.Fatalf("AI calls = %d, want 2", len(client.requests))
+		}
+		repair := client.requests[1].User[len(client.requests[1].User)-1]
+		if !strings.Contains(repair, `body has no "Test Plan" section`) {
+			t.Errorf("repair instruction does not list the missing section:\n%s", repair)
+		}
+	})
+
+	t.Run("signature violates the policy", func(t *testing.T) {
+		client := &scriptedClient{responses: []string{`{"title": "Add main", "body": "Adds main"}`}}
+		generator := NewGenerator(client, "")
+		generator.SetLogger(logger.Discard)
+		policy, err := NewPolicy(&config.PolicyConfig{ForbiddenWords: []string{"AI-powered"}}, nil)
+		if err != nil {
+			t.Fatalf("NewPolicy() error = %v", err)
+		}
+		generator.SetPolicy(policy)
+
+		_, err = generator.Generate(gitResult, "", "", "en", true)
+		var policyErr *PolicyError
+		if !errors.As(err, &policyErr) {
+			t.Fatalf("Generate() error = %v, want a PolicyError", err)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package pr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+)
+
+// ChangeTypes are the change types the AI chooses from
+var ChangeTypes = []string{"feature", "fix", "refactor", "performance", "docs", "test", "build", "ci", "chore"}
+
+// Risk levels of a change
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// Result represents the generated PR description
+type Result struct {
+	Title string
+	Body  string
+	// Labels are suggested from the configured label set
+	Labels     []string
+	ChangeType string
+	Risk       *Risk
+	// BreakingChanges lists changes that break existing users
+	BreakingChanges []string
+	MigrationNotes  string
+	// TestPlan lists the steps to verify the change
+	TestPlan []string
+	// Reviewers are suggested from CODEOWNERS and the git history, not by the AI
+	Reviewers []reviewers.Reviewer
+	Usage     ai.Usage
+	// Redactions lists the secrets replaced before the prompt was sent
+	Redactions []redact.Finding
+
+	// signature is the pullpoet signature at the end of Body, if any
+	signature string
+}
+
+// Risk is the estimated risk of merging a change
+type Risk struct {
+	Level     string `json:"level"`
+	Rationale string `json:"rationale"`
+}
+
+// resultDocument is the JSON document returned by the AI
+type resultDocument struct {
+	Title           string   `json:"title"`
+	Body            string   `json:"body"`
+	Labels          []string `json:"labels"`
+	ChangeType      string   `json:"change_type"`
+	Risk            *Risk    `json:"risk"`
+	BreakingChanges []string `json:"breaking_changes"`
+	MigrationNotes  string   `json:"migration_notes"`
+	TestPlan        []string `json:"test_plan"`
+}
+
+// commitSchema is the structured output requested for commit messages
+var commitSchema = &ai.Schema{
+	Type: "object",
+	Properties: map[string]*ai.Schema{
+		"title": {Type: "string", Description: "The commit subject line", MinLength: 1},
+		"body":  {Type: "string", Description: "The commit message body"},
+	},
+	Required: []string{"title", "body"},
+	Name:     "commit_message",
+	Order:    []string{"title", "body"},
+}
+
+// resultSchema returns the structured output requested for pull requests.
+// Labels are only requested when a label set is configured and must be one of them.
+func resultSchema(labels []string) *ai.Schema {
+	schema := &ai.Schema{
+		Type: "object",
+		Properties: map[string]*ai.Schema{
+			"title":       {Type: "string", Description: "A concise one-line title (max 80 characters)", MinLength: 1},
+			"body":        {Type: "string", Description: "The detailed Markdown description"},
+			"change_type": {Type: "string", Description: "The kind of change", Enum: ChangeTypes},
+			"risk": {
+				Type:        "object",
+				Description: "The risk of merging the change",
+				Properties: map[string]*ai.Schema{
+					"level":     {Type: "string", Enum: []string{RiskLow, RiskMedium, RiskHigh}},
+					"rationale": {Type: "string", Description: "One sentence explaining the risk level", MinLength: 1},
+				},
+				Required: []string{"level", "rationale"},
+				Order:    []string{"level", "rationale"},
+			},
+			"breaking_changes": {Type: "array", Description: "Changes that break existing users, empty if there are none", Items: &ai.Schema{Type: "string"}},
+			"migration_notes":  {Type: "string", Description: "What users have to do to upgrade, empty if nothing"},
+			"test_plan":        {Type: "array", Description: "Steps to verify the change", Items: &ai.Schema{Type: "string"}},
+		},
+		Required: []string{"title", "body"},
+		Name:     "pull_request",
+		Order:    []string{"title", "body", "change_type", "risk", "breaking_changes", "migration_notes", "test_plan"},
+	}
+	if len(labels) > 0 {
+		schema.Order = append([]string{"title", "body", "labels"}, schema.Order[2:]...)
+		schema.Properties["labels"] = &ai.Schema{
+			Type:        "array",
+			Description: "Labels that apply to the change, only from the allowed values",
+			Items:       &ai.Schema{Type: "string", Enum: labels},
+		}
+	}
+	return schema
+}
+
+// Details renders the structured fields as Markdown sections for the body
+func (r *Result) Details() string {
+	var sections []string
+
+	var summary []string
+	if r.ChangeType != "" {
+		summary = append(summary, fmt.Sprintf("- **Type**: %s", r.ChangeType))
+	}
+	if r.Risk != nil && r.Risk.Level != "" {
+		line := fmt.Sprintf("- **Risk**: %s %s", riskEmoji(r.Risk.Level), r.Risk.Level)
+		if r.Risk.Rationale != "" {
+			line += " – " + r.Risk.Rationale
+		}
+		summary = append(summary, line)
+	}
+	if len(r.Labels) > 0 {
+		summary = append(summary, fmt.Sprintf("- **Labels**: `%s`", strings.Join(r.Labels, "`, `")))
+	}
+	if len(summary) > 0 {
+		sections = append(sections, "## 🏷️ Change Summary\n"+strings.Join(summary, "\n"))
+	}
+
+	if len(r.BreakingChanges) > 0 {
+		sections = append(sections, "## ⚠️ Breaking Changes\n- "+strings.Join(r.BreakingChanges, "\n- "))
+	}
+	if notes := strings.TrimSpace(r.MigrationNotes); notes != "" {
+		sections = append(sections, "## 🔄 Migration Notes\n"+notes)
+	}
+	if len(r.TestPlan) > 0 {
+		sections = append(sections, "## 🧪 Test Plan\n- [ ] "+strings.Join(r.TestPlan, "\n- [ ] "))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// riskEmoji returns the traffic light of a risk level
+func riskEmoji(level string) string {
+	switch level {
+	case RiskHigh:
+		return "🔴"
+	case RiskMedium:
+		return "🟡"
+	default:
+		return "🟢"
+	}
+}
+
+// Markdown returns the body with the structured fields rendered before the signature,
+// as it is published. Body itself only holds what the model wrote.
+func (r *Result) Markdown() string {
+	body, signature := r.Body, ""
+	if r.signature != "" && strings.HasSuffix(body, r.signature) {
+		body, signature = strings.TrimSuffix(body, r.signature), r.signature
+	}
+	return withDetails(body, r) + signature
+}
+
+// withDetails appends the rendered structured fields to the body
+func withDetails(body string, result *Result) string {
+	details := result.Details()
+	if details == "" {
+		return body
+	}
+	if strings.TrimSpace(body) == "" {
+		return details
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + details
+}
+
+// allowed returns the labels that are in the label set, in its spelling and without
+// duplicates. Not every provider enforces the schema enum, so the labels are filtered again.
+func allowed(labels, set []string) []string {
+	var kept []string
+	for _, label := range nonEmpty(labels) {
+		for _, name := range set {
+			if strings.EqualFold(label, name) && !containsLabel(kept, name) {
+				kept = append(kept, name)
+			}
+		}
+	}
+	return kept
+}
+
+// containsLabel reports whether labels contains label
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// nonEmpty removes blank entries from a list
+func nonEmpty(values []string) []string {
+	var kept []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
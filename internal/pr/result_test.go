@@ -0,0 +1,147 @@
+package pr
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/logger"
+)
+
+func TestGenerateStructuredFields(t *testing.T) {
+	client := &scriptedClient{responses: []string{`{
+		"title": "Drop the v1 API",
+		"body": "## Overview\nRemoves the v1 endpoints.",
+		"labels": ["breaking-change"],
+		"change_type": "refactor",
+		"risk": {"level": "high", "rationale": "Clients of the v1 API stop working."},
+		"breaking_changes": ["The /v1 endpoints are removed"],
+		"migration_notes": "Call /v2 instead.",
+		"test_plan": ["Call /v1 and expect 404", "Call /v2"]
+	}`}}
+	generator := NewGenerator(client, "")
+	generator.SetLogger(logger.Discard)
+	generator.SetLabels([]string{"bug", "breaking-change"})
+
+	result, err := generator.Generate(&git.GitResult{Diff: "+package api\n"}, "", "", "en", false)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	labels := client.requests[0].Schema.Properties["labels"]
+	if labels == nil || !reflect.DeepEqual(labels.Items.Enum, []string{"bug", "breaking-change"}) {
+		t.Errorf("labels schema = %+v, want the configured labels as enum", labels)
+	}
+	if !reflect.DeepEqual(result.Labels, []string{"breaking-change"}) || result.ChangeType != "refactor" {
+		t.Errorf("Labels = %q, ChangeType = %q", result.Labels, result.ChangeType)
+	}
+	if result.Risk == nil || result.Risk.Level != RiskHigh {
+		t.Errorf("Risk = %+v, want high", result.Risk)
+	}
+
+	if result.Body != "## Overview\nRemoves the v1 endpoints." {
+		t.Errorf("Body = %q, want the body of the model only", result.Body)
+	}
+	wantMarkdown := "## Overview\nRemoves the v1 endpoints.\n\n" +
+		"## 🏷️ Change Summary\n- **Type**: refactor\n- **Risk**: 🔴 high – Clients of the v1 API stop working.\n- **Labels**: `breaking-change`\n\n" +
+		"## ⚠️ Breaking Changes\n- The /v1 endpoints are removed\n\n" +
+		"## 🔄 Migration Notes\nCall /v2 instead.\n\n" +
+		"## 🧪 Test Plan\n- [ ] Call /v1 and expect 404\n- [ ] Call /v2"
+	if got := result.Markdown(); got != wantMarkdown {
+		t.Errorf("Markdown() = %q, want %q", got, wantMarkdown)
+	}
+}
+
+func TestResultMarkdown(t *testing.T) {
+	const signature = "\n\n---\n\n*signature*"
+	tests := []struct {
+		name   string
+		result Result
+		want   string
+	}{
+		{
+			name:   "without structured fields",
+			result: Result{Body: "## Overview\nText"},
+			want:   "## Overview\nText",
+		},
+		{
+			name:   "structured fields after the body",
+			result: Result{Body: "## Overview\nText\n", ChangeType: "fix"},
+			want:   "## Overview\nText\n\n## 🏷️ Change Summary\n- **Type**: fix",
+		},
+		{
+			name:   "structured fields without a body",
+			result: Result{TestPlan: []string{"Run it"}},
+			want:   "## 🧪 Test Plan\n- [ ] Run it",
+		},
+		{
+			name:   "structured fields before the signature",
+			result: Result{Body: "## Overview\nText" + signature, ChangeType: "fix", signature: signature},
+			want:   "## Overview\nText\n\n## 🏷️ Change Summary\n- **Type**: fix" + signature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.Markdown(); got != tt.want {
+				t.Errorf("Markdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWithoutLabelSet(t *testing.T) {
+	client := &scriptedClient{responses: []string{`{"title": "Add main", "body": "Adds main", "risk": null, "labels": ["hotfix"]}`}}
+	generator := NewGenerator(client, "")
+	generator.SetLogger(logger.Discard)
+
+	result, err := generator.Generate(&git.GitResult{Diff: "+package main\n"}, "", "", "en", false)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, ok := client.requests[0].Schema.Properties["labels"]; ok {
+		t.Error("labels are requested without a configured label set")
+	}
+	for _, block := range client.requests[0].User {
+		if strings.Contains(block, "Allowed labels") {
+			t.Error("allowed labels are listed without a configured label set")
+		}
+	}
+	if len(result.Labels) != 0 {
+		t.Errorf("Labels = %q, want none without a configured label set", result.Labels)
+	}
+	if result.Body != "Adds main" || result.Risk != nil {
+		t.Errorf("result = %+v, want the body unchanged", result)
+	}
+}
+
+func TestGenerateWithoutLabelSetStrictSchema(t *testing.T) {
+	var requested map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&requested)
+		w.Write([]byte(`{"choices": [{"message": {"content": "{\"title\": \"Add main\", \"body\": \"Adds main\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	// gpt-4o receives the schema as a strict json_schema response format
+	client := ai.NewOpenWebUIClient(server.URL, "", "gpt-4o")
+	client.SetLogger(logger.Discard)
+	generator := NewGenerator(client, "")
+	generator.SetLogger(logger.Discard)
+
+	if _, err := generator.Generate(&git.GitResult{Diff: "+package main\n"}, "", "", "en", false); err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	format := requested["response_format"].(map[string]interface{})
+	schema := format["json_schema"].(map[string]interface{})["schema"].(map[string]interface{})
+	for _, name := range schema["required"].([]interface{}) {
+		if name == "labels" {
+			t.Error("labels are required without a configured label set")
+		}
+	}
+}
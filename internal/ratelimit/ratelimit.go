@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRetryAfter is the longest Retry-After delay that is waited for before retrying
+const maxRetryAfter = 30 * time.Second
+
+// Limiter spaces requests to the same host evenly
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+// New creates a limiter allowing perSecond requests per host; zero or less means no limit
+func New(perSecond float64) *Limiter {
+	limiter := &Limiter{next: make(map[string]time.Time)}
+	if perSecond > 0 {
+		limiter.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return limiter
+}
+
+// Wait blocks until a request to host is allowed
+func (l *Limiter) Wait(host string) {
+	if l.interval == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(slot))
+}
+
+// Transport rate limits requests per host and retries once on 429 Too Many Requests
+type Transport struct {
+	Limiter *Limiter
+	// Base performs the requests, http.DefaultTransport if nil
+	Base http.RoundTripper
+}
+
+// RoundTrip waits for the host's rate limit and performs the request
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t.Limiter.Wait(req.URL.Host)
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+
+	delay, ok := retryAfter(resp.Header.Get("Retry-After"))
+	if !ok || delay > maxRetryAfter || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	resp.Body.Close()
+
+	time.Sleep(delay)
+	t.Limiter.Wait(req.URL.Host)
+	return base.RoundTrip(retry)
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+	return 0, false
+}
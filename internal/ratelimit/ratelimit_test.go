@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimiterSpacesRequestsPerHost(t *testing.T) {
+	limiter := New(50)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Wait("a.example.com")
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("3 requests took %v, want at least 40ms", elapsed)
+	}
+
+	// Other hosts have their own schedule
+	start = time.Now()
+	limiter.Wait("b.example.com")
+	if elapsed := time.Since(start); elapsed > 10*time.Millisecond {
+		t.Errorf("first request to another host waited %v", elapsed)
+	}
+}
+
+func TestTransportRetriesTooManyRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryAfter   string
+		wantStatus   int
+		wantRequests int
+	}{
+		{name: "short delay is retried", retryAfter: "0", wantStatus: http.StatusOK, wantRequests: 2},
+		{name: "long delay is returned", retryAfter: "120", wantStatus: http.StatusTooManyRequests, wantRequests: 1},
+		{name: "missing header is returned", wantStatus: http.StatusTooManyRequests, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			client := &http.Client{Transport: &Transport{Limiter: New(0)}}
+			resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{}`))
+			if err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus || requests != tt.wantRequests {
+				t.Errorf("status = %d after %d requests, want %d after %d", resp.StatusCode, requests, tt.wantStatus, tt.wantRequests)
+			}
+		})
+	}
+}
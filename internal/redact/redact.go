@@ -0,0 +1,292 @@
+package redact
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrSecretsFound is returned when secrets are detected and redaction is configured to fail
+var ErrSecretsFound = errors.New("secrets detected in the changes")
+
+// Finding describes a single redacted value; the value itself is never recorded
+type Finding struct {
+	Detector    string `json:"detector"`
+	File        string `json:"file,omitempty"`
+	Line        int    `json:"line"`
+	Placeholder string `json:"placeholder"`
+}
+
+// String formats the finding for log output
+func (f Finding) String() string {
+	location := "line " + strconv.Itoa(f.Line)
+	if f.File != "" {
+		location = fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return fmt.Sprintf("%s in %s → %s", f.Detector, location, f.Placeholder)
+}
+
+// detector finds one kind of secret; group selects the submatch to redact
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+	group   int
+	// accept filters candidate matches, e.g. by entropy or file name
+	accept func(value, file string) bool
+}
+
+// Redactor replaces secrets with stable placeholders such as [REDACTED:github-token-1]
+type Redactor struct {
+	mu           sync.Mutex
+	detectors    []detector
+	placeholders map[string]string
+	counts       map[string]int
+}
+
+// NewRedactor creates a redactor with the built-in detectors
+func NewRedactor() *Redactor {
+	return &Redactor{
+		detectors: []detector{
+			{name: "private-key", pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)},
+			{name: "aws-access-key-id", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+			{name: "aws-secret-access-key", pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|private).{0,20}?['"\s:=]+([A-Za-z0-9/+]{40})\b`), group: 1},
+			{name: "github-token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+			{name: "gitlab-token", pattern: regexp.MustCompile(`\bglpat-[A-Za-z0-9_\-]{20,}`)},
+			{name: "clickup-token", pattern: regexp.MustCompile(`\bpk_[0-9]+_[A-Z0-9]{32}\b`)},
+			{name: "atlassian-api-token", pattern: regexp.MustCompile(`\bATATT[A-Za-z0-9_\-=]{20,}`)},
+			{name: "jwt", pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+			{name: "high-entropy-string", pattern: regexp.MustCompile(`(?:^|[^A-Za-z0-9+/_\-=])([A-Za-z0-9+/_\-]{32,}={0,2})`), group: 1, accept: highEntropy},
+		},
+		placeholders: make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// AddPattern adds a custom detector; when the expression has a capture group only the first group is redacted
+func (r *Redactor) AddPattern(name, expr string) error {
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+	}
+	group := 0
+	if pattern.NumSubexp() > 0 {
+		group = 1
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.detectors = append(r.detectors, detector{name: name, pattern: pattern, group: group})
+	return nil
+}
+
+// AddLiteral redacts an exact value, such as a token from the pullpoet configuration
+func (r *Redactor) AddLiteral(name, value string) {
+	// Short values would redact ordinary words
+	if len(value) < 8 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.detectors = append(r.detectors, detector{name: name, pattern: regexp.MustCompile(regexp.QuoteMeta(value))})
+}
+
+type match struct {
+	start, end int
+	detector   int
+}
+
+// Redact replaces every detected secret in text and reports what was replaced.
+// File names and line numbers are reported when text is a unified diff.
+func (r *Redactor) Redact(text string) (string, []Finding) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lines := locate(text)
+	var matches []match
+	for i, d := range r.detectors {
+		for _, loc := range d.pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*d.group], loc[2*d.group+1]
+			if start < 0 || start == end {
+				continue
+			}
+			if d.accept != nil && !d.accept(text[start:end], lines.at(start).file) {
+				continue
+			}
+			matches = append(matches, match{start: start, end: end, detector: i})
+		}
+	}
+	if len(matches) == 0 {
+		return text, nil
+	}
+
+	// Earliest match wins, then the longest, then the most specific detector
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		if matches[i].end != matches[j].end {
+			return matches[i].end > matches[j].end
+		}
+		return matches[i].detector < matches[j].detector
+	})
+
+	var builder strings.Builder
+	var findings []Finding
+	last := 0
+	for _, m := range matches {
+		if m.start < last {
+			continue
+		}
+		name := r.detectors[m.detector].name
+		placeholder := r.placeholder(name, text[m.start:m.end])
+		position := lines.at(m.start)
+		findings = append(findings, Finding{
+			Detector:    name,
+			File:        position.file,
+			Line:        position.line,
+			Placeholder: placeholder,
+		})
+		builder.WriteString(text[last:m.start])
+		builder.WriteString(placeholder)
+		last = m.end
+	}
+	builder.WriteString(text[last:])
+	return builder.String(), findings
+}
+
+// placeholder returns the placeholder of a secret, reusing it when the value was seen before
+func (r *Redactor) placeholder(name, value string) string {
+	if placeholder, ok := r.placeholders[value]; ok {
+		return placeholder
+	}
+	r.counts[name]++
+	placeholder := fmt.Sprintf("[REDACTED:%s-%d]", name, r.counts[name])
+	r.placeholders[value] = placeholder
+	return placeholder
+}
+
+// lockFiles contain checksums that look like secrets
+var lockFiles = map[string]bool{
+	"go.sum":            true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"Cargo.lock":        true,
+	"Gemfile.lock":      true,
+	"poetry.lock":       true,
+	"composer.lock":     true,
+}
+
+// highEntropy accepts random-looking tokens that mix upper case, lower case and digits
+func highEntropy(value, file string) bool {
+	if lockFiles[path.Base(file)] {
+		return false
+	}
+	value = strings.TrimRight(value, "=")
+	var upper, lower, digit bool
+	for _, c := range value {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	return upper && lower && digit && shannonEntropy(value) >= 4.3
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+	var entropy float64
+	length := float64(len([]rune(s)))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// position is the file and line a byte offset belongs to
+type position struct {
+	file string
+	line int
+}
+
+// lineIndex maps offsets to positions
+type lineIndex struct {
+	starts    []int
+	positions []position
+}
+
+// locate computes the position of every line; in a unified diff lines are numbered
+// like the new version of the file, otherwise by their line in text
+func locate(text string) *lineIndex {
+	index := &lineIndex{}
+	file := ""
+	newLine := 0
+	offset := 0
+	for i, line := range strings.SplitAfter(text, "\n") {
+		if line == "" {
+			continue
+		}
+		trimmed := strings.TrimRight(line, "\r\n")
+		pos := position{file: file, line: i + 1}
+
+		switch {
+		case strings.HasPrefix(trimmed, "diff --git "):
+			fields := strings.Fields(trimmed)
+			file = strings.TrimPrefix(fields[len(fields)-1], "b/")
+			newLine = 0
+			pos = position{file: file}
+		case strings.HasPrefix(trimmed, "@@ "):
+			newLine = hunkStart(trimmed)
+			pos = position{file: file, line: newLine}
+		case file != "" && newLine > 0 && (strings.HasPrefix(trimmed, "+") || strings.HasPrefix(trimmed, " ")):
+			pos = position{file: file, line: newLine}
+			newLine++
+		case file != "":
+			pos = position{file: file, line: max(newLine-1, 0)}
+		}
+
+		index.starts = append(index.starts, offset)
+		index.positions = append(index.positions, pos)
+		offset += len(line)
+	}
+	return index
+}
+
+// hunkStart returns the first new-file line of a "@@ -a,b +c,d @@" header
+func hunkStart(header string) int {
+	for _, field := range strings.Fields(header) {
+		if strings.HasPrefix(field, "+") {
+			number := strings.SplitN(strings.TrimPrefix(field, "+"), ",", 2)[0]
+			if n, err := strconv.Atoi(number); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// at returns the position of the line containing offset
+func (l *lineIndex) at(offset int) position {
+	i := sort.SearchInts(l.starts, offset+1) - 1
+	if i < 0 || i >= len(l.positions) {
+		return position{}
+	}
+	return l.positions[i]
+}
@@ -0,0 +1,165 @@
+package reviewers
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rule is a CODEOWNERS line: a path pattern and its owners
+type Rule struct {
+	Pattern string
+	Owners  []string
+	// Section is the GitLab section of the rule, empty for GitHub files
+	Section string
+	pattern *regexp.Regexp
+}
+
+// CodeOwners is a parsed CODEOWNERS file
+type CodeOwners struct {
+	Rules []Rule
+}
+
+// sectionPattern matches GitLab section headers, e.g. "^[Docs][2] @docs-team"
+var sectionPattern = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?\s*(.*)$`)
+
+// ParseCodeOwners parses a CODEOWNERS file in GitHub or GitLab syntax.
+// Invalid patterns are skipped.
+func ParseCodeOwners(content string) *CodeOwners {
+	owners := &CodeOwners{}
+	section := ""
+	var sectionOwners []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if match := sectionPattern.FindStringSubmatch(line); match != nil {
+			section = match[1]
+			sectionOwners = strings.Fields(match[2])
+			continue
+		}
+
+		fields := splitFields(line)
+		rule := Rule{Pattern: fields[0], Owners: fields[1:], Section: section}
+		// GitLab rules without owners inherit the section's default owners
+		if len(rule.Owners) == 0 && section != "" {
+			rule.Owners = sectionOwners
+		}
+		compiled, err := compilePattern(rule.Pattern)
+		if err != nil {
+			continue
+		}
+		rule.pattern = compiled
+		owners.Rules = append(owners.Rules, rule)
+	}
+	return owners
+}
+
+// Owners returns the owners of a path. The last matching rule wins; in GitLab
+// files the last matching rule of every section applies.
+func (c *CodeOwners) Owners(path string) []string {
+	path = strings.TrimPrefix(path, "/")
+	matches := make(map[string]*Rule)
+	var sections []string
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if _, ok := matches[rule.Section]; !ok {
+			sections = append(sections, rule.Section)
+		}
+		matches[rule.Section] = rule
+	}
+
+	var owners []string
+	for _, section := range sections {
+		for _, owner := range matches[section].Owners {
+			if !containsFold(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// stripComment removes a trailing comment; "\#" is a literal hash
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitFields splits a rule into its pattern and owners; "\ " is an escaped space in the pattern
+func splitFields(line string) []string {
+	var pattern strings.Builder
+	i := 0
+	for ; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && (line[i+1] == ' ' || line[i+1] == '#') {
+			pattern.WriteByte(line[i+1])
+			i++
+			continue
+		}
+		if line[i] == ' ' || line[i] == '\t' {
+			break
+		}
+		pattern.WriteByte(line[i])
+	}
+	return append([]string{pattern.String()}, strings.Fields(line[i:])...)
+}
+
+// compilePattern converts a gitignore style CODEOWNERS pattern to a regular expression.
+// Patterns with a slash other than a trailing one are relative to the repository root,
+// others match at any depth. Patterns naming a directory match everything below it.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	directory := strings.HasSuffix(pattern, "/")
+	trimmed := strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(trimmed, "/")
+	trimmed = strings.TrimPrefix(trimmed, "/")
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	if !anchored {
+		expr.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(trimmed); i++ {
+		switch {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case trimmed[i] == '*':
+			expr.WriteString("[^/]*")
+		case trimmed[i] == '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(trimmed[i : i+1]))
+		}
+	}
+
+	lastSegment := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	switch {
+	case directory:
+		expr.WriteString("/.*")
+	case !strings.ContainsAny(lastSegment, "*?"):
+		// A plain name may be a directory, e.g. "apps" or "/docs"
+		expr.WriteString("(?:/.*)?")
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
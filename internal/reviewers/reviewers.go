@@ -0,0 +1,232 @@
+// Package reviewers suggests pull request reviewers from CODEOWNERS and the git history
+// of the changed files. It runs locally and does not use the AI provider.
+package reviewers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/git"
+)
+
+// Defaults for reviewer suggestions
+const (
+	// DefaultMax is the number of suggested reviewers
+	DefaultMax = 5
+	// DefaultHalfLife is the age at which a commit counts half
+	DefaultHalfLife = 90 * 24 * time.Hour
+)
+
+// ownerWeight is the score of a code owner per owned changed file
+const ownerWeight = 1.0
+
+// Reviewer is a suggested reviewer with the reasons for the suggestion
+type Reviewer struct {
+	// Name is a CODEOWNERS owner (@user, @org/team or an email) or a commit author
+	Name string `json:"name"`
+	// Email is set for commit authors
+	Email   string   `json:"email,omitempty"`
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Handle returns the forge username or team of the reviewer without "@", empty if it has none
+func (r Reviewer) Handle() string {
+	if strings.HasPrefix(r.Name, "@") {
+		return strings.TrimPrefix(r.Name, "@")
+	}
+	return ""
+}
+
+// Options control the suggestions
+type Options struct {
+	// Max is the number of reviewers returned, DefaultMax if 0
+	Max int
+	// HalfLife is the age at which a commit counts half, DefaultHalfLife if 0
+	HalfLife time.Duration
+	// Exclude are names, emails or handles never suggested, e.g. the PR author.
+	// The authors of the pull request's commits are always excluded.
+	Exclude []string
+	// Now is the reference time for the commit age, the current time if zero
+	Now time.Time
+}
+
+// candidate collects the score of a reviewer while ranking
+type candidate struct {
+	reviewer Reviewer
+	owned    []string
+	commits  int
+	touched  []string
+	last     time.Time
+}
+
+// OptionsFromConfig returns the suggestion options of the configuration
+func OptionsFromConfig(cfg *config.Config) Options {
+	return Options{
+		Max:      cfg.ReviewersMax,
+		HalfLife: cfg.ReviewersHalfLife,
+		Exclude:  append([]string(nil), cfg.ReviewersExclude...),
+	}
+}
+
+// Suggest ranks reviewers for the changes of gitResult. Code owners score per
+// owned file, commit authors per commit touching a changed file, weighted by
+// the commit's age.
+func Suggest(gitResult *git.GitResult, opts Options) []Reviewer {
+	if gitResult == nil {
+		return nil
+	}
+	if opts.Max <= 0 {
+		opts.Max = DefaultMax
+	}
+	if opts.HalfLife <= 0 {
+		opts.HalfLife = DefaultHalfLife
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	changed := make(map[string]bool)
+	var paths []string
+	for _, file := range git.SplitDiff(gitResult.Diff) {
+		for _, path := range []string{file.Path, file.OldPath} {
+			if path != "" && !changed[path] {
+				changed[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	candidates := make(map[string]*candidate)
+	var order []string
+	get := func(key, name, email string) *candidate {
+		if c, ok := candidates[key]; ok {
+			return c
+		}
+		c := &candidate{reviewer: Reviewer{Name: name, Email: email}}
+		candidates[key] = c
+		order = append(order, key)
+		return c
+	}
+
+	if gitResult.CodeOwners != "" {
+		codeOwners := ParseCodeOwners(gitResult.CodeOwners)
+		for _, path := range paths {
+			for _, owner := range codeOwners.Owners(path) {
+				c := get(strings.ToLower(owner), owner, "")
+				if strings.Contains(owner, "@") && !strings.HasPrefix(owner, "@") {
+					c.reviewer.Email = owner
+				}
+				c.owned = append(c.owned, path)
+				c.reviewer.Score += ownerWeight
+			}
+		}
+	}
+
+	for _, commit := range gitResult.History {
+		var touched []string
+		for _, file := range commit.Files {
+			if changed[file] {
+				touched = append(touched, file)
+			}
+		}
+		if len(touched) == 0 || (commit.Email == "" && commit.Author == "") {
+			continue
+		}
+
+		key := strings.ToLower(commit.Email)
+		if key == "" {
+			key = strings.ToLower(commit.Author)
+		}
+		c := get(key, commit.Author, commit.Email)
+		c.commits++
+		for _, file := range touched {
+			if !contains(c.touched, file) {
+				c.touched = append(c.touched, file)
+			}
+		}
+		if commit.Date.After(c.last) {
+			c.last = commit.Date
+		}
+		age := opts.Now.Sub(commit.Date)
+		if age < 0 {
+			age = 0
+		}
+		c.reviewer.Score += math.Pow(0.5, float64(age)/float64(opts.HalfLife))
+	}
+
+	exclude := append(authors(gitResult), opts.Exclude...)
+	var reviewers []Reviewer
+	for _, key := range order {
+		c := candidates[key]
+		if excluded(c.reviewer, exclude) {
+			continue
+		}
+		if len(c.owned) > 0 {
+			c.reviewer.Reasons = append(c.reviewer.Reasons, fmt.Sprintf("code owner of %s", describeFiles(c.owned)))
+		}
+		if c.commits > 0 {
+			c.reviewer.Reasons = append(c.reviewer.Reasons, fmt.Sprintf("%d recent commit(s) to %s, last on %s", c.commits, describeFiles(c.touched), c.last.Format("2006-01-02")))
+		}
+		c.reviewer.Score = math.Round(c.reviewer.Score*100) / 100
+		reviewers = append(reviewers, c.reviewer)
+	}
+
+	sort.SliceStable(reviewers, func(i, j int) bool {
+		return reviewers[i].Score > reviewers[j].Score
+	})
+	if len(reviewers) > opts.Max {
+		reviewers = reviewers[:opts.Max]
+	}
+	return reviewers
+}
+
+// authors returns the authors and emails of the commits of the pull request
+func authors(gitResult *git.GitResult) []string {
+	var authors []string
+	for _, commit := range gitResult.Commits {
+		for _, value := range []string{commit.Email, commit.Author} {
+			if value != "" && !containsFold(authors, value) {
+				authors = append(authors, value)
+			}
+		}
+	}
+	return authors
+}
+
+// excluded reports whether the reviewer matches one of the excluded names, emails or handles
+func excluded(reviewer Reviewer, exclude []string) bool {
+	for _, value := range exclude {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "@")
+		if value == "" {
+			continue
+		}
+		if strings.EqualFold(value, reviewer.Handle()) || strings.EqualFold(value, reviewer.Email) ||
+			(reviewer.Handle() == "" && strings.EqualFold(value, reviewer.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeFiles names a single file or counts several
+func describeFiles(files []string) string {
+	if len(files) == 1 {
+		return files[0]
+	}
+	return fmt.Sprintf("%d changed files", len(files))
+}
+
+// contains reports whether values contains value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
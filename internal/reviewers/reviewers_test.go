@@ -0,0 +1,90 @@
+package reviewers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/git"
+)
+
+const codeOwnersFile = `# Global owners
+*                 @acme/core
+
+*.md              @docs-writer
+/docs/*           @docs-lead
+apps/             @apps-team   # everything under apps
+/build/logs/      @ops
+**/migrations     dba@example.com
+api/**/v1.go      @api-owner
+/empty/path
+
+[Frontend] @acme/frontend
+web/
+web/legacy/ @legacy-owner
+`
+
+func TestCodeOwners(t *testing.T) {
+	owners := ParseCodeOwners(codeOwnersFile)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "main.go", want: []string{"@acme/core"}},
+		{path: "README.md", want: []string{"@docs-writer"}},
+		{path: "docs/guide.md", want: []string{"@docs-lead"}},
+		{path: "docs/deep/guide.md", want: []string{"@docs-writer"}},
+		{path: "apps/api/main.go", want: []string{"@apps-team"}},
+		{path: "src/apps/main.go", want: []string{"@apps-team"}},
+		{path: "build/logs/out.txt", want: []string{"@ops"}},
+		{path: "x/build/logs/out.txt", want: []string{"@acme/core"}},
+		{path: "db/migrations/001.sql", want: []string{"dba@example.com"}},
+		{path: "api/users/v1.go", want: []string{"@api-owner"}},
+		{path: "empty/path", want: nil},
+		{path: "web/app.ts", want: []string{"@acme/core", "@acme/frontend"}},
+		{path: "web/legacy/app.ts", want: []string{"@acme/core", "@legacy-owner"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := owners.Owners(tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Owners(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	gitResult := &git.GitResult{
+		Diff: "diff --git a/api/users.go b/api/users.go\n--- a/api/users.go\n+++ b/api/users.go\n@@ -1 +1 @@\n-a\n+b\n" +
+			"diff --git a/docs/api.md b/docs/api.md\n--- a/docs/api.md\n+++ b/docs/api.md\n@@ -1 +1 @@\n-a\n+b\n",
+		Commits:    []git.CommitInfo{{Author: "Pat Author", Email: "pat@example.com"}},
+		CodeOwners: "api/ @api-team\n*.md @docs-writer bob@example.com\n",
+		History: []git.FileCommit{
+			{Author: "Bob", Email: "bob@example.com", Date: now.AddDate(0, 0, -1), Files: []string{"api/users.go"}},
+			{Author: "Bob", Email: "bob@example.com", Date: now.AddDate(0, 0, -90), Files: []string{"docs/api.md"}},
+			{Author: "Pat Author", Email: "pat@example.com", Date: now, Files: []string{"api/users.go"}},
+			{Author: "Old Timer", Email: "old@example.com", Date: now.AddDate(-2, 0, 0), Files: []string{"api/users.go"}},
+			{Author: "Unrelated", Email: "other@example.com", Date: now, Files: []string{"cmd/main.go"}},
+		},
+	}
+
+	got := Suggest(gitResult, Options{Now: now, Exclude: []string{"@docs-writer"}})
+	want := []Reviewer{
+		{Name: "bob@example.com", Email: "bob@example.com", Score: 2.49, Reasons: []string{
+			"code owner of docs/api.md",
+			"2 recent commit(s) to 2 changed files, last on 2024-05-31",
+		}},
+		{Name: "@api-team", Score: 1, Reasons: []string{"code owner of api/users.go"}},
+		{Name: "Old Timer", Email: "old@example.com", Score: 0, Reasons: []string{"1 recent commit(s) to api/users.go, last on 2022-06-01"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggest() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := Suggest(gitResult, Options{Now: now, Max: 1}); len(got) != 1 || got[0].Name != "bob@example.com" {
+		t.Errorf("Suggest() with Max 1 = %+v", got)
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metrics collects the counters exposed on /metrics in the Prometheus text format
+type metrics struct {
+	mu            sync.Mutex
+	webhooks      map[string]int64
+	jobs          map[string]int64
+	jobSeconds    float64
+	jobCount      int64
+	queueCapacity int
+	queueDepth    func() int
+}
+
+// newMetrics creates an empty metrics registry
+func newMetrics(queueCapacity int, queueDepth func() int) *metrics {
+	return &metrics{
+		webhooks:      make(map[string]int64),
+		jobs:          make(map[string]int64),
+		queueCapacity: queueCapacity,
+		queueDepth:    queueDepth,
+	}
+}
+
+// webhookReceived counts a webhook delivery by forge and outcome
+func (m *metrics) webhookReceived(forgeName, outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.webhooks[forgeName+"\x00"+outcome]++
+}
+
+// jobFinished counts a finished job by status and records its duration
+func (m *metrics) jobFinished(status string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs[status]++
+	m.jobSeconds += duration.Seconds()
+	m.jobCount++
+}
+
+// jobSkipped counts a pull request that was not described
+func (m *metrics) jobSkipped() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.jobs["skipped"]++
+}
+
+// write renders all metrics in the Prometheus text exposition format
+func (m *metrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP pullpoet_webhooks_total Webhook deliveries received, by forge and outcome.")
+	fmt.Fprintln(w, "# TYPE pullpoet_webhooks_total counter")
+	for _, key := range sortedKeys(m.webhooks) {
+		parts := strings.SplitN(key, "\x00", 2)
+		fmt.Fprintf(w, "pullpoet_webhooks_total{forge=%q,outcome=%q} %d\n", parts[0], parts[1], m.webhooks[key])
+	}
+
+	fmt.Fprintln(w, "# HELP pullpoet_jobs_total Description jobs processed, by status.")
+	fmt.Fprintln(w, "# TYPE pullpoet_jobs_total counter")
+	for _, status := range sortedKeys(m.jobs) {
+		fmt.Fprintf(w, "pullpoet_jobs_total{status=%q} %d\n", status, m.jobs[status])
+	}
+
+	fmt.Fprintln(w, "# HELP pullpoet_job_duration_seconds Time spent processing description jobs.")
+	fmt.Fprintln(w, "# TYPE pullpoet_job_duration_seconds summary")
+	fmt.Fprintf(w, "pullpoet_job_duration_seconds_sum %g\n", m.jobSeconds)
+	fmt.Fprintf(w, "pullpoet_job_duration_seconds_count %d\n", m.jobCount)
+
+	fmt.Fprintln(w, "# HELP pullpoet_queue_depth Jobs waiting for a worker.")
+	fmt.Fprintln(w, "# TYPE pullpoet_queue_depth gauge")
+	fmt.Fprintf(w, "pullpoet_queue_depth %d\n", m.queueDepth())
+
+	fmt.Fprintln(w, "# HELP pullpoet_queue_capacity Maximum number of queued jobs.")
+	fmt.Fprintln(w, "# TYPE pullpoet_queue_capacity gauge")
+	fmt.Fprintf(w, "pullpoet_queue_capacity %d\n", m.queueCapacity)
+}
+
+// sortedKeys returns the keys of a counter map in stable order
+func sortedKeys(counters map[string]int64) []string {
+	keys := make([]string, 0, len(counters))
+	for key := range counters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
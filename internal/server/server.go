@@ -0,0 +1,352 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/forge"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/pr"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+)
+
+// Modes for publishing the generated description
+const (
+	ModeUpdate  = "update"
+	ModeComment = "comment"
+)
+
+// maxPayloadSize limits the size of accepted webhook payloads
+const maxPayloadSize = 5 << 20
+
+// Describer generates the description of a pull request
+type Describer interface {
+	Describe(pull forge.PullRequest) (*pr.Result, error)
+}
+
+// Config holds the webhook server settings
+type Config struct {
+	// GitHubSecret is the HMAC secret configured on GitHub webhooks
+	GitHubSecret string
+	// GitLabToken is the secret token configured on GitLab webhooks
+	GitLabToken string
+	// Insecure accepts unverified webhooks of a forge without a secret
+	Insecure bool
+	// GitHub and GitLab publish results; a nil publisher disables that forge
+	GitHub    forge.Publisher
+	GitLab    forge.Publisher
+	Describer Describer
+	// Mode is ModeUpdate to replace the PR body or ModeComment to post a comment
+	Mode string
+	// RequestReviewers requests the suggested reviewers that have a forge handle
+	RequestReviewers bool
+	Workers          int
+	QueueSize        int
+	Log              logger.Logger
+}
+
+// Server receives pull request webhooks and describes new pull requests in the background
+type Server struct {
+	config  Config
+	log     logger.Logger
+	queue   chan forge.PullRequest
+	metrics *metrics
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// New creates a server; call Start to launch the workers
+func New(config Config) *Server {
+	if config.Workers <= 0 {
+		config.Workers = 2
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.Mode == "" {
+		config.Mode = ModeUpdate
+	}
+
+	s := &Server{
+		config: config,
+		log:    logger.OrDefault(config.Log),
+		queue:  make(chan forge.PullRequest, config.QueueSize),
+	}
+	s.metrics = newMetrics(config.QueueSize, func() int { return len(s.queue) })
+	return s
+}
+
+// Start launches the worker pool
+func (s *Server) Start() {
+	for i := 0; i < s.config.Workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+}
+
+// Stop stops accepting jobs and waits for queued jobs to finish
+func (s *Server) Stop() {
+	s.once.Do(func() {
+		close(s.queue)
+	})
+	s.wg.Wait()
+}
+
+// Handler returns the HTTP routes of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhooks/github", s.handleGitHub)
+	mux.HandleFunc("/webhooks/gitlab", s.handleGitLab)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		s.metrics.write(w)
+	})
+	return mux
+}
+
+// handleGitHub verifies and enqueues GitHub pull_request events
+func (s *Server) handleGitHub(w http.ResponseWriter, r *http.Request) {
+	payload, ok := s.readPayload(w, r, forge.GitHub)
+	if !ok {
+		return
+	}
+	if s.config.GitHub == nil {
+		s.reject(w, forge.GitHub, "disabled", http.StatusNotFound, "GitHub webhooks are not configured")
+		return
+	}
+	if !s.unverified(s.config.GitHubSecret) && !verifyGitHubSignature(s.config.GitHubSecret, payload, r.Header.Get("X-Hub-Signature-256")) {
+		s.reject(w, forge.GitHub, "invalid_signature", http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	pull, err := parseGitHubEvent(r.Header.Get("X-GitHub-Event"), payload)
+	s.dispatch(w, forge.GitHub, pull, err)
+}
+
+// unverified reports whether webhooks are accepted without verification: only
+// in insecure mode and when no secret is configured
+func (s *Server) unverified(secret string) bool {
+	return s.config.Insecure && secret == ""
+}
+
+// handleGitLab verifies and enqueues GitLab merge request events
+func (s *Server) handleGitLab(w http.ResponseWriter, r *http.Request) {
+	payload, ok := s.readPayload(w, r, forge.GitLab)
+	if !ok {
+		return
+	}
+	if s.config.GitLab == nil {
+		s.reject(w, forge.GitLab, "disabled", http.StatusNotFound, "GitLab webhooks are not configured")
+		return
+	}
+	if !s.unverified(s.config.GitLabToken) && !verifyGitLabToken(s.config.GitLabToken, r.Header.Get("X-Gitlab-Token")) {
+		s.reject(w, forge.GitLab, "invalid_signature", http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	pull, err := parseGitLabEvent(payload)
+	s.dispatch(w, forge.GitLab, pull, err)
+}
+
+// readPayload reads the request body of a POST request
+func (s *Server) readPayload(w http.ResponseWriter, r *http.Request, forgeName string) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		s.reject(w, forgeName, "bad_request", http.StatusMethodNotAllowed, "method not allowed")
+		return nil, false
+	}
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		s.reject(w, forgeName, "bad_request", http.StatusBadRequest, "failed to read payload")
+		return nil, false
+	}
+	return payload, true
+}
+
+// dispatch enqueues a parsed pull request, ignoring events that need no description
+func (s *Server) dispatch(w http.ResponseWriter, forgeName string, pull *forge.PullRequest, err error) {
+	if err != nil {
+		s.reject(w, forgeName, "bad_request", http.StatusBadRequest, err.Error())
+		return
+	}
+	if pull == nil {
+		s.metrics.webhookReceived(forgeName, "ignored")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if HasHumanDescription(pull.Body) {
+		s.log.Printf("⏭️  Skipping %s %s#%d: it already has a description\n", forgeName, pull.Repo, pull.Number)
+		s.metrics.webhookReceived(forgeName, "ignored")
+		s.metrics.jobSkipped()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	select {
+	case s.queue <- *pull:
+		s.log.Printf("📥 Queued %s %s#%d (%s → %s)\n", forgeName, pull.Repo, pull.Number, pull.Source, pull.Target)
+		s.metrics.webhookReceived(forgeName, "queued")
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		s.reject(w, forgeName, "queue_full", http.StatusServiceUnavailable, "job queue is full")
+	}
+}
+
+// reject writes an error response and counts the delivery
+func (s *Server) reject(w http.ResponseWriter, forgeName, outcome string, status int, message string) {
+	s.metrics.webhookReceived(forgeName, outcome)
+	http.Error(w, message, status)
+}
+
+// worker processes queued pull requests until the queue is closed
+func (s *Server) worker() {
+	defer s.wg.Done()
+	for pull := range s.queue {
+		start := time.Now()
+		if err := s.process(pull); err != nil {
+			s.log.Printf("❌ Failed to describe %s %s#%d: %v\n", pull.Forge, pull.Repo, pull.Number, err)
+			s.metrics.jobFinished("failure", time.Since(start))
+			continue
+		}
+		s.log.Printf("✅ Described %s %s#%d in %s\n", pull.Forge, pull.Repo, pull.Number, time.Since(start).Round(time.Millisecond))
+		s.metrics.jobFinished("success", time.Since(start))
+	}
+}
+
+// process generates the description of a pull request and publishes it
+func (s *Server) process(pull forge.PullRequest) error {
+	result, err := s.config.Describer.Describe(pull)
+	if err != nil {
+		return err
+	}
+
+	publisher := s.config.GitHub
+	if pull.Forge == forge.GitLab {
+		publisher = s.config.GitLab
+	}
+
+	if s.config.Mode == ModeComment {
+		err = publisher.Comment(pull, fmt.Sprintf("### %s\n\n%s", result.Title, result.Markdown()))
+	} else {
+		err = publisher.UpdateDescription(pull, result.Markdown())
+	}
+	if err != nil {
+		return err
+	}
+	if len(result.Labels) > 0 {
+		if err := publisher.AddLabels(pull, result.Labels); err != nil {
+			return fmt.Errorf("failed to add labels: %w", err)
+		}
+	}
+	if s.config.RequestReviewers {
+		var handles []string
+		for _, reviewer := range result.Reviewers {
+			if handle := reviewer.Handle(); handle != "" {
+				handles = append(handles, handle)
+			}
+		}
+		if len(handles) > 0 {
+			if err := publisher.RequestReviewers(pull, handles); err != nil {
+				return fmt.Errorf("failed to request reviewers: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+var htmlCommentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// HasHumanDescription reports whether a PR body was written by a person.
+// Empty bodies, bodies made only of template comments and pullpoet output are not.
+func HasHumanDescription(body string) bool {
+	if strings.Contains(body, "generated by [pullpoet]") {
+		return false
+	}
+	return strings.TrimSpace(htmlCommentPattern.ReplaceAllString(body, "")) != ""
+}
+
+// GitDescriber clones the repository and runs the regular git and AI pipeline
+type GitDescriber struct {
+	// NewAIClient creates the AI client for a job, each job gets its own client
+	NewAIClient  func() (ai.Client, error)
+	SystemPrompt string
+	Language     string
+	// Labels the AI may suggest, they are added to the pull request
+	Labels []string
+	// Policy the generated title and body must follow, nil if none
+	Policy *pr.Policy
+	// NewRedactor creates the secret redactor for a job, nil or a nil redactor disables redaction.
+	// Each job gets its own redactor so placeholders are not shared between repositories.
+	NewRedactor func() (*redact.Redactor, error)
+	// FailOnSecrets fails a job instead of sending redacted content when secrets are found
+	FailOnSecrets bool
+	// Reviewers are suggested with these options unless ReviewersDisabled is set
+	Reviewers         reviewers.Options
+	ReviewersDisabled bool
+	// Tokens are embedded into HTTPS clone URLs of private repositories
+	GitHubToken string
+	GitLabToken string
+	Log         logger.Logger
+}
+
+// Describe analyzes the pull request branches and generates its description
+func (d *GitDescriber) Describe(pull forge.PullRequest) (*pr.Result, error) {
+	cloneURL := pull.CloneURL
+	switch pull.Forge {
+	case forge.GitHub:
+		cloneURL = forge.WithCredentials(cloneURL, "x-access-token", d.GitHubToken)
+	case forge.GitLab:
+		cloneURL = forge.WithCredentials(cloneURL, "oauth2", d.GitLabToken)
+	}
+
+	gitClient := git.NewFastClient()
+	gitClient.SetLogger(d.Log)
+	source := pull.Source
+	if pull.SourceRef != "" {
+		source = pull.SourceRef
+	}
+	gitResult, err := gitClient.GetDiffWithCommits(cloneURL, source, pull.Target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze git changes: %w", err)
+	}
+
+	aiClient, err := d.NewAIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	generator := pr.NewGenerator(aiClient, d.SystemPrompt)
+	generator.SetLogger(d.Log)
+	generator.SetLabels(d.Labels)
+	generator.SetPolicy(d.Policy)
+	if d.NewRedactor != nil {
+		redactor, err := d.NewRedactor()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create redactor: %w", err)
+		}
+		generator.SetRedactor(redactor)
+	}
+	generator.SetFailOnSecrets(d.FailOnSecrets)
+	result, err := generator.Generate(gitResult, "", pull.CloneURL, d.Language, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PR description: %w", err)
+	}
+
+	if !d.ReviewersDisabled {
+		opts := d.Reviewers
+		opts.Exclude = append(append([]string(nil), opts.Exclude...), pull.Author)
+		result.Reviewers = reviewers.Suggest(gitResult, opts)
+	}
+	return result, nil
+}
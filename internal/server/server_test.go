@@ -0,0 +1,502 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/forge"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/redact"
+)
+
+const testSecret = "webhook-secret"
+
+// newTestRepo creates a local repository with a "main" branch and a "feature" branch one commit ahead
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	run("init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Demo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-q", "-m", "Initial commit")
+	run("checkout", "-q", "-b", "feature")
+	if err := os.WriteFile(filepath.Join(dir, "login.go"), []byte("package demo\n\nfunc Login() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-q", "-m", "Add login")
+	return dir
+}
+
+// newFakeProvider serves Ollama chat responses
+func newFakeProvider(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := json.Marshal(map[string]string{"title": "✨ Add login", "body": "## Summary\nAdds a login function"})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"content": string(content)},
+			"done":    true,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+type forgeRequest struct {
+	Method string
+	Path   string
+	Body   map[string]string
+}
+
+// newFakeForge records API calls made to it
+func newFakeForge(t *testing.T) (*httptest.Server, chan forgeRequest) {
+	t.Helper()
+	requests := make(chan forgeRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		requests <- forgeRequest{Method: r.Method, Path: r.URL.Path, Body: body}
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, "{}")
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func newTestServer(t *testing.T, mode string) (*Server, *httptest.Server, chan forgeRequest, string) {
+	t.Helper()
+	repoDir := newTestRepo(t)
+	provider := newFakeProvider(t)
+	fakeForge, requests := newFakeForge(t)
+
+	srv := New(Config{
+		GitHubSecret: testSecret,
+		GitLabToken:  testSecret,
+		GitHub:       forge.NewGitHubClient(fakeForge.URL, "gh-token"),
+		GitLab:       forge.NewGitLabClient(fakeForge.URL, "gl-token"),
+		Describer: &GitDescriber{
+			NewAIClient: func() (ai.Client, error) {
+				client := ai.NewOllamaClient(provider.URL, "test-model")
+				client.SetLogger(logger.Discard)
+				return client, nil
+			},
+			Log: logger.Discard,
+		},
+		Mode:      mode,
+		Workers:   1,
+		QueueSize: 4,
+		Log:       logger.Discard,
+	})
+	srv.Start()
+	t.Cleanup(srv.Stop)
+
+	httpServer := httptest.NewServer(srv.Handler())
+	t.Cleanup(httpServer.Close)
+	return srv, httpServer, requests, repoDir
+}
+
+func githubPayload(repoDir, body string) []byte {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"action": "opened",
+		"pull_request": map[string]interface{}{
+			"number": 7,
+			"title":  "Add login",
+			"body":   body,
+			"user":   map[string]string{"login": "octocat"},
+			"head": map[string]interface{}{
+				"ref":  "feature",
+				"repo": map[string]string{"full_name": "acme/demo"},
+			},
+			"base": map[string]interface{}{
+				"ref":  "main",
+				"repo": map[string]string{"full_name": "acme/demo", "clone_url": repoDir},
+			},
+		},
+	})
+	return payload
+}
+
+func sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func postWebhook(t *testing.T, url string, payload []byte, headers map[string]string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func waitForRequest(t *testing.T, requests chan forgeRequest) forgeRequest {
+	t.Helper()
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(30 * time.Second):
+		t.Fatal("timed out waiting for the forge to be updated")
+		return forgeRequest{}
+	}
+}
+
+func TestGitHubWebhookUpdatesPullRequest(t *testing.T) {
+	srv, server, requests, repoDir := newTestServer(t, ModeUpdate)
+
+	payload := githubPayload(repoDir, "")
+	status := postWebhook(t, server.URL+"/webhooks/github", payload, map[string]string{
+		"X-GitHub-Event":      "pull_request",
+		"X-Hub-Signature-256": sign(payload),
+	})
+	if status != http.StatusAccepted {
+		t.Fatalf("webhook status = %d, want %d", status, http.StatusAccepted)
+	}
+
+	req := waitForRequest(t, requests)
+	if req.Method != http.MethodPatch || req.Path != "/repos/acme/demo/pulls/7" {
+		t.Errorf("forge request = %s %s, want PATCH /repos/acme/demo/pulls/7", req.Method, req.Path)
+	}
+	if !strings.Contains(req.Body["body"], "Adds a login function") {
+		t.Errorf("updated body = %q, want generated description", req.Body["body"])
+	}
+
+	// Wait for the worker to record the finished job
+	srv.Stop()
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	for _, want := range []string{
+		`pullpoet_webhooks_total{forge="github",outcome="queued"} 1`,
+		`pullpoet_jobs_total{status="success"} 1`,
+	} {
+		if !strings.Contains(string(metrics), want) {
+			t.Errorf("metrics missing %q:\n%s", want, metrics)
+		}
+	}
+}
+
+func TestGitHubWebhookForkPullRequest(t *testing.T) {
+	_, server, requests, repoDir := newTestServer(t, ModeUpdate)
+
+	// The base repository only has the fork branch as the pull request ref
+	cmd := exec.Command("git", "update-ref", "refs/pull/7/head", "feature")
+	cmd.Dir = repoDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git update-ref failed: %v\n%s", err, output)
+	}
+
+	var event map[string]interface{}
+	json.Unmarshal(githubPayload(repoDir, ""), &event)
+	event["pull_request"].(map[string]interface{})["head"] = map[string]interface{}{
+		"ref":  "login",
+		"repo": map[string]string{"full_name": "octocat/demo"},
+	}
+	payload, _ := json.Marshal(event)
+
+	status := postWebhook(t, server.URL+"/webhooks/github", payload, map[string]string{
+		"X-GitHub-Event":      "pull_request",
+		"X-Hub-Signature-256": sign(payload),
+	})
+	if status != http.StatusAccepted {
+		t.Fatalf("webhook status = %d, want %d", status, http.StatusAccepted)
+	}
+
+	req := waitForRequest(t, requests)
+	if !strings.Contains(req.Body["body"], "Adds a login function") {
+		t.Errorf("updated body = %q, want generated description", req.Body["body"])
+	}
+}
+
+func TestParseForkPullRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func() (*forge.PullRequest, error)
+		wantRef string
+	}{
+		{
+			name: "github same repository",
+			parse: func() (*forge.PullRequest, error) {
+				return parseGitHubEvent("pull_request", githubPayload("/tmp/demo", ""))
+			},
+		},
+		{
+			name: "github fork",
+			parse: func() (*forge.PullRequest, error) {
+				return parseGitHubEvent("pull_request", []byte(`{"action": "opened", "pull_request": {"number": 7,
+					"head": {"ref": "feature", "repo": {"full_name": "octocat/demo"}},
+					"base": {"ref": "main", "repo": {"full_name": "acme/demo"}}}}`))
+			},
+			wantRef: "refs/pull/7/head",
+		},
+		{
+			name: "github deleted fork",
+			parse: func() (*forge.PullRequest, error) {
+				return parseGitHubEvent("pull_request", []byte(`{"action": "opened", "pull_request": {"number": 7,
+					"head": {"ref": "feature", "repo": null},
+					"base": {"ref": "main", "repo": {"full_name": "acme/demo"}}}}`))
+			},
+			wantRef: "refs/pull/7/head",
+		},
+		{
+			name: "gitlab same project",
+			parse: func() (*forge.PullRequest, error) {
+				return parseGitLabEvent([]byte(`{"object_kind": "merge_request", "project": {"id": 42},
+					"object_attributes": {"iid": 3, "action": "open", "source_project_id": 42, "target_project_id": 42}}`))
+			},
+		},
+		{
+			name: "gitlab fork",
+			parse: func() (*forge.PullRequest, error) {
+				return parseGitLabEvent([]byte(`{"object_kind": "merge_request", "project": {"id": 42},
+					"object_attributes": {"iid": 3, "action": "open", "source_project_id": 57, "target_project_id": 42}}`))
+			},
+			wantRef: "refs/merge-requests/3/head",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pull, err := tt.parse()
+			if err != nil || pull == nil {
+				t.Fatalf("parse() = %v, %v", pull, err)
+			}
+			if pull.SourceRef != tt.wantRef {
+				t.Errorf("SourceRef = %q, want %q", pull.SourceRef, tt.wantRef)
+			}
+		})
+	}
+}
+
+func TestGitLabWebhookPostsComment(t *testing.T) {
+	_, server, requests, repoDir := newTestServer(t, ModeComment)
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"object_kind": "merge_request",
+		"user":        map[string]string{"username": "dev"},
+		"project":     map[string]interface{}{"id": 42, "git_http_url": repoDir},
+		"object_attributes": map[string]interface{}{
+			"iid":           3,
+			"title":         "Add login",
+			"source_branch": "feature",
+			"target_branch": "main",
+			"action":        "open",
+		},
+	})
+	status := postWebhook(t, server.URL+"/webhooks/gitlab", payload, map[string]string{
+		"X-Gitlab-Event": "Merge Request Hook",
+		"X-Gitlab-Token": testSecret,
+	})
+	if status != http.StatusAccepted {
+		t.Fatalf("webhook status = %d, want %d", status, http.StatusAccepted)
+	}
+
+	req := waitForRequest(t, requests)
+	if req.Method != http.MethodPost || req.Path != "/projects/42/merge_requests/3/notes" {
+		t.Errorf("forge request = %s %s, want POST /projects/42/merge_requests/3/notes", req.Method, req.Path)
+	}
+	if !strings.HasPrefix(req.Body["body"], "### ✨ Add login") {
+		t.Errorf("comment = %q, want generated title heading", req.Body["body"])
+	}
+}
+
+func TestWebhookRejectsAndSkips(t *testing.T) {
+	_, server, requests, repoDir := newTestServer(t, ModeUpdate)
+
+	tests := []struct {
+		name    string
+		payload []byte
+		headers func(payload []byte) map[string]string
+		want    int
+	}{
+		{
+			name:    "invalid signature",
+			payload: githubPayload(repoDir, ""),
+			headers: func([]byte) map[string]string {
+				return map[string]string{"X-GitHub-Event": "pull_request", "X-Hub-Signature-256": "sha256=00"}
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name:    "human-written description",
+			payload: githubPayload(repoDir, "I wrote this myself."),
+			headers: func(payload []byte) map[string]string {
+				return map[string]string{"X-GitHub-Event": "pull_request", "X-Hub-Signature-256": sign(payload)}
+			},
+			want: http.StatusNoContent,
+		},
+		{
+			name:    "unrelated event",
+			payload: []byte(`{"zen": "Keep it logically awesome."}`),
+			headers: func(payload []byte) map[string]string {
+				return map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": sign(payload)}
+			},
+			want: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := postWebhook(t, server.URL+"/webhooks/github", tt.payload, tt.headers(tt.payload))
+			if status != tt.want {
+				t.Errorf("status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+
+	select {
+	case req := <-requests:
+		t.Errorf("unexpected forge request: %s %s", req.Method, req.Path)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWebhookWithoutSecret(t *testing.T) {
+	fakeForge, _ := newFakeForge(t)
+	payload := []byte(`{"zen": "Keep it logically awesome."}`)
+
+	tests := []struct {
+		name     string
+		insecure bool
+		want     int
+	}{
+		{name: "rejected by default", want: http.StatusUnauthorized},
+		{name: "accepted in insecure mode", insecure: true, want: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := New(Config{
+				GitHub:   forge.NewGitHubClient(fakeForge.URL, "gh-token"),
+				GitLab:   forge.NewGitLabClient(fakeForge.URL, "gl-token"),
+				Insecure: tt.insecure,
+				Log:      logger.Discard,
+			})
+			server := httptest.NewServer(srv.Handler())
+			defer server.Close()
+
+			if status := postWebhook(t, server.URL+"/webhooks/github", payload, map[string]string{"X-GitHub-Event": "ping"}); status != tt.want {
+				t.Errorf("GitHub status = %d, want %d", status, tt.want)
+			}
+			if status := postWebhook(t, server.URL+"/webhooks/gitlab", payload, map[string]string{"X-Gitlab-Event": "Push Hook"}); status != tt.want {
+				t.Errorf("GitLab status = %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitDescriberRedactsSecrets(t *testing.T) {
+	repoDir := newTestRepo(t)
+
+	tests := []struct {
+		name          string
+		failOnSecrets bool
+		wantErr       bool
+	}{
+		{name: "redacted"},
+		{name: "fail on secrets", failOnSecrets: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts []string
+			provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				prompts = append(prompts, string(body))
+				content, _ := json.Marshal(map[string]string{"title": "Add login", "body": "Adds a login function"})
+				json.NewEncoder(w).Encode(map[string]interface{}{"message": map[string]string{"content": string(content)}, "done": true})
+			}))
+			defer provider.Close()
+
+			describer := &GitDescriber{
+				NewAIClient: func() (ai.Client, error) {
+					client := ai.NewOllamaClient(provider.URL, "test-model")
+					client.SetLogger(logger.Discard)
+					return client, nil
+				},
+				NewRedactor: func() (*redact.Redactor, error) {
+					redactor := redact.NewRedactor()
+					return redactor, redactor.AddPattern("login-func", `func Login\(\)`)
+				},
+				FailOnSecrets: tt.failOnSecrets,
+				Log:           logger.Discard,
+			}
+
+			_, err := describer.Describe(forge.PullRequest{Forge: forge.GitHub, Source: "feature", Target: "main", CloneURL: repoDir})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Describe() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(prompts) != 0 {
+					t.Errorf("AI requests = %d, want none", len(prompts))
+				}
+				return
+			}
+			if len(prompts) == 0 || strings.Contains(prompts[0], "func Login()") || !strings.Contains(prompts[0], "[REDACTED:login-func-1]") {
+				t.Errorf("prompt was not redacted:\n%v", prompts)
+			}
+		})
+	}
+}
+
+func TestHasHumanDescription(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{body: "", want: false},
+		{body: "  \n", want: false},
+		{body: "<!-- Describe your changes -->\n", want: false},
+		{body: "Fixes the login flow", want: true},
+		{body: "## Summary\n\n*🤖 This PR description was generated by [pullpoet](https://github.com/erkineren/pullpoet)*", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.body), func(t *testing.T) {
+			if got := HasHumanDescription(tt.body); got != tt.want {
+				t.Errorf("HasHumanDescription(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/erkineren/pullpoet/internal/forge"
+)
+
+// verifyGitHubSignature checks the X-Hub-Signature-256 header against the payload;
+// nothing is valid without a secret
+func verifyGitHubSignature(secret string, payload []byte, header string) bool {
+	if secret == "" {
+		return false
+	}
+	signature, ok := strings.CutPrefix(header, "sha256=")
+	if !ok {
+		return false
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// verifyGitLabToken checks the X-Gitlab-Token header against the configured secret;
+// nothing is valid without a secret
+func verifyGitLabToken(secret, header string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(header)) == 1
+}
+
+// githubPullRequestEvent holds the parts of a GitHub pull_request webhook pullpoet uses
+type githubPullRequestEvent struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		HTMLURL string `json:"html_url"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			Ref string `json:"ref"`
+			// Repo is nil when the fork was deleted
+			Repo *struct {
+				FullName string `json:"full_name"`
+			} `json:"repo"`
+		} `json:"head"`
+		Base struct {
+			Ref  string `json:"ref"`
+			Repo struct {
+				FullName string `json:"full_name"`
+				CloneURL string `json:"clone_url"`
+			} `json:"repo"`
+		} `json:"base"`
+	} `json:"pull_request"`
+}
+
+// parseGitHubEvent returns the pull request of an "opened" or "reopened" event, or nil for other events
+func parseGitHubEvent(event string, payload []byte) (*forge.PullRequest, error) {
+	if event != "pull_request" {
+		return nil, nil
+	}
+
+	var data githubPullRequestEvent
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub payload: %w", err)
+	}
+	if data.Action != "opened" && data.Action != "reopened" {
+		return nil, nil
+	}
+
+	pull := data.PullRequest
+	result := &forge.PullRequest{
+		Forge:    forge.GitHub,
+		Repo:     pull.Base.Repo.FullName,
+		Number:   pull.Number,
+		Title:    pull.Title,
+		Body:     pull.Body,
+		Author:   pull.User.Login,
+		Source:   pull.Head.Ref,
+		Target:   pull.Base.Ref,
+		CloneURL: pull.Base.Repo.CloneURL,
+		URL:      pull.HTMLURL,
+	}
+	// The branch of a fork is not in the base repository, its pull request ref is
+	if pull.Head.Repo == nil || pull.Head.Repo.FullName != pull.Base.Repo.FullName {
+		result.SourceRef = fmt.Sprintf("refs/pull/%d/head", pull.Number)
+	}
+	return result, nil
+}
+
+// gitlabMergeRequestEvent holds the parts of a GitLab merge request webhook pullpoet uses
+type gitlabMergeRequestEvent struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		ID                int    `json:"id"`
+		GitHTTPURL        string `json:"git_http_url"`
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID             int    `json:"iid"`
+		Title           string `json:"title"`
+		Description     string `json:"description"`
+		SourceBranch    string `json:"source_branch"`
+		TargetBranch    string `json:"target_branch"`
+		SourceProjectID int    `json:"source_project_id"`
+		TargetProjectID int    `json:"target_project_id"`
+		Action          string `json:"action"`
+		URL             string `json:"url"`
+	} `json:"object_attributes"`
+}
+
+// parseGitLabEvent returns the merge request of an "open" or "reopen" event, or nil for other events
+func parseGitLabEvent(payload []byte) (*forge.PullRequest, error) {
+	var data gitlabMergeRequestEvent
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse GitLab payload: %w", err)
+	}
+	if data.ObjectKind != "merge_request" {
+		return nil, nil
+	}
+
+	attrs := data.ObjectAttributes
+	if attrs.Action != "open" && attrs.Action != "reopen" {
+		return nil, nil
+	}
+
+	repo := data.Project.PathWithNamespace
+	if data.Project.ID != 0 {
+		repo = strconv.Itoa(data.Project.ID)
+	}
+
+	pull := &forge.PullRequest{
+		Forge:    forge.GitLab,
+		Repo:     repo,
+		Number:   attrs.IID,
+		Title:    attrs.Title,
+		Body:     attrs.Description,
+		Author:   data.User.Username,
+		Source:   attrs.SourceBranch,
+		Target:   attrs.TargetBranch,
+		CloneURL: data.Project.GitHTTPURL,
+		URL:      attrs.URL,
+	}
+	// The branch of a fork is not in the target project, its merge request ref is
+	if attrs.SourceProjectID != 0 && attrs.SourceProjectID != attrs.TargetProjectID {
+		pull.SourceRef = fmt.Sprintf("refs/merge-requests/%d/head", attrs.IID)
+	}
+	return pull, nil
+}
@@ -0,0 +1,226 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// ANSI SGR codes used by the terminal renderers
+const (
+	sgrBold      = "1"
+	sgrFaint     = "2"
+	sgrItalic    = "3"
+	sgrUnderline = "4"
+	sgrReverse   = "7"
+	sgrRed       = "31"
+	sgrGreen     = "32"
+	sgrYellow    = "33"
+	sgrBlue      = "34"
+	sgrCyan      = "36"
+)
+
+var (
+	ansiPattern       = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+	boldPattern       = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	inlineCodePattern = regexp.MustCompile("`([^`]+)`")
+	linkPattern       = regexp.MustCompile(`\[([^\]]+)\]\(([^)]+)\)`)
+	orderedPattern    = regexp.MustCompile(`^(\d+[.)])\s+(.*)$`)
+)
+
+// ansi wraps text in the given SGR codes when colors are enabled
+func ansi(enabled bool, text string, codes ...string) string {
+	if !enabled || text == "" || len(codes) == 0 {
+		return text
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m"
+}
+
+// visibleWidth returns the number of terminal cells used by text, ignoring ANSI escapes
+func visibleWidth(text string) int {
+	return uniseg.StringWidth(ansiPattern.ReplaceAllString(text, ""))
+}
+
+// fitWidth truncates or pads text (which may contain ANSI escapes) to exactly width cells
+func fitWidth(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	used := 0
+	truncated := false
+	for len(text) > 0 {
+		if loc := ansiPattern.FindStringIndex(text); loc != nil && loc[0] == 0 {
+			builder.WriteString(text[:loc[1]])
+			text = text[loc[1]:]
+			continue
+		}
+
+		cluster, rest, w, _ := uniseg.FirstGraphemeClusterInString(text, -1)
+		if used+w > width {
+			truncated = true
+			break
+		}
+		builder.WriteString(cluster)
+		used += w
+		text = rest
+	}
+
+	if truncated || strings.Contains(builder.String(), "\x1b[") {
+		builder.WriteString("\x1b[0m")
+	}
+	if used < width {
+		builder.WriteString(strings.Repeat(" ", width-used))
+	}
+	return builder.String()
+}
+
+// keepEnd returns the longest end of plain text that fits in width cells
+func keepEnd(text string, width int) string {
+	var clusters []string
+	var widths []int
+	state := -1
+	for len(text) > 0 {
+		var cluster string
+		var w int
+		cluster, text, w, state = uniseg.FirstGraphemeClusterInString(text, state)
+		clusters = append(clusters, cluster)
+		widths = append(widths, w)
+	}
+
+	start, used := len(clusters), 0
+	for start > 0 && used+widths[start-1] <= width {
+		start--
+		used += widths[start]
+	}
+	return strings.Join(clusters[start:], "")
+}
+
+// wrapText breaks plain text into lines no wider than width, splitting on spaces
+func wrapText(text string, width int) []string {
+	if width <= 0 || uniseg.StringWidth(text) <= width {
+		return []string{text}
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, word := range strings.Fields(text) {
+		wordWidth := uniseg.StringWidth(word)
+		if lineWidth > 0 && lineWidth+1+wordWidth > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 {
+			line.WriteString(" ")
+			lineWidth++
+		}
+		line.WriteString(word)
+		lineWidth += wordWidth
+	}
+	if line.Len() > 0 {
+		lines = append(lines, line.String())
+	}
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
+}
+
+// renderInline applies bold, inline code and link styling to a single line
+func renderInline(text string, colors bool) string {
+	text = inlineCodePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return ansi(colors, match[1:len(match)-1], sgrYellow)
+	})
+	text = boldPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return ansi(colors, match[2:len(match)-2], sgrBold)
+	})
+	text = linkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := linkPattern.FindStringSubmatch(match)
+		return ansi(colors, parts[1], sgrUnderline, sgrBlue)
+	})
+	return text
+}
+
+// RenderMarkdown renders markdown into terminal lines no wider than width
+func RenderMarkdown(markdown string, width int, colors bool) []string {
+	var out []string
+	inCode := false
+
+	for _, raw := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(raw)
+
+		// Fenced code blocks are shown verbatim
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			out = append(out, ansi(colors, strings.Repeat("─", min(width, 40)), sgrFaint))
+			continue
+		}
+		if inCode {
+			out = append(out, ansi(colors, "  "+strings.ReplaceAll(raw, "\t", "    "), sgrYellow))
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			out = append(out, "")
+
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			out = append(out, ansi(colors, strings.Repeat("─", width), sgrFaint))
+
+		case strings.HasPrefix(trimmed, "#"):
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			text := strings.TrimSpace(trimmed[level:])
+			codes := []string{sgrBold}
+			switch level {
+			case 1:
+				codes = append(codes, sgrUnderline, sgrCyan)
+			case 2:
+				codes = append(codes, sgrCyan)
+			}
+			for _, line := range wrapText(text, width) {
+				out = append(out, ansi(colors, line, codes...))
+			}
+
+		case strings.HasPrefix(trimmed, ">"):
+			text := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			for _, line := range wrapText(text, width-2) {
+				out = append(out, ansi(colors, "│ ", sgrFaint)+ansi(colors, renderInline(line, colors), sgrItalic))
+			}
+
+		default:
+			indent := strings.Repeat(" ", len(raw)-len(strings.TrimLeft(raw, " \t")))
+			marker, text := listMarker(trimmed)
+			prefix := indent + marker
+			hanging := strings.Repeat(" ", uniseg.StringWidth(prefix))
+			for i, line := range wrapText(text, width-uniseg.StringWidth(prefix)) {
+				lead := hanging
+				if i == 0 {
+					lead = prefix
+				}
+				out = append(out, lead+renderInline(line, colors))
+			}
+		}
+	}
+
+	return out
+}
+
+// listMarker splits a list item into its rendered marker and text
+func listMarker(line string) (string, string) {
+	switch {
+	case strings.HasPrefix(line, "- [x] ") || strings.HasPrefix(line, "- [X] "):
+		return "☑ ", line[6:]
+	case strings.HasPrefix(line, "- [ ] "):
+		return "☐ ", line[6:]
+	case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ "):
+		return "• ", line[2:]
+	}
+	if match := orderedPattern.FindStringSubmatch(line); match != nil {
+		return match[1] + " ", match[2]
+	}
+	return "", line
+}
@@ -0,0 +1,456 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrReviewAborted is returned by Review when the user quits without accepting
+var ErrReviewAborted = errors.New("review aborted by user")
+
+// ReviewFile is a changed file shown in the review screen
+type ReviewFile struct {
+	Path      string
+	Additions int
+	Deletions int
+	Patch     string
+}
+
+// RegenerateFunc generates a new title and body while ignoring the excluded paths
+type RegenerateFunc func(excluded []string) (title, body string, err error)
+
+// reviewPane identifies a pane of the review screen
+type reviewPane int
+
+const (
+	paneFiles reviewPane = iota
+	paneDiff
+	panePreview
+)
+
+// reviewAction is the outcome of a key press
+type reviewAction int
+
+const (
+	actionNone reviewAction = iota
+	actionAccept
+	actionQuit
+	actionRegenerate
+)
+
+// Key names produced by readKey
+const (
+	keyUp       = "up"
+	keyDown     = "down"
+	keyPageUp   = "pgup"
+	keyPageDown = "pgdn"
+	keyHome     = "home"
+	keyEnd      = "end"
+	keyTab      = "tab"
+	keyEnter    = "enter"
+	keyEscape   = "esc"
+	keyCtrlC    = "ctrl+c"
+)
+
+const reviewHelp = "↑↓ move  space exclude  tab focus  d diff  p preview  r regenerate  enter accept  q quit"
+
+// Review opens a full-screen review of the changed files and the generated description.
+// Files can be excluded from the AI context and the description regenerated in place.
+// It returns the accepted title and body. When stdin or the UI output is not a terminal,
+// it falls back to the plain output and returns the input unchanged.
+func (ui *UI) Review(files []ReviewFile, title, body string, regenerate RegenerateFunc) (string, string, error) {
+	out, ok := ui.output.(*os.File)
+	if !ok || !isTerminal(os.Stdin) || !isTerminal(out) {
+		ui.Warning("Interactive review requires a terminal, showing plain output instead")
+		return title, body, nil
+	}
+
+	inFd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return title, body, fmt.Errorf("failed to enable raw terminal mode: %w", err)
+	}
+	defer term.Restore(inFd, state)
+
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	model := newReviewModel(files, title, body, ui.colors)
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		model.resize(width, height)
+		ui.drawScreen(out, model.render())
+
+		key, err := readKey(reader)
+		if err != nil {
+			return title, body, fmt.Errorf("failed to read key: %w", err)
+		}
+
+		switch model.handleKey(key) {
+		case actionAccept:
+			return model.title, model.body, nil
+		case actionQuit:
+			return title, body, ErrReviewAborted
+		case actionRegenerate:
+			model.status = "⏳ Regenerating description..."
+			ui.drawScreen(out, model.render())
+
+			newTitle, newBody, err := regenerate(model.excludedPaths())
+			if err != nil {
+				model.status = fmt.Sprintf("Regeneration failed: %v", err)
+				continue
+			}
+			model.title = newTitle
+			model.body = newBody
+			model.right = panePreview
+			model.scroll = 0
+			model.status = fmt.Sprintf("Regenerated using %d of %d files", model.includedCount(), len(model.files))
+		}
+	}
+}
+
+// drawScreen repaints the whole terminal with the given lines
+func (ui *UI) drawScreen(out *os.File, lines []string) {
+	var builder strings.Builder
+	builder.WriteString("\x1b[H")
+	for i, line := range lines {
+		builder.WriteString(line)
+		builder.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			builder.WriteString("\r\n")
+		}
+	}
+	fmt.Fprint(out, builder.String())
+}
+
+// readKey reads a single key press from a terminal in raw mode
+func readKey(reader *bufio.Reader) (string, error) {
+	b, err := reader.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	switch b {
+	case 3:
+		return keyCtrlC, nil
+	case '\t':
+		return keyTab, nil
+	case '\r', '\n':
+		return keyEnter, nil
+	case 0x1b:
+		if reader.Buffered() == 0 {
+			return keyEscape, nil
+		}
+		seq := []byte{}
+		for reader.Buffered() > 0 {
+			c, _ := reader.ReadByte()
+			seq = append(seq, c)
+			if (c >= 'A' && c <= 'Z' && len(seq) > 1) || c == '~' {
+				break
+			}
+		}
+		switch string(seq) {
+		case "[A", "OA":
+			return keyUp, nil
+		case "[B", "OB":
+			return keyDown, nil
+		case "[5~":
+			return keyPageUp, nil
+		case "[6~":
+			return keyPageDown, nil
+		case "[H", "OH", "[1~":
+			return keyHome, nil
+		case "[F", "OF", "[4~":
+			return keyEnd, nil
+		}
+		return "", nil
+	}
+
+	// Decode multi-byte UTF-8 runes so they don't leak into later reads
+	if b >= 0x80 {
+		reader.UnreadByte()
+		r, _, err := reader.ReadRune()
+		return string(r), err
+	}
+	return string(b), nil
+}
+
+// reviewModel holds the state of the review screen independently of the terminal
+type reviewModel struct {
+	files      []ReviewFile
+	excluded   map[string]bool
+	cursor     int
+	fileOffset int
+	focus      reviewPane
+	right      reviewPane
+	scroll     int
+	title      string
+	body       string
+	status     string
+	colors     bool
+	width      int
+	height     int
+}
+
+// newReviewModel creates the review state showing the description preview first
+func newReviewModel(files []ReviewFile, title, body string, colors bool) *reviewModel {
+	return &reviewModel{
+		files:    files,
+		excluded: make(map[string]bool),
+		focus:    paneFiles,
+		right:    panePreview,
+		title:    title,
+		body:     body,
+		colors:   colors,
+		width:    80,
+		height:   24,
+	}
+}
+
+// resize updates the screen dimensions
+func (m *reviewModel) resize(width, height int) {
+	m.width = max(width, 20)
+	m.height = max(height, 5)
+}
+
+// contentHeight is the number of rows available to the panes
+func (m *reviewModel) contentHeight() int {
+	// header, pane titles and status bar
+	return m.height - 3
+}
+
+// handleKey updates the state for a key press and returns the resulting action
+func (m *reviewModel) handleKey(key string) reviewAction {
+	page := max(m.contentHeight()-1, 1)
+
+	switch key {
+	case keyCtrlC, keyEscape, "q":
+		return actionQuit
+	case keyEnter, "a":
+		return actionAccept
+	case keyTab:
+		if m.focus == paneFiles {
+			m.focus = m.right
+		} else {
+			m.focus = paneFiles
+		}
+	case "d":
+		m.showRight(paneDiff)
+	case "p":
+		m.showRight(panePreview)
+	case " ", "x":
+		if len(m.files) > 0 {
+			path := m.files[m.cursor].Path
+			m.excluded[path] = !m.excluded[path]
+			m.status = fmt.Sprintf("%d of %d files included — press r to regenerate", m.includedCount(), len(m.files))
+		}
+	case "r":
+		if len(m.files) > 0 && m.includedCount() == 0 {
+			m.status = "Cannot regenerate with every file excluded"
+			return actionNone
+		}
+		return actionRegenerate
+	case keyUp, "k":
+		m.move(-1)
+	case keyDown, "j":
+		m.move(1)
+	case keyPageUp:
+		m.scrollBy(-page)
+	case keyPageDown:
+		m.scrollBy(page)
+	case keyHome, "g":
+		m.scroll = 0
+	case keyEnd, "G":
+		m.scrollBy(len(m.rightLines()))
+	}
+	return actionNone
+}
+
+// showRight switches the right pane between diff and preview
+func (m *reviewModel) showRight(pane reviewPane) {
+	m.right = pane
+	m.scroll = 0
+	if m.focus != paneFiles {
+		m.focus = pane
+	}
+}
+
+// move moves the file cursor or scrolls the right pane depending on focus
+func (m *reviewModel) move(delta int) {
+	if m.focus != paneFiles {
+		m.scrollBy(delta)
+		return
+	}
+	if len(m.files) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.files)-1)
+	if m.right == paneDiff {
+		m.scroll = 0
+	}
+}
+
+// scrollBy scrolls the right pane, clamped to its content
+func (m *reviewModel) scrollBy(delta int) {
+	maxScroll := max(len(m.rightLines())-m.contentHeight(), 0)
+	m.scroll = min(max(m.scroll+delta, 0), maxScroll)
+}
+
+// includedCount returns how many files are still part of the AI context
+func (m *reviewModel) includedCount() int {
+	count := 0
+	for _, file := range m.files {
+		if !m.excluded[file.Path] {
+			count++
+		}
+	}
+	return count
+}
+
+// excludedPaths returns the excluded paths in file order
+func (m *reviewModel) excludedPaths() []string {
+	var paths []string
+	for _, file := range m.files {
+		if m.excluded[file.Path] {
+			paths = append(paths, file.Path)
+		}
+	}
+	return paths
+}
+
+// layout returns the widths of the file list and the right pane
+func (m *reviewModel) layout() (int, int) {
+	left := min(max(m.width/3, 24), 50)
+	if m.width < 60 {
+		left = m.width / 2
+	}
+	return left, m.width - left - 1
+}
+
+// rightLines returns the content of the right pane
+func (m *reviewModel) rightLines() []string {
+	_, width := m.layout()
+
+	if m.right == panePreview {
+		return RenderMarkdown("# "+m.title+"\n\n"+m.body, width-1, m.colors)
+	}
+
+	if len(m.files) == 0 {
+		return []string{"No changed files"}
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(m.files[m.cursor].Patch, "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff --git"):
+			lines = append(lines, ansi(m.colors, line, sgrBold))
+		case strings.HasPrefix(line, "@@"):
+			lines = append(lines, ansi(m.colors, line, sgrCyan))
+		case strings.HasPrefix(line, "+"):
+			lines = append(lines, ansi(m.colors, line, sgrGreen))
+		case strings.HasPrefix(line, "-"):
+			lines = append(lines, ansi(m.colors, line, sgrRed))
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// render draws the whole screen into lines of exactly the screen width
+func (m *reviewModel) render() []string {
+	leftWidth, rightWidth := m.layout()
+	rows := m.contentHeight()
+	lines := make([]string, 0, m.height)
+
+	// Header
+	lines = append(lines, ansi(m.colors, fitWidth(" PullPoet Review — "+m.title, m.width), sgrReverse, sgrBold))
+
+	// Pane titles
+	leftTitle := fmt.Sprintf(" Files (%d/%d included)", m.includedCount(), len(m.files))
+	rightTitle := " Preview"
+	if m.right == paneDiff && len(m.files) > 0 {
+		rightTitle = " Diff: " + m.files[m.cursor].Path
+	}
+	leftCodes, rightCodes := []string{sgrBold}, []string{sgrBold}
+	if m.focus == paneFiles {
+		leftCodes = append(leftCodes, sgrCyan)
+	} else {
+		rightCodes = append(rightCodes, sgrCyan)
+	}
+	lines = append(lines, ansi(m.colors, fitWidth(leftTitle, leftWidth), leftCodes...)+"│"+ansi(m.colors, fitWidth(rightTitle, rightWidth), rightCodes...))
+
+	// Keep the cursor visible in the file list
+	if m.cursor < m.fileOffset {
+		m.fileOffset = m.cursor
+	}
+	if m.cursor >= m.fileOffset+rows {
+		m.fileOffset = m.cursor - rows + 1
+	}
+
+	right := m.rightLines()
+	for row := 0; row < rows; row++ {
+		left := ""
+		if idx := m.fileOffset + row; idx < len(m.files) {
+			left = m.fileLine(idx, leftWidth)
+		}
+		content := ""
+		if idx := m.scroll + row; idx < len(right) {
+			content = " " + right[idx]
+		}
+		lines = append(lines, fitWidth(left, leftWidth)+ansi(m.colors, "│", sgrFaint)+fitWidth(content, rightWidth))
+	}
+
+	// Status bar
+	status := m.status
+	if status == "" {
+		status = reviewHelp
+	}
+	lines = append(lines, ansi(m.colors, fitWidth(" "+status, m.width), sgrReverse))
+
+	return lines
+}
+
+// fileLine renders a single entry of the file list
+func (m *reviewModel) fileLine(idx, width int) string {
+	file := m.files[idx]
+
+	marker := "●"
+	if m.excluded[file.Path] {
+		marker = "○"
+	}
+	cursor := " "
+	if idx == m.cursor {
+		cursor = "▸"
+	}
+
+	counts := fmt.Sprintf("+%d -%d", file.Additions, file.Deletions)
+	pathWidth := width - visibleWidth(counts) - 5
+	path := file.Path
+	if visibleWidth(path) > pathWidth && pathWidth > 1 {
+		// Keep the end of long paths, it is the most informative part
+		path = "…" + keepEnd(path, pathWidth-1)
+	}
+
+	line := fmt.Sprintf("%s%s %s ", cursor, marker, fitWidth(path, max(pathWidth, 0))) +
+		ansi(m.colors, fmt.Sprintf("+%d", file.Additions), sgrGreen) + " " +
+		ansi(m.colors, fmt.Sprintf("-%d", file.Deletions), sgrRed)
+
+	switch {
+	case idx == m.cursor && m.focus == paneFiles:
+		return ansi(m.colors, fitWidth(ansiPattern.ReplaceAllString(line, ""), width), sgrReverse)
+	case m.excluded[file.Path]:
+		return ansi(m.colors, ansiPattern.ReplaceAllString(line, ""), sgrFaint)
+	}
+	return line
+}
@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func reviewFiles() []ReviewFile {
+	return []ReviewFile{
+		{Path: "cmd/main.go", Additions: 12, Deletions: 3, Patch: "diff --git a/cmd/main.go b/cmd/main.go\n@@ -1 +1 @@\n-old\n+new\n"},
+		{Path: "internal/ui/review.go", Additions: 40, Deletions: 0, Patch: "+package ui\n"},
+		{Path: "go.sum", Additions: 2, Deletions: 2, Patch: "+sum\n"},
+	}
+}
+
+func TestReviewModelHandleKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        []ReviewFile
+		keys         []string
+		wantAction   reviewAction
+		wantCursor   int
+		wantFocus    reviewPane
+		wantRight    reviewPane
+		wantExcluded []string
+		wantStatus   string
+	}{
+		{name: "enter accepts", keys: []string{keyEnter}, wantAction: actionAccept, wantRight: panePreview},
+		{name: "a accepts", keys: []string{"a"}, wantAction: actionAccept, wantRight: panePreview},
+		{name: "q quits", keys: []string{"q"}, wantAction: actionQuit, wantRight: panePreview},
+		{name: "escape quits", keys: []string{keyEscape}, wantAction: actionQuit, wantRight: panePreview},
+		{name: "ctrl+c quits", keys: []string{keyCtrlC}, wantAction: actionQuit, wantRight: panePreview},
+		{name: "r regenerates", keys: []string{"r"}, wantAction: actionRegenerate, wantRight: panePreview},
+		{
+			name:       "cursor stays within the files",
+			keys:       []string{keyDown, "j", keyDown, keyDown},
+			wantCursor: 2,
+			wantRight:  panePreview,
+		},
+		{
+			name:       "cursor stops at the first file",
+			keys:       []string{keyDown, keyUp, "k"},
+			wantCursor: 0,
+			wantRight:  panePreview,
+		},
+		{
+			name:         "space excludes the file under the cursor",
+			keys:         []string{keyDown, " "},
+			wantCursor:   1,
+			wantRight:    panePreview,
+			wantExcluded: []string{"internal/ui/review.go"},
+			wantStatus:   "2 of 3 files included — press r to regenerate",
+		},
+		{
+			name:       "x toggles the exclusion back",
+			keys:       []string{"x", "x"},
+			wantRight:  panePreview,
+			wantStatus: "3 of 3 files included — press r to regenerate",
+		},
+		{
+			name:         "regenerate after excluding files",
+			keys:         []string{" ", "r"},
+			wantAction:   actionRegenerate,
+			wantRight:    panePreview,
+			wantExcluded: []string{"cmd/main.go"},
+			wantStatus:   "2 of 3 files included — press r to regenerate",
+		},
+		{
+			name:         "regenerate is refused with every file excluded",
+			keys:         []string{" ", keyDown, " ", keyDown, " ", "r"},
+			wantCursor:   2,
+			wantRight:    panePreview,
+			wantExcluded: []string{"cmd/main.go", "internal/ui/review.go", "go.sum"},
+			wantStatus:   "Cannot regenerate with every file excluded",
+		},
+		{
+			name:       "regenerate without files",
+			files:      []ReviewFile{},
+			keys:       []string{" ", "r"},
+			wantAction: actionRegenerate,
+			wantRight:  panePreview,
+		},
+		{
+			name:      "d shows the diff",
+			keys:      []string{"d"},
+			wantRight: paneDiff,
+		},
+		{
+			name:      "tab focuses the right pane",
+			keys:      []string{"d", keyTab},
+			wantFocus: paneDiff,
+			wantRight: paneDiff,
+		},
+		{
+			name:      "switching panes keeps the focus on the right",
+			keys:      []string{keyTab, "d", "p"},
+			wantFocus: panePreview,
+			wantRight: panePreview,
+		},
+		{
+			name:      "tab returns to the files",
+			keys:      []string{keyTab, keyTab},
+			wantFocus: paneFiles,
+			wantRight: panePreview,
+		},
+		{
+			name:      "moving with the right pane focused does not move the cursor",
+			keys:      []string{keyTab, keyDown, keyDown},
+			wantFocus: panePreview,
+			wantRight: panePreview,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := tt.files
+			if files == nil {
+				files = reviewFiles()
+			}
+			model := newReviewModel(files, "Add review screen", "## Summary\nAdds the review screen", false)
+
+			action := actionNone
+			for _, key := range tt.keys {
+				action = model.handleKey(key)
+			}
+
+			if action != tt.wantAction {
+				t.Errorf("action = %v, want %v", action, tt.wantAction)
+			}
+			if model.cursor != tt.wantCursor {
+				t.Errorf("cursor = %d, want %d", model.cursor, tt.wantCursor)
+			}
+			if model.focus != tt.wantFocus {
+				t.Errorf("focus = %v, want %v", model.focus, tt.wantFocus)
+			}
+			if model.right != tt.wantRight {
+				t.Errorf("right pane = %v, want %v", model.right, tt.wantRight)
+			}
+			if got := model.excludedPaths(); !reflect.DeepEqual(got, tt.wantExcluded) {
+				t.Errorf("excluded = %q, want %q", got, tt.wantExcluded)
+			}
+			if model.status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", model.status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReviewModelFileLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		idx      int
+		width    int
+		excluded bool
+		want     string
+	}{
+		{name: "cursor", idx: 0, width: 30, want: "▸● cmd/main.go         +12 -3 "},
+		{name: "included", idx: 2, width: 30, want: " ● go.sum               +2 -2"},
+		{name: "excluded", idx: 2, width: 30, excluded: true, want: " ○ go.sum               +2 -2"},
+		{name: "long path keeps its end", idx: 1, width: 24, want: " ● …ui/review.go +40 -0"},
+		{name: "wide characters are cut by cell width", idx: 3, width: 24, want: " ● …本語日本語.md +1 -0"},
+		{name: "wide characters do not split a cell", idx: 3, width: 25, want: " ● …本語日本語.md  +1 -0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := append(reviewFiles(), ReviewFile{Path: "docs/日本語日本語日本語.md", Additions: 1})
+			model := newReviewModel(files, "Title", "Body", false)
+			if tt.excluded {
+				model.excluded[model.files[tt.idx].Path] = true
+			}
+			if got := model.fileLine(tt.idx, tt.width); got != tt.want {
+				t.Errorf("fileLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewModelRender(t *testing.T) {
+	model := newReviewModel(reviewFiles(), "Add review screen", "## Summary\nAdds the review screen", false)
+	model.resize(80, 10)
+
+	lines := model.render()
+	if len(lines) != 10 {
+		t.Fatalf("render() = %d lines, want 10", len(lines))
+	}
+	for i, line := range lines {
+		if width := visibleWidth(line); width != 80 {
+			t.Errorf("line %d is %d columns wide, want 80: %q", i, width, line)
+		}
+	}
+	if !strings.Contains(lines[0], "PullPoet Review — Add review screen") {
+		t.Errorf("header = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "Files (3/3 included)") || !strings.Contains(lines[1], "Preview") {
+		t.Errorf("pane titles = %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[len(lines)-1], " ↑↓ move  space exclude") {
+		t.Errorf("status bar = %q, want the key help", lines[len(lines)-1])
+	}
+
+	model.handleKey("d")
+	lines = model.render()
+	if !strings.Contains(lines[1], "Diff: cmd/main.go") || !strings.Contains(strings.Join(lines, "\n"), "+new") {
+		t.Errorf("diff pane does not show the patch of the selected file:\n%s", strings.Join(lines, "\n"))
+	}
+}
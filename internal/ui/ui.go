@@ -19,6 +19,8 @@ type UI struct {
 	verbose      bool
 	theme        string
 	output       io.Writer
+	// wantColors is the configured color preference before terminal detection
+	wantColors bool
 }
 
 // Config holds UI configuration
@@ -43,23 +45,29 @@ func DefaultConfig() Config {
 
 // New creates a new UI instance
 func New(config Config) *UI {
-	// Auto-detect if colors should be disabled
-	colors := config.Colors
-	if config.Theme == "auto" {
-		// Disable colors if not a terminal or NO_COLOR is set
-		if os.Getenv("NO_COLOR") != "" || !isTerminal(os.Stdout) {
-			colors = false
-		}
-	}
-
 	return &UI{
-		colors:       colors,
+		colors:       detectColors(config.Colors, config.Theme, os.Stdout),
 		progressBars: config.ProgressBars,
 		emoji:        config.Emoji,
 		verbose:      config.Verbose,
 		theme:        config.Theme,
 		output:       os.Stdout,
+		wantColors:   config.Colors,
+	}
+}
+
+// SetOutput redirects all UI output to w, e.g. os.Stderr when stdout carries machine-readable output
+func (ui *UI) SetOutput(w io.Writer) {
+	ui.output = w
+	ui.colors = detectColors(ui.wantColors, ui.theme, w)
+}
+
+// detectColors disables colors in auto theme when w is not a terminal or NO_COLOR is set
+func detectColors(colors bool, theme string, w io.Writer) bool {
+	if theme == "auto" && (os.Getenv("NO_COLOR") != "" || !isTerminal(w)) {
+		return false
 	}
+	return colors
 }
 
 // isTerminal checks if the writer is a terminal
@@ -0,0 +1,317 @@
+// Package pullpoet generates pull request titles and descriptions from git changes.
+//
+// It is the public API for embedding pullpoet in other Go programs:
+//
+//	gen, err := pullpoet.New(
+//		pullpoet.WithConfig(pullpoet.Config{Provider: "ollama", Model: "llama3"}),
+//		pullpoet.WithProgress(pullpoet.ProgressFunc(func(e pullpoet.Event) { log.Println(e.Message) })),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	result, err := gen.Generate(pullpoet.Request{Repo: "https://github.com/org/repo.git", Source: "feature", Target: "main"})
+//
+// The package never writes to stdout; progress is reported through the Progress interface.
+package pullpoet
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/erkineren/pullpoet/config"
+	"github.com/erkineren/pullpoet/internal/ai"
+	"github.com/erkineren/pullpoet/internal/git"
+	"github.com/erkineren/pullpoet/internal/logger"
+	"github.com/erkineren/pullpoet/internal/pr"
+	"github.com/erkineren/pullpoet/internal/redact"
+	"github.com/erkineren/pullpoet/internal/reviewers"
+)
+
+// Types shared with the pullpoet internals
+type (
+	// AIClient is the interface implemented by AI providers; supply your own with WithAIClient
+	AIClient = ai.Client
+	// AIRequest is the structured request passed to AIClient.Complete
+	AIRequest = ai.Request
+	// Schema is the JSON schema of the structured output in an AIRequest
+	Schema = ai.Schema
+	// Usage holds the token counts reported by the provider
+	Usage = ai.Usage
+	// GitResult holds a unified diff and the commits it covers
+	GitResult = git.GitResult
+	// CommitInfo describes a single commit of a GitResult
+	CommitInfo = git.CommitInfo
+	// Redaction describes a secret that was replaced before the prompt was sent
+	Redaction = redact.Finding
+	// Risk is the estimated risk of merging a change
+	Risk = pr.Risk
+	// Reviewer is a reviewer suggested from CODEOWNERS and the git history
+	Reviewer = reviewers.Reviewer
+	// PolicyConfig holds the rules for the generated title and body, set as Config.Policy
+	PolicyConfig = config.PolicyConfig
+	// PolicyError is returned when the description still violates the policy after all repairs
+	PolicyError = pr.PolicyError
+)
+
+// Config holds the provider, model, prompt and generation settings
+type Config struct {
+	// Provider is ollama, openai, openwebui or gemini
+	Provider string
+	Model    string
+	APIKey   string
+	// ProviderBaseURL overrides the default API URL of the provider
+	ProviderBaseURL string
+	// SystemPrompt is the path of a custom prompt template, the embedded one is used if empty
+	SystemPrompt string
+	// Language of the generated description, "en" if empty
+	Language string
+	// Labels the AI may suggest, none are suggested if empty
+	Labels []string
+	// Policy constrains the generated title and body, nil if none
+	Policy *PolicyConfig
+	// ReviewersDisabled turns off the reviewer suggestions
+	ReviewersDisabled bool
+	// MaxReviewers is the number of suggested reviewers, 5 if zero
+	MaxReviewers int
+	// ReviewerHalfLife is the age at which a commit counts half, 90 days if zero
+	ReviewerHalfLife time.Duration
+	// ExcludeReviewers are names, emails or handles never suggested as reviewers
+	ExcludeReviewers []string
+}
+
+// settings converts the config to the settings of the internal packages
+func (c Config) settings() *config.Config {
+	return &config.Config{
+		Provider:          c.Provider,
+		Model:             c.Model,
+		APIKey:            c.APIKey,
+		ProviderBaseURL:   c.ProviderBaseURL,
+		SystemPrompt:      c.SystemPrompt,
+		Language:          c.Language,
+		Labels:            c.Labels,
+		Policy:            c.Policy,
+		ReviewersDisabled: c.ReviewersDisabled,
+		ReviewersMax:      c.MaxReviewers,
+		ReviewersHalfLife: c.ReviewerHalfLife,
+		ReviewersExclude:  c.ExcludeReviewers,
+	}
+}
+
+// Stage identifies the part of the pipeline that reported progress
+type Stage string
+
+// Pipeline stages
+const (
+	StageGit      Stage = "git"
+	StageGenerate Stage = "generate"
+)
+
+// Event is a single progress message
+type Event struct {
+	Stage   Stage
+	Message string
+}
+
+// Progress receives progress events while a description is generated
+type Progress interface {
+	OnProgress(event Event)
+}
+
+// ProgressFunc adapts a plain function to the Progress interface
+type ProgressFunc func(event Event)
+
+// OnProgress calls f
+func (f ProgressFunc) OnProgress(event Event) {
+	f(event)
+}
+
+// FileStat summarizes the changes of a single file
+type FileStat struct {
+	Path      string
+	OldPath   string
+	Additions int
+	Deletions int
+	Binary    bool
+}
+
+// Request selects the changes to describe. Set GitResult to describe a diff you
+// already have, otherwise Repo is cloned and Source is compared with Target.
+type Request struct {
+	Repo      string
+	Source    string
+	Target    string
+	GitResult *GitResult
+	// IssueContext is optional task or issue text added to the prompt
+	IssueContext string
+	// AddSignature appends the "generated by pullpoet" footer to the body
+	AddSignature bool
+}
+
+// Result is the generated description together with the analyzed changes
+type Result struct {
+	Title string
+	Body  string
+	// Markdown is Body with the structured fields rendered as sections, as it is published
+	Markdown string
+	// Labels are suggested from Config.Labels
+	Labels          []string
+	ChangeType      string
+	Risk            *Risk
+	BreakingChanges []string
+	MigrationNotes  string
+	TestPlan        []string
+	// Reviewers are suggested locally unless Config.ReviewersDisabled is set
+	Reviewers []Reviewer
+	Usage     Usage
+	Commits   []CommitInfo
+	Files     []FileStat
+	// Redactions lists the secrets replaced by the built-in detectors
+	Redactions []Redaction
+}
+
+// Option configures a Generator
+type Option func(*Generator)
+
+// WithConfig sets the provider, model, system prompt and language
+func WithConfig(cfg Config) Option {
+	return func(g *Generator) {
+		g.cfg = cfg.settings()
+	}
+}
+
+// WithAIClient uses a custom AI client instead of creating one from the config
+func WithAIClient(client AIClient) Option {
+	return func(g *Generator) {
+		g.aiClient = client
+	}
+}
+
+// WithProgress reports progress events to p
+func WithProgress(p Progress) Option {
+	return func(g *Generator) {
+		g.progress = p
+	}
+}
+
+// WithFastMode uses native git commands instead of go-git to clone repositories
+func WithFastMode(fast bool) Option {
+	return func(g *Generator) {
+		g.fast = fast
+	}
+}
+
+// Generator generates pull request descriptions
+type Generator struct {
+	cfg      *config.Config
+	aiClient ai.Client
+	progress Progress
+	fast     bool
+}
+
+// New creates a generator; a provider and model are required unless WithAIClient is used
+func New(opts ...Option) (*Generator, error) {
+	g := &Generator{cfg: &config.Config{}}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.cfg.Language == "" {
+		g.cfg.Language = "en"
+	}
+
+	if g.aiClient == nil {
+		if g.cfg.Provider == "" || g.cfg.Model == "" {
+			return nil, fmt.Errorf("provider and model are required unless a custom AI client is supplied")
+		}
+		client, err := ai.New(g.cfg.Provider, g.cfg.GetProviderBaseURL(), g.cfg.APIKey, g.cfg.Model, g.logger(StageGenerate))
+		if err != nil {
+			return nil, err
+		}
+		g.aiClient = client
+	}
+	return g, nil
+}
+
+// Generate describes the requested changes
+func (g *Generator) Generate(req Request) (*Result, error) {
+	repoURL, source, target := req.Repo, req.Source, req.Target
+
+	gitResult := req.GitResult
+	if gitResult == nil {
+		if repoURL == "" || source == "" || target == "" {
+			return nil, fmt.Errorf("repo, source and target are required when no GitResult is supplied")
+		}
+
+		var err error
+		if g.fast {
+			client := git.NewFastClient()
+			client.SetLogger(g.logger(StageGit))
+			gitResult, err = client.GetDiffWithCommits(repoURL, source, target)
+		} else {
+			client := git.NewClient()
+			client.SetLogger(g.logger(StageGit))
+			gitResult, err = client.GetDiffWithCommits(repoURL, source, target)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze git changes: %w", err)
+		}
+	}
+
+	generator := pr.NewGenerator(g.aiClient, g.cfg.SystemPrompt)
+	generator.SetLogger(g.logger(StageGenerate))
+	generator.SetLabels(g.cfg.Labels)
+	policy, err := pr.NewPolicy(g.cfg.Policy, nil)
+	if err != nil {
+		return nil, err
+	}
+	generator.SetPolicy(policy)
+	generated, err := generator.Generate(gitResult, req.IssueContext, repoURL, g.cfg.Language, req.AddSignature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate PR description: %w", err)
+	}
+
+	result := &Result{
+		Title:           generated.Title,
+		Body:            generated.Body,
+		Markdown:        generated.Markdown(),
+		Labels:          generated.Labels,
+		ChangeType:      generated.ChangeType,
+		Risk:            generated.Risk,
+		BreakingChanges: generated.BreakingChanges,
+		MigrationNotes:  generated.MigrationNotes,
+		TestPlan:        generated.TestPlan,
+		Usage:           generated.Usage,
+		Commits:         gitResult.Commits,
+		Files:           []FileStat{},
+		Redactions:      generated.Redactions,
+	}
+	if !g.cfg.ReviewersDisabled {
+		result.Reviewers = reviewers.Suggest(gitResult, reviewers.OptionsFromConfig(g.cfg))
+	}
+	for _, file := range git.SplitDiff(gitResult.Diff) {
+		stat := FileStat{
+			Path:      file.Path,
+			Additions: file.Additions,
+			Deletions: file.Deletions,
+			Binary:    file.Binary,
+		}
+		if file.OldPath != file.Path {
+			stat.OldPath = file.OldPath
+		}
+		result.Files = append(result.Files, stat)
+	}
+	return result, nil
+}
+
+// logger turns the progress messages of the internal packages into events
+func (g *Generator) logger(stage Stage) logger.Logger {
+	if g.progress == nil {
+		return logger.Discard
+	}
+	return logger.Func(func(format string, args ...interface{}) {
+		message := strings.TrimSpace(fmt.Sprintf(format, args...))
+		if message != "" {
+			g.progress.OnProgress(Event{Stage: stage, Message: message})
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package pullpoet
+
+import (
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeAIClient struct {
+	response string
+}
+
+func (c *fakeAIClient) Complete(request AIRequest) (string, error) {
+	return c.response, nil
+}
+
+func (c *fakeAIClient) GetProviderInfo() (string, string) {
+	return "fake", "fake-model"
+}
+
+const testDiff = `diff --git a/login.go b/login.go
+new file mode 100644
+--- /dev/null
++++ b/login.go
+@@ -0,0 +1,3 @@
++package demo
++
++func Login() {}
+`
+
+func TestGenerateFromGitResult(t *testing.T) {
+	var events []Event
+	gen, err := New(
+		WithAIClient(&fakeAIClient{response: `{"title": "Add login", "body": "Adds a login function"}`}),
+		WithProgress(ProgressFunc(func(e Event) { events = append(events, e) })),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Nothing may be written to stdout
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = writer
+	result, err := gen.Generate(Request{GitResult: &GitResult{Diff: testDiff}})
+	os.Stdout = stdout
+	writer.Close()
+	written, _ := io.ReadAll(reader)
+
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(written) > 0 {
+		t.Errorf("Generate() wrote to stdout: %q", written)
+	}
+	if result.Title != "Add login" || result.Body != "Adds a login function" {
+		t.Errorf("result = %q / %q", result.Title, result.Body)
+	}
+	if len(result.Files) != 1 || result.Files[0].Path != "login.go" || result.Files[0].Additions != 3 {
+		t.Errorf("Files = %+v, want login.go with 3 additions", result.Files)
+	}
+	if len(events) == 0 {
+		t.Fatal("no progress events reported")
+	}
+	for _, e := range events {
+		if e.Stage != StageGenerate || strings.HasSuffix(e.Message, "\n") {
+			t.Errorf("unexpected event %+v", e)
+		}
+	}
+}
+
+func TestNewAndGenerateErrors(t *testing.T) {
+	if _, err := New(); err == nil {
+		t.Error("New() without provider or AI client should fail")
+	}
+
+	gen, err := New(WithAIClient(&fakeAIClient{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := gen.Generate(Request{Repo: "https://example.com/repo.git"}); err == nil {
+		t.Error("Generate() without branches or GitResult should fail")
+	}
+}
+
+func TestGenerateWithConfig(t *testing.T) {
+	response := `{"title": "Add the login function", "body": "Adds a login function", "labels": ["feature"]}`
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		wantLabels []string
+		wantPolicy bool
+	}{
+		{name: "labels", cfg: Config{Labels: []string{"feature", "bug"}}, wantLabels: []string{"feature"}},
+		{name: "policy", cfg: Config{Policy: &PolicyConfig{TitleMaxLength: 10}}, wantPolicy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gen, err := New(WithConfig(tt.cfg), WithAIClient(&fakeAIClient{response: response}))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			result, err := gen.Generate(Request{GitResult: &GitResult{Diff: testDiff}})
+			var policyErr *PolicyError
+			if errors.As(err, &policyErr) != tt.wantPolicy {
+				t.Fatalf("Generate() error = %v, want a policy error: %v", err, tt.wantPolicy)
+			}
+			if tt.wantPolicy {
+				return
+			}
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if !reflect.DeepEqual(result.Labels, tt.wantLabels) {
+				t.Errorf("Labels = %v, want %v", result.Labels, tt.wantLabels)
+			}
+		})
+	}
+}